@@ -0,0 +1,250 @@
+package opengl_exercise
+
+import (
+	"math"
+)
+
+// AABB is an axis aligned bounding box given by its minimum and maximum corners
+type AABB struct {
+	Min, Max Vector
+}
+
+// NewAABB returns the smallest box that contains all the points, or an empty box without points
+func NewAABB(points []Vector) AABB {
+	box := EmptyAABB()
+	for _, point := range points {
+		box = box.AddPoint(point)
+	}
+
+	return box
+}
+
+// EmptyAABB returns a box that contains nothing, adding a point to it gives the box around just that point.
+// Its minimum is above its maximum, so it also fails every overlap test without special cases.
+func EmptyAABB() AABB {
+	return AABB{
+		Min: Vector{X: math.MaxFloat32, Y: math.MaxFloat32, Z: math.MaxFloat32},
+		Max: Vector{X: -math.MaxFloat32, Y: -math.MaxFloat32, Z: -math.MaxFloat32},
+	}
+}
+
+// IsEmpty tests whether the box contains no points at all
+func (b AABB) IsEmpty() bool {
+	return b.Min.X > b.Max.X || b.Min.Y > b.Max.Y || b.Min.Z > b.Max.Z
+}
+
+// AddPoint grows the box to contain a point
+func (b AABB) AddPoint(point Vector) AABB {
+	return AABB{
+		Min: Vector{
+			X: float32(math.Min(float64(b.Min.X), float64(point.X))),
+			Y: float32(math.Min(float64(b.Min.Y), float64(point.Y))),
+			Z: float32(math.Min(float64(b.Min.Z), float64(point.Z))),
+		},
+		Max: Vector{
+			X: float32(math.Max(float64(b.Max.X), float64(point.X))),
+			Y: float32(math.Max(float64(b.Max.Y), float64(point.Y))),
+			Z: float32(math.Max(float64(b.Max.Z), float64(point.Z))),
+		},
+	}
+}
+
+// Merge returns the smallest box that contains both boxes
+func (b AABB) Merge(other AABB) AABB {
+	// the corners of an empty box would stretch the other one
+	if other.IsEmpty() {
+		return b
+	}
+
+	return b.AddPoint(other.Min).AddPoint(other.Max)
+}
+
+func (b AABB) Center() Vector {
+	return b.Min.AddVector(b.Max).MultiplyScalar(0.5)
+}
+
+// Extents returns half the size of the box along each axis
+func (b AABB) Extents() Vector {
+	return b.Max.AddVector(b.Min.Negative()).MultiplyScalar(0.5)
+}
+
+func (b AABB) ContainsPoint(point Vector) bool {
+	return point.X >= b.Min.X && point.X <= b.Max.X &&
+		point.Y >= b.Min.Y && point.Y <= b.Max.Y &&
+		point.Z >= b.Min.Z && point.Z <= b.Max.Z
+}
+
+// Intersects tests whether two boxes overlap
+func (b AABB) Intersects(other AABB) bool {
+	return b.Min.X <= other.Max.X && b.Max.X >= other.Min.X &&
+		b.Min.Y <= other.Max.Y && b.Max.Y >= other.Min.Y &&
+		b.Min.Z <= other.Max.Z && b.Max.Z >= other.Min.Z
+}
+
+// Transform returns the axis aligned box around the transformed box
+func (b AABB) Transform(m *Matrix) AABB {
+	if b.IsEmpty() {
+		return b
+	}
+
+	// start from the translation and add the smallest and largest contribution of each matrix element
+	min := Vector{X: m[12], Y: m[13], Z: m[14]}
+	max := min
+
+	in := [3][2]float32{{b.Min.X, b.Max.X}, {b.Min.Y, b.Max.Y}, {b.Min.Z, b.Max.Z}}
+	out := [3]struct{ min, max *float32 }{{&min.X, &max.X}, {&min.Y, &max.Y}, {&min.Z, &max.Z}}
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			e := m[row*4+col] * in[row][0]
+			f := m[row*4+col] * in[row][1]
+			if e < f {
+				*out[col].min += e
+				*out[col].max += f
+			} else {
+				*out[col].min += f
+				*out[col].max += e
+			}
+		}
+	}
+
+	return AABB{Min: min, Max: max}
+}
+
+// IntersectRay returns the distance along the ray to where it enters the box, or 0 if it starts inside
+func (b AABB) IntersectRay(ray Ray) (float32, bool) {
+	if b.IsEmpty() {
+		return 0, false
+	}
+
+	// clip the ray against the three pairs of slabs that make up the box
+	tMin, tMax := 0.0, math.Inf(1)
+	origin := [3]float32{ray.Origin.X, ray.Origin.Y, ray.Origin.Z}
+	direction := [3]float32{ray.Direction.X, ray.Direction.Y, ray.Direction.Z}
+	min := [3]float32{b.Min.X, b.Min.Y, b.Min.Z}
+	max := [3]float32{b.Max.X, b.Max.Y, b.Max.Z}
+
+	for i := 0; i < 3; i++ {
+		if direction[i] == 0 {
+			// parallel to the slabs, so the origin must already be between them
+			if origin[i] < min[i] || origin[i] > max[i] {
+				return 0, false
+			}
+			continue
+		}
+
+		t1 := float64((min[i] - origin[i]) / direction[i])
+		t2 := float64((max[i] - origin[i]) / direction[i])
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+
+		tMin = math.Max(tMin, t1)
+		tMax = math.Min(tMax, t2)
+		if tMin > tMax {
+			return 0, false
+		}
+	}
+
+	return float32(tMin), true
+}
+
+// BoundingSphere is a sphere that contains a set of points
+type BoundingSphere struct {
+	Center Vector
+	Radius float32
+}
+
+// NewBoundingSphere returns a sphere centered on the box around the points that contains all of them
+func NewBoundingSphere(points []Vector) BoundingSphere {
+	if len(points) == 0 {
+		return BoundingSphere{}
+	}
+
+	center := NewAABB(points).Center()
+
+	var radiusSquared float32
+	for _, point := range points {
+		offset := point.AddVector(center.Negative())
+		radiusSquared = float32(math.Max(float64(radiusSquared), float64(offset.Dot(offset))))
+	}
+
+	return BoundingSphere{Center: center, Radius: float32(math.Sqrt(float64(radiusSquared)))}
+}
+
+// Merge returns the smallest sphere that contains both spheres
+func (s BoundingSphere) Merge(other BoundingSphere) BoundingSphere {
+	offset := other.Center.AddVector(s.Center.Negative())
+	distance := float32(math.Sqrt(float64(offset.Dot(offset))))
+
+	// one sphere already contains the other
+	if distance+other.Radius <= s.Radius {
+		return s
+	}
+	if distance+s.Radius <= other.Radius {
+		return other
+	}
+
+	radius := (distance + s.Radius + other.Radius) * 0.5
+	return BoundingSphere{
+		Center: s.Center.AddVector(offset.MultiplyScalar((radius - s.Radius) / distance)),
+		Radius: radius,
+	}
+}
+
+// Transform returns a sphere around the transformed sphere, non uniform scale makes it grow by the largest axis scale
+func (s BoundingSphere) Transform(m *Matrix) BoundingSphere {
+	xAxis := Vector{X: m[0], Y: m[1], Z: m[2]}
+	yAxis := Vector{X: m[4], Y: m[5], Z: m[6]}
+	zAxis := Vector{X: m[8], Y: m[9], Z: m[10]}
+	scale := math.Max(float64(xAxis.Dot(xAxis)), math.Max(float64(yAxis.Dot(yAxis)), float64(zAxis.Dot(zAxis))))
+
+	return BoundingSphere{
+		Center: m.TransformPoint(s.Center),
+		Radius: s.Radius * float32(math.Sqrt(scale)),
+	}
+}
+
+func (s BoundingSphere) ContainsPoint(point Vector) bool {
+	offset := point.AddVector(s.Center.Negative())
+	return offset.Dot(offset) <= s.Radius*s.Radius
+}
+
+// Intersects tests whether two spheres overlap
+func (s BoundingSphere) Intersects(other BoundingSphere) bool {
+	offset := other.Center.AddVector(s.Center.Negative())
+	radius := s.Radius + other.Radius
+	return offset.Dot(offset) <= radius*radius
+}
+
+// IntersectsAABB tests whether the sphere overlaps a box
+func (s BoundingSphere) IntersectsAABB(box AABB) bool {
+	// find the point of the box closest to the sphere center
+	closest := Vector{
+		X: float32(math.Max(float64(box.Min.X), math.Min(float64(s.Center.X), float64(box.Max.X)))),
+		Y: float32(math.Max(float64(box.Min.Y), math.Min(float64(s.Center.Y), float64(box.Max.Y)))),
+		Z: float32(math.Max(float64(box.Min.Z), math.Min(float64(s.Center.Z), float64(box.Max.Z)))),
+	}
+
+	return s.ContainsPoint(closest)
+}
+
+// IntersectRay returns the distance along the ray to where it enters the sphere, or 0 if it starts inside
+func (s BoundingSphere) IntersectRay(ray Ray) (float32, bool) {
+	// solve |origin + direction*t - center|^2 = radius^2 for t
+	offset := ray.Origin.AddVector(s.Center.Negative())
+	a := ray.Direction.Dot(ray.Direction)
+	b := offset.Dot(ray.Direction)
+	c := offset.Dot(offset) - s.Radius*s.Radius
+
+	// starting inside the sphere
+	if c <= 0 {
+		return 0, true
+	}
+
+	discriminant := b*b - a*c
+	if a == 0 || b > 0 || discriminant < 0 {
+		return 0, false
+	}
+
+	return (-b - float32(math.Sqrt(float64(discriminant)))) / a, true
+}
@@ -0,0 +1,228 @@
+package opengl_exercise
+
+import (
+	"math"
+	"testing"
+)
+
+func boxNearlyEqual(a, b AABB) bool {
+	return vectorNearlyEqual(a.Min, b.Min) && vectorNearlyEqual(a.Max, b.Max)
+}
+
+// corners returns the eight corners of a box
+func (b AABB) corners() []Vector {
+	corners := make([]Vector, 0, 8)
+	for _, x := range []float32{b.Min.X, b.Max.X} {
+		for _, y := range []float32{b.Min.Y, b.Max.Y} {
+			for _, z := range []float32{b.Min.Z, b.Max.Z} {
+				corners = append(corners, Vector{x, y, z})
+			}
+		}
+	}
+
+	return corners
+}
+
+func TestAABBTransform(t *testing.T) {
+	box := AABB{Min: Vector{-1, -1, -1}, Max: Vector{1, 2, 3}}
+	rotation := RotationYawPitchRollMatrix(math.Pi/4, math.Pi/6, 0)
+	scaling := ScalingMatrix(2, 0.5, -3)
+	translation := TranslationMatrix(10, -5, 2)
+	rotationTranslation := rotation.Multiply(&translation)
+	scalingRotation := scaling.Multiply(&rotation)
+
+	tests := []struct {
+		name   string
+		matrix Matrix
+	}{
+		{"translated", translation},
+		{"rotated", rotation},
+		{"scaled and mirrored", scaling},
+		{"rotated and translated", rotationTranslation},
+		{"scaled and rotated", scalingRotation},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// the box around the transformed corners is the smallest box around the transformed box
+			transformed := make([]Vector, 0, 8)
+			for _, corner := range box.corners() {
+				transformed = append(transformed, test.matrix.TransformPoint(corner))
+			}
+			expected := NewAABB(transformed)
+
+			if got := box.Transform(&test.matrix); !boxNearlyEqual(got, expected) {
+				t.Errorf("expected %+v, got %+v", expected, got)
+			}
+		})
+	}
+
+	// a quarter turn about y swaps the x and z extents
+	quarter := RotationYawPitchRollMatrix(math.Pi/2, 0, 0)
+	if got, expected := box.Transform(&quarter), (AABB{Min: Vector{-1, -1, -1}, Max: Vector{3, 2, 1}}); !boxNearlyEqual(got, expected) {
+		t.Errorf("quarter turn: expected %+v, got %+v", expected, got)
+	}
+
+	if got := EmptyAABB().Transform(&translation); !got.IsEmpty() {
+		t.Errorf("expected the transformed empty box to stay empty, got %+v", got)
+	}
+}
+
+func TestBoundingSphereTransform(t *testing.T) {
+	sphere := BoundingSphere{Center: Vector{1, 0, 0}, Radius: 2}
+	translation := TranslationMatrix(0, 5, 0)
+
+	tests := []struct {
+		name     string
+		matrix   Matrix
+		expected BoundingSphere
+	}{
+		{"translated", translation, BoundingSphere{Vector{1, 5, 0}, 2}},
+		{"rotated", RotationYawPitchRollMatrix(math.Pi/2, 0, 0), BoundingSphere{Vector{0, 0, -1}, 2}},
+		{"uniform scale", ScalingMatrix(3, 3, 3), BoundingSphere{Vector{3, 0, 0}, 6}},
+		// the radius grows by the largest axis scale so the stretched sphere still fits
+		{"non uniform scale", ScalingMatrix(0.5, 4, -2), BoundingSphere{Vector{0.5, 0, 0}, 8}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := sphere.Transform(&test.matrix)
+			if !vectorNearlyEqual(got.Center, test.expected.Center) || math.Abs(float64(got.Radius-test.expected.Radius)) > matrixEpsilon {
+				t.Errorf("expected %+v, got %+v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestAABBMerge(t *testing.T) {
+	box := AABB{Min: Vector{0, 0, 0}, Max: Vector{1, 1, 1}}
+
+	tests := []struct {
+		name     string
+		a, b     AABB
+		expected AABB
+	}{
+		{"disjoint", box, AABB{Min: Vector{2, -1, 0.5}, Max: Vector{3, 0, 4}}, AABB{Min: Vector{0, -1, 0}, Max: Vector{3, 1, 4}}},
+		{"overlapping", box, AABB{Min: Vector{0.5, 0.5, 0.5}, Max: Vector{2, 2, 2}}, AABB{Min: Vector{0, 0, 0}, Max: Vector{2, 2, 2}}},
+		{"contained", box, AABB{Min: Vector{0.25, 0.25, 0.25}, Max: Vector{0.5, 0.5, 0.5}}, box},
+		// merging with an empty box must not stretch the other one to the origin or anywhere else
+		{"with an empty box", AABB{Min: Vector{5, 5, 5}, Max: Vector{6, 6, 6}}, EmptyAABB(), AABB{Min: Vector{5, 5, 5}, Max: Vector{6, 6, 6}}},
+		{"into an empty box", EmptyAABB(), AABB{Min: Vector{5, 5, 5}, Max: Vector{6, 6, 6}}, AABB{Min: Vector{5, 5, 5}, Max: Vector{6, 6, 6}}},
+		{"without points", NewAABB(nil), box, box},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.a.Merge(test.b); got != test.expected {
+				t.Errorf("expected %+v, got %+v", test.expected, got)
+			}
+		})
+	}
+
+	if merged := EmptyAABB().Merge(NewAABB(nil)); !merged.IsEmpty() {
+		t.Errorf("expected two empty boxes to merge into an empty box, got %+v", merged)
+	}
+	if box.IsEmpty() || !NewAABB(nil).IsEmpty() {
+		t.Error("expected only the box without points to be empty")
+	}
+	if single := NewAABB([]Vector{{1, 2, 3}}); single.IsEmpty() || single.Min != single.Max {
+		t.Errorf("expected a box around a single point, got %+v", single)
+	}
+}
+
+func TestBoundingSphereMerge(t *testing.T) {
+	sphere := BoundingSphere{Center: Vector{}, Radius: 1}
+
+	tests := []struct {
+		name     string
+		other    BoundingSphere
+		expected BoundingSphere
+	}{
+		{"disjoint", BoundingSphere{Vector{4, 0, 0}, 1}, BoundingSphere{Vector{2, 0, 0}, 3}},
+		{"touching", BoundingSphere{Vector{0, 3, 0}, 2}, BoundingSphere{Vector{0, 2, 0}, 3}},
+		{"contained", BoundingSphere{Vector{0.5, 0, 0}, 0.25}, sphere},
+		{"containing", BoundingSphere{Vector{0, 0, 1}, 5}, BoundingSphere{Vector{0, 0, 1}, 5}},
+		{"same center", BoundingSphere{Vector{}, 2}, BoundingSphere{Vector{}, 2}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := sphere.Merge(test.other)
+			if !vectorNearlyEqual(got.Center, test.expected.Center) || math.Abs(float64(got.Radius-test.expected.Radius)) > matrixEpsilon {
+				t.Errorf("expected %+v, got %+v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestAABBIntersectRay(t *testing.T) {
+	box := AABB{Min: Vector{-1, -1, -1}, Max: Vector{1, 2, 3}}
+
+	tests := []struct {
+		name     string
+		box      AABB
+		ray      Ray
+		hit      bool
+		distance float32
+	}{
+		{"straight", box, Ray{Vector{0, 0, -10}, Vector{0, 0, 1}}, true, 9},
+		{"diagonal", box, Ray{Vector{-3, -3, 0}, Vector{1, 1, 0}}, true, 2},
+		{"long direction", box, Ray{Vector{0, 0, -10}, Vector{0, 0, 3}}, true, 3},
+		{"pointing away", box, Ray{Vector{0, 0, -10}, Vector{0, 0, -1}}, false, 0},
+		{"passing by", box, Ray{Vector{0, 3, -10}, Vector{0, 0, 1}}, false, 0},
+		{"passing the corner", box, Ray{Vector{-3, 0, 0}, Vector{1, 2, 0}}, false, 0},
+		{"parallel outside the slab", box, Ray{Vector{2, 0, -10}, Vector{0, 0, 1}}, false, 0},
+		{"parallel along a face", box, Ray{Vector{1, 0, -10}, Vector{0, 0, 1}}, true, 9},
+		// a ray starting inside the box hits it right away, whichever way it points
+		{"starting inside", box, Ray{Vector{0, 0, 0}, Vector{0, 0, 1}}, true, 0},
+		{"starting inside pointing back", box, Ray{Vector{0.5, 1, 2}, Vector{-1, -1, -1}}, true, 0},
+		{"starting on a face", box, Ray{Vector{0, 0, 3}, Vector{0, 0, 1}}, true, 0},
+		{"empty box", EmptyAABB(), Ray{Vector{0, 0, -10}, Vector{0, 0, 1}}, false, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			distance, hit := test.box.IntersectRay(test.ray)
+			if hit != test.hit {
+				t.Fatalf("expected hit %v, got %v at %v", test.hit, hit, distance)
+			}
+			if hit && math.Abs(float64(distance-test.distance)) > matrixEpsilon {
+				t.Errorf("expected distance %v, got %v", test.distance, distance)
+			}
+		})
+	}
+}
+
+func TestBoundingSphereIntersectRay(t *testing.T) {
+	sphere := BoundingSphere{Center: Vector{0, 0, 5}, Radius: 2}
+
+	tests := []struct {
+		name     string
+		ray      Ray
+		hit      bool
+		distance float32
+	}{
+		{"straight", Ray{Vector{}, Vector{0, 0, 1}}, true, 3},
+		{"long direction", Ray{Vector{}, Vector{0, 0, 2}}, true, 1.5},
+		{"pointing away", Ray{Vector{}, Vector{0, 0, -1}}, false, 0},
+		{"passing by", Ray{Vector{3, 0, 0}, Vector{0, 0, 1}}, false, 0},
+		{"starting inside", Ray{Vector{0, 1, 5}, Vector{0, 0, -1}}, true, 0},
+		{"zero direction", Ray{Vector{}, Vector{}}, false, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			distance, hit := sphere.IntersectRay(test.ray)
+			if hit != test.hit {
+				t.Fatalf("expected hit %v, got %v at %v", test.hit, hit, distance)
+			}
+			if hit && math.Abs(float64(distance-test.distance)) > matrixEpsilon {
+				t.Errorf("expected distance %v, got %v", test.distance, distance)
+			}
+		})
+	}
+
+	if sphere := NewBoundingSphere(nil); sphere != (BoundingSphere{}) {
+		t.Errorf("expected an empty sphere without points, got %+v", sphere)
+	}
+}
@@ -2,6 +2,7 @@ package opengl_exercise
 
 import (
 	"math"
+	"time"
 )
 
 type Vector struct {
@@ -69,20 +70,221 @@ func (lhs Vector) Dot(rhs Vector) float32 {
 	return lhs.X*rhs.X + lhs.Y*rhs.Y + lhs.Z*rhs.Z
 }
 
+func (v Vector) Length() float32 {
+	return float32(math.Sqrt(float64(v.Dot(v))))
+}
+
+// CameraController moves a camera every frame, usually in response to input
+type CameraController interface {
+	Update(camera *Camera, input *Input, delta time.Duration)
+}
+
+// RotationMode selects how the camera keeps track of its orientation
+type RotationMode int
+
+const (
+	// RotationEuler rebuilds the orientation from the Rotation angles every frame
+	RotationEuler RotationMode = iota
+
+	// RotationQuaternion keeps the orientation in Orientation, which avoids gimbal lock and can be blended smoothly.
+	// Changes to Rotation are still picked up and replace the current orientation.
+	RotationQuaternion
+)
+
+// ProjectionMode selects the kind of lens a camera uses
+type ProjectionMode int
+
+const (
+	PerspectiveProjection ProjectionMode = iota
+	OrthographicProjection
+)
+
 type Camera struct {
-	Position   Vector
-	Rotation   Vector
-	viewMatrix Matrix
+	Position     Vector
+	Rotation     Vector
+	RotationMode RotationMode
+	Orientation  Quaternion
+
+	Projection ProjectionMode
+
+	// FOV is the vertical field of view of the perspective projection in radians
+	FOV float32
+
+	// OrthoHeight is the height of the orthographic view volume in world units
+	OrthoHeight float32
+
+	// Near and Far are the distances of the clip planes, a Far of zero puts the perspective far plane at infinity
+	Near float32
+	Far  float32
+
+	// Aspect is the width of the view divided by its height
+	Aspect float32
+
+	// ReversedZ maps the near plane to 1 and the far plane to 0, which needs OpenGL.SetReversedZ
+	ReversedZ bool
+
+	lastRotation Vector
+	viewMatrix   Matrix
+
+	// projectionOverride replaces the projection built from the lens settings when set
+	projectionOverride *Matrix
 }
 
 func NewCamera() *Camera {
-	return &Camera{}
+	return &Camera{
+		Orientation: IdentityQuaternion(),
+		FOV:         math.Pi / 4,
+		OrthoHeight: 10,
+		Near:        0.1,
+		Far:         1000,
+		Aspect:      4.0 / 3.0,
+	}
+}
+
+// ProjectionMatrix builds the projection matrix from the camera lens settings
+func (c *Camera) ProjectionMatrix() Matrix {
+	return c.ProjectionMatrixForAspect(c.Aspect)
+}
+
+// ProjectionMatrixForAspect builds the projection matrix from the camera lens settings for a view of another shape,
+// like a viewport, without changing the Aspect of the camera
+func (c *Camera) ProjectionMatrixForAspect(aspect float32) Matrix {
+	if c.projectionOverride != nil {
+		return *c.projectionOverride
+	}
+
+	if c.Projection == OrthographicProjection {
+		width := c.OrthoHeight * aspect
+		if c.ReversedZ {
+			// swapping the clip planes flips the depth range
+			return OrthoLHMatrix(width, c.OrthoHeight, c.Far, c.Near)
+		}
+		return OrthoLHMatrix(width, c.OrthoHeight, c.Near, c.Far)
+	}
+
+	switch {
+	case c.Far <= 0 && c.ReversedZ:
+		return PerspectiveFovInfiniteReversedZLHMatrix(c.FOV, aspect, c.Near)
+	case c.Far <= 0:
+		return PerspectiveFovInfiniteLHMatrix(c.FOV, aspect, c.Near)
+	case c.ReversedZ:
+		return PerspectiveFovReversedZLHMatrix(c.FOV, aspect, c.Near, c.Far)
+	default:
+		return PerspectiveFovLHMatrix(c.FOV, aspect, c.Near, c.Far)
+	}
+}
+
+// SetProjectionMatrix makes the camera use a fixed projection instead of the one built from its lens settings,
+// for example an off-center orthographic one for 2D overlays or a light projection for shadow cameras.
+// The matrix is used for every viewport as it is, ReversedZ has to match its depth range.
+func (c *Camera) SetProjectionMatrix(projectionMatrix Matrix) {
+	c.projectionOverride = &projectionMatrix
+}
+
+// ResetProjectionMatrix goes back to building the projection from the lens settings
+func (c *Camera) ResetProjectionMatrix() {
+	c.projectionOverride = nil
+}
+
+// Zoom narrows the field of view by a factor for perspective cameras and shrinks the view volume for orthographic ones
+func (c *Camera) Zoom(factor float32) {
+	if factor <= 0 {
+		return
+	}
+
+	if c.Projection == OrthographicProjection {
+		c.OrthoHeight /= factor
+		return
+	}
+
+	// scale the tangent of the half angle so the image is magnified by exactly the factor
+	halfTan := math.Tan(float64(c.FOV)*0.5) / float64(factor)
+	c.FOV = float32(2 * math.Atan(halfTan))
+}
+
+// SetOrientation sets the camera orientation and switches the camera to quaternion mode
+func (c *Camera) SetOrientation(orientation Quaternion) {
+	c.RotationMode = RotationQuaternion
+	c.Orientation = orientation.Normalize()
+	c.lastRotation = c.Rotation
+}
+
+// Rotate applies a rotation relative to the current camera orientation and switches the camera to quaternion mode
+func (c *Camera) Rotate(rotation Quaternion) {
+	c.SetOrientation(rotation.Multiply(c.currentOrientation()))
+}
+
+func (c *Camera) currentOrientation() Quaternion {
+	if c.RotationMode == RotationQuaternion {
+		c.syncRotation()
+		return c.Orientation
+	}
+
+	return QuaternionFromEuler(c.Rotation)
+}
+
+// syncRotation replaces the quaternion orientation when the Euler rotation field was changed from outside
+func (c *Camera) syncRotation() {
+	if c.Rotation != c.lastRotation {
+		c.Orientation = QuaternionFromEuler(c.Rotation)
+		c.lastRotation = c.Rotation
+	}
 }
 
 func (c *Camera) ViewMatrix() Matrix {
 	return c.viewMatrix
 }
 
+// Forward returns the direction the camera is looking at
+func (c *Camera) Forward() Vector {
+	return c.currentOrientation().Rotate(Vector{Z: 1})
+}
+
+// LookAt turns the camera towards a point, keeping it upright
+func (c *Camera) LookAt(target Vector) {
+	c.LookDirection(target.AddVector(c.Position.Negative()))
+}
+
+// LookDirection turns the camera to look along a direction, keeping it upright
+func (c *Camera) LookDirection(direction Vector) {
+	yaw, pitch, ok := lookYawPitch(direction)
+	if !ok {
+		return
+	}
+
+	if c.RotationMode == RotationQuaternion {
+		c.SetOrientation(QuaternionFromYawPitchRoll(yaw, pitch, 0))
+	} else {
+		c.Rotation = Vector{X: pitch, Y: yaw}.MultiplyScalar(180 / math.Pi)
+	}
+}
+
+// lookYawPitch returns the upright yaw and pitch that turn the default +z look direction towards direction
+func lookYawPitch(direction Vector) (yaw, pitch float32, ok bool) {
+	direction = direction.Normalize()
+	if direction == (Vector{}) {
+		return 0, 0, false
+	}
+
+	// the default look direction is +z, yaw turns it around y and pitch tilts it down around x
+	yaw = float32(math.Atan2(float64(direction.X), float64(direction.Z)))
+	pitch = float32(-math.Asin(float64(direction.Y)))
+
+	return yaw, pitch, true
+}
+
+// FrameSphere moves the camera back along its view direction until the sphere fits in a view cone of fov radians
+func (c *Camera) FrameSphere(sphere BoundingSphere, fov float32) {
+	distance := sphere.Radius / float32(math.Sin(float64(fov)*0.5))
+	c.Position = sphere.Center.AddVector(c.Forward().MultiplyScalar(-distance))
+}
+
+// PickRay returns the world space ray through a window pixel as seen by this camera, x and y are measured from the top left corner.
+// The view matrix from the last call to Render is used.
+func (c *Camera) PickRay(opengl *OpenGL, x, y int) (Ray, error) {
+	return opengl.PickRay(x, y, c.viewMatrix, c.ProjectionMatrix())
+}
+
 func (c *Camera) Render() {
 	// upward
 	up := Vector{Y: 1}
@@ -90,11 +292,19 @@ func (c *Camera) Render() {
 	// where camera is looking by default
 	lookAt := Vector{Z: 1}
 
-	// set rotation in radians
-	rotation := c.Rotation.MultiplyScalar(math.Pi / 180)
+	var rotationMatrix Matrix
+	if c.RotationMode == RotationQuaternion {
+		// create rotation matrix from the orientation quaternion
+		c.syncRotation()
+		c.Orientation = c.Orientation.Normalize()
+		rotationMatrix = c.Orientation.Matrix()
+	} else {
+		// set rotation in radians
+		rotation := c.Rotation.MultiplyScalar(math.Pi / 180)
 
-	// create rotation matrix from yaw, pitch and roll values
-	rotationMatrix := c.matrixRotationYawPitchRoll(rotation.Y, rotation.X, rotation.Z)
+		// create rotation matrix from yaw, pitch and roll values
+		rotationMatrix = c.matrixRotationYawPitchRoll(rotation.Y, rotation.X, rotation.Z)
+	}
 
 	// transform the lookat and up vector by the rotation matrix so the view is correctly rotated at the origin
 	up = up.MultiplyMatrix(&rotationMatrix)
@@ -108,6 +318,15 @@ func (c *Camera) Render() {
 }
 
 func (c *Camera) matrixRotationYawPitchRoll(yaw, pitch, roll float32) Matrix {
+	return RotationYawPitchRollMatrix(yaw, pitch, roll)
+}
+
+func (c *Camera) buildViewMatrix(position, lookAt, up Vector) Matrix {
+	return LookAtLHMatrix(position, lookAt, up)
+}
+
+// RotationYawPitchRollMatrix builds a rotation that rolls around z, then pitches around x, then yaws around y, angles are in radians
+func RotationYawPitchRollMatrix(yaw, pitch, roll float32) Matrix {
 	cYaw := float32(math.Cos(float64(yaw)))
 	cPitch := float32(math.Cos(float64(pitch)))
 	cRoll := float32(math.Cos(float64(roll)))
@@ -140,15 +359,51 @@ func (c *Camera) matrixRotationYawPitchRoll(yaw, pitch, roll float32) Matrix {
 	}
 }
 
-func (c *Camera) buildViewMatrix(position, lookAt, up Vector) Matrix {
-	zAxis := lookAt.AddVector(position.Negative()).Normalize()
+// YawPitchRoll extracts the angles RotationYawPitchRollMatrix was built from, in radians.
+// Pitch comes out between -pi/2 and pi/2. When looking straight up or down yaw and roll turn around the same axis,
+// so roll is returned as zero and the whole turn goes into yaw.
+func (m *Matrix) YawPitchRoll() (yaw, pitch, roll float32) {
+	// the third row is the rotated z axis: (cos(pitch) sin(yaw), -sin(pitch), cos(pitch) cos(yaw))
+	sPitch := -m[9]
+	if sPitch >= 0.99999 || sPitch <= -0.99999 {
+		pitch = float32(math.Copysign(math.Pi/2, float64(sPitch)))
+		yaw = float32(math.Atan2(float64(-m[2]), float64(m[0])))
+		return yaw, pitch, 0
+	}
+
+	pitch = float32(math.Asin(float64(sPitch)))
+	yaw = float32(math.Atan2(float64(m[8]), float64(m[10])))
+
+	// the second column holds sin(roll) cos(pitch) and cos(roll) cos(pitch)
+	roll = float32(math.Atan2(float64(m[1]), float64(m[5])))
+
+	return yaw, pitch, roll
+}
+
+// LookAtLHMatrix builds a left-handed view matrix for a viewer at eye looking at target
+func LookAtLHMatrix(eye, target, up Vector) Matrix {
+	zAxis := target.AddVector(eye.Negative()).Normalize()
 	xAxis := up.Cross(zAxis).Normalize()
 	yAxis := zAxis.Cross(xAxis)
 
+	return viewMatrixFromAxes(eye, xAxis, yAxis, zAxis)
+}
+
+// LookAtRHMatrix builds a right-handed view matrix for a viewer at eye looking at target
+func LookAtRHMatrix(eye, target, up Vector) Matrix {
+	zAxis := eye.AddVector(target.Negative()).Normalize()
+	xAxis := up.Cross(zAxis).Normalize()
+	yAxis := zAxis.Cross(xAxis)
+
+	return viewMatrixFromAxes(eye, xAxis, yAxis, zAxis)
+}
+
+// viewMatrixFromAxes builds the matrix that moves the eye to the origin and lines the camera axes up with x, y and z
+func viewMatrixFromAxes(eye, xAxis, yAxis, zAxis Vector) Matrix {
 	return Matrix{
 		xAxis.X, yAxis.X, zAxis.X, 0,
 		xAxis.Y, yAxis.Y, zAxis.Y, 0,
-		xAxis.Z, xAxis.Z, zAxis.Z, 0,
-		-xAxis.Dot(position), -yAxis.Dot(position), -zAxis.Dot(position), 1,
+		xAxis.Z, yAxis.Z, zAxis.Z, 0,
+		-xAxis.Dot(eye), -yAxis.Dot(eye), -zAxis.Dot(eye), 1,
 	}
 }
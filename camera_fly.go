@@ -0,0 +1,184 @@
+package opengl_exercise
+
+import (
+	"math"
+	"time"
+
+	"github.com/TheTitanrain/w32"
+)
+
+// FlyKeys maps virtual key codes to the movements of a FlyController
+type FlyKeys struct {
+	Forward  uint32
+	Backward uint32
+	Left     uint32
+	Right    uint32
+	Up       uint32
+	Down     uint32
+
+	TurnLeft  uint32
+	TurnRight uint32
+	LookUp    uint32
+	LookDown  uint32
+
+	Fast uint32
+	Slow uint32
+}
+
+// DefaultFlyKeys moves with WASD, rises and sinks with E and Q, turns with the arrow keys,
+// speeds up with shift and slows down with control
+func DefaultFlyKeys() FlyKeys {
+	return FlyKeys{
+		Forward:   'W',
+		Backward:  'S',
+		Left:      'A',
+		Right:     'D',
+		Up:        'E',
+		Down:      'Q',
+		TurnLeft:  w32.VK_LEFT,
+		TurnRight: w32.VK_RIGHT,
+		LookUp:    w32.VK_UP,
+		LookDown:  w32.VK_DOWN,
+		Fast:      w32.VK_SHIFT,
+		Slow:      w32.VK_CONTROL,
+	}
+}
+
+// FlyController moves a camera like a free flying first person viewer.
+// Movement is scaled by the frame delta so the speed doesn't depend on the frame rate.
+type FlyController struct {
+	Keys FlyKeys
+
+	// Speed is the top speed in world units per second
+	Speed float32
+
+	// FastMultiplier and SlowMultiplier scale the top speed while the Fast and Slow keys are held
+	FastMultiplier float32
+	SlowMultiplier float32
+
+	// Acceleration is how quickly the velocity changes towards the top speed in world units per second squared.
+	// Zero changes the velocity immediately.
+	Acceleration float32
+
+	// TurnSpeed is how fast the turn keys rotate the view in radians per second
+	TurnSpeed float32
+
+	// LookButton enables mouse look while held, with MouseSensitivity radians per pixel
+	LookButton       MouseButton
+	MouseSensitivity float32
+
+	MinPitch float32
+	MaxPitch float32
+
+	yaw         float32
+	pitch       float32
+	velocity    Vector
+	initialized bool
+}
+
+// NewFlyController returns a controller with the default keys and usable speeds
+func NewFlyController() *FlyController {
+	return &FlyController{
+		Keys:             DefaultFlyKeys(),
+		Speed:            5,
+		FastMultiplier:   4,
+		SlowMultiplier:   0.25,
+		Acceleration:     20,
+		TurnSpeed:        math.Pi / 2,
+		LookButton:       MouseRight,
+		MouseSensitivity: 0.005,
+		MinPitch:         -89 * math.Pi / 180,
+		MaxPitch:         89 * math.Pi / 180,
+	}
+}
+
+// Update applies the input of this frame and moves the camera
+func (f *FlyController) Update(camera *Camera, input *Input, delta time.Duration) {
+	seconds := float32(delta.Seconds())
+
+	// continue from wherever the camera was looking before the controller took over
+	if !f.initialized {
+		forward := camera.Forward()
+		f.yaw = float32(math.Atan2(float64(forward.X), float64(forward.Z)))
+		f.pitch = float32(-math.Asin(float64(forward.Y)))
+		f.initialized = true
+	}
+
+	f.turn(input, seconds)
+
+	orientation := QuaternionFromYawPitchRoll(f.yaw, f.pitch, 0)
+	camera.SetOrientation(orientation)
+
+	// collect the wanted direction in camera space, up and down stay vertical in the world
+	var local Vector
+	local.Z += f.axis(input, f.Keys.Forward, f.Keys.Backward)
+	local.X += f.axis(input, f.Keys.Right, f.Keys.Left)
+	vertical := f.axis(input, f.Keys.Up, f.Keys.Down)
+
+	direction := orientation.Rotate(Vector{Z: local.Z}).
+		AddVector(orientation.Rotate(Vector{X: local.X})).
+		AddVector(Vector{Y: vertical}).
+		Normalize()
+
+	speed := f.Speed
+	if input.IsKeyDown(f.Keys.Fast) {
+		speed *= f.FastMultiplier
+	}
+	if input.IsKeyDown(f.Keys.Slow) {
+		speed *= f.SlowMultiplier
+	}
+
+	f.accelerate(direction.MultiplyScalar(speed), seconds)
+	camera.Position = camera.Position.AddVector(f.velocity.MultiplyScalar(seconds))
+}
+
+// Stop drops the current velocity, e.g. after the camera was moved somewhere else
+func (f *FlyController) Stop() {
+	f.velocity = Vector{}
+}
+
+// Reset makes the next Update continue from where the camera is looking now and drops the velocity,
+// for example after another controller moved the camera
+func (f *FlyController) Reset() {
+	f.initialized = false
+	f.Stop()
+}
+
+func (f *FlyController) turn(input *Input, seconds float32) {
+	f.yaw += f.axis(input, f.Keys.TurnRight, f.Keys.TurnLeft) * f.TurnSpeed * seconds
+	f.pitch += f.axis(input, f.Keys.LookDown, f.Keys.LookUp) * f.TurnSpeed * seconds
+
+	if input.IsMouseButtonDown(f.LookButton) {
+		dx, dy := input.MouseDelta()
+		f.yaw += float32(dx) * f.MouseSensitivity
+		f.pitch += float32(dy) * f.MouseSensitivity
+	}
+
+	f.pitch = float32(math.Max(float64(f.MinPitch), math.Min(float64(f.MaxPitch), float64(f.pitch))))
+}
+
+// accelerate changes the velocity towards the target by at most Acceleration*seconds
+func (f *FlyController) accelerate(target Vector, seconds float32) {
+	difference := target.AddVector(f.velocity.Negative())
+	length := float32(math.Sqrt(float64(difference.Dot(difference))))
+	step := f.Acceleration * seconds
+
+	if f.Acceleration <= 0 || length <= step {
+		f.velocity = target
+		return
+	}
+
+	f.velocity = f.velocity.AddVector(difference.MultiplyScalar(step / length))
+}
+
+// axis returns 1 if only the positive key is held, -1 if only the negative one is and 0 otherwise
+func (f *FlyController) axis(input *Input, positive, negative uint32) float32 {
+	var value float32
+	if input.IsKeyDown(positive) {
+		value++
+	}
+	if input.IsKeyDown(negative) {
+		value--
+	}
+	return value
+}
@@ -0,0 +1,197 @@
+package opengl_exercise
+
+import (
+	"math"
+	"time"
+)
+
+// FollowController keeps a camera behind a moving target for third-person views.
+// The camera is placed at Offset in the target space and looks at LookOffset, both are smoothed with critically damped springs.
+type FollowController struct {
+	Target *Transform
+
+	// Offset is where the camera sits relative to the target, rotated with the target
+	Offset Vector
+
+	// LookOffset is the point the camera looks at relative to the target, rotated with the target
+	LookOffset Vector
+
+	// LookAhead moves the look point along the target velocity by this many seconds
+	LookAhead float32
+
+	// PositionSmoothTime and RotationSmoothTime are roughly the seconds it takes to catch up with the target.
+	// Zero follows the target directly.
+	PositionSmoothTime float32
+	RotationSmoothTime float32
+
+	// Colliders are the models that push the camera towards the target when they are between the two.
+	// The model the target belongs to should not be in the list.
+	Colliders []*Model
+
+	// CollisionRadius is the distance the camera keeps from the geometry it collides with
+	CollisionRadius float32
+
+	// MinDistance is the closest the camera is pulled in towards the look point
+	MinDistance float32
+
+	// state of the springs
+	position         Vector
+	velocity         Vector
+	orientation      Quaternion
+	angularVelocity  Quaternion
+	distance         float32
+	distanceVelocity float32
+
+	lastTarget  Vector
+	initialized bool
+}
+
+// NewFollowController returns a controller looking over the shoulder of the target from behind
+func NewFollowController(target *Transform) *FollowController {
+	return &FollowController{
+		Target:             target,
+		Offset:             Vector{Y: 2, Z: -6},
+		LookOffset:         Vector{Y: 1},
+		LookAhead:          0.3,
+		PositionSmoothTime: 0.2,
+		RotationSmoothTime: 0.1,
+		CollisionRadius:    0.2,
+		MinDistance:        0.5,
+	}
+}
+
+// Snap makes the next Update place the camera without smoothing, for example after the target teleported
+func (f *FollowController) Snap() {
+	f.initialized = false
+}
+
+// Update moves the camera after the target
+func (f *FollowController) Update(camera *Camera, input *Input, delta time.Duration) {
+	if f.Target == nil {
+		return
+	}
+
+	dt := float32(delta.Seconds())
+
+	world := f.Target.WorldMatrix()
+	targetPosition, targetRotation, _, err := world.Decompose()
+	if err != nil {
+		// a flattened target has no usable rotation
+		targetPosition = f.Target.WorldPosition()
+		targetRotation = IdentityQuaternion()
+	}
+
+	// estimate the target velocity from its movement since the last frame
+	var targetVelocity Vector
+	if f.initialized && dt > 0 {
+		targetVelocity = targetPosition.AddVector(f.lastTarget.Negative()).MultiplyScalar(1 / dt)
+	}
+	f.lastTarget = targetPosition
+
+	pivot := targetPosition.AddVector(targetRotation.Rotate(f.LookOffset))
+	lookPoint := pivot.AddVector(targetVelocity.MultiplyScalar(f.LookAhead))
+	desired := targetPosition.AddVector(targetRotation.Rotate(f.Offset))
+
+	if !f.initialized {
+		f.position = desired
+		f.velocity = Vector{}
+		f.distance = desired.AddVector(pivot.Negative()).Length()
+		f.distanceVelocity = 0
+	} else {
+		f.position, f.velocity = springVector(f.position, desired, f.velocity, f.PositionSmoothTime, dt)
+	}
+
+	// pull in towards the pivot at once when something is in the way and ease back out when it is gone
+	toCamera := f.position.AddVector(pivot.Negative())
+	fullDistance := toCamera.Length()
+	allowed := f.allowedDistance(pivot, toCamera.Normalize(), fullDistance)
+	if allowed < f.distance || !f.initialized {
+		f.distance = allowed
+		f.distanceVelocity = 0
+	} else {
+		f.distance, f.distanceVelocity = springDamp(f.distance, allowed, f.distanceVelocity, f.PositionSmoothTime, dt)
+	}
+
+	camera.Position = pivot.AddVector(toCamera.Normalize().MultiplyScalar(float32(math.Min(float64(f.distance), float64(fullDistance)))))
+
+	// turn towards the look point
+	goal := f.orientation
+	if yaw, pitch, ok := lookYawPitch(lookPoint.AddVector(camera.Position.Negative())); ok {
+		goal = QuaternionFromYawPitchRoll(yaw, pitch, 0)
+	}
+
+	if !f.initialized {
+		f.orientation = goal
+		f.angularVelocity = Quaternion{}
+	} else {
+		f.orientation, f.angularVelocity = springQuaternion(f.orientation, goal, f.angularVelocity, f.RotationSmoothTime, dt)
+	}
+
+	camera.SetOrientation(f.orientation)
+	f.initialized = true
+}
+
+// allowedDistance returns how far the camera can be from the pivot along direction before it hits a collider
+func (f *FollowController) allowedDistance(pivot, direction Vector, distance float32) float32 {
+	if direction == (Vector{}) {
+		return distance
+	}
+
+	ray := Ray{Origin: pivot, Direction: direction}
+	allowed := distance
+	for _, model := range f.Colliders {
+		hit, ok := model.IntersectWorldRay(ray)
+		if !ok || hit.Distance < 0 || hit.Distance-f.CollisionRadius >= allowed {
+			continue
+		}
+
+		allowed = hit.Distance - f.CollisionRadius
+	}
+
+	return float32(math.Max(float64(f.MinDistance), float64(allowed)))
+}
+
+// springDamp moves a value towards a goal with a critically damped spring, which never overshoots.
+// smoothTime is roughly the time it takes to reach the goal, velocity is the spring state carried between calls.
+func springDamp(current, goal, velocity, smoothTime, dt float32) (float32, float32) {
+	if smoothTime <= 0 {
+		return goal, 0
+	}
+
+	// approximation of exp(-omega*dt) that is accurate for the step sizes of a frame
+	omega := 2 / smoothTime
+	x := omega * dt
+	decay := 1 / (1 + x + 0.48*x*x + 0.235*x*x*x)
+
+	change := current - goal
+	temp := (velocity + omega*change) * dt
+	velocity = (velocity - omega*temp) * decay
+	result := goal + (change+temp)*decay
+
+	// a fast spring can be carried past the goal in a long frame, stop at the goal instead
+	if change*(result-goal) < 0 {
+		return goal, 0
+	}
+
+	return result, velocity
+}
+
+func springVector(current, goal, velocity Vector, smoothTime, dt float32) (Vector, Vector) {
+	current.X, velocity.X = springDamp(current.X, goal.X, velocity.X, smoothTime, dt)
+	current.Y, velocity.Y = springDamp(current.Y, goal.Y, velocity.Y, smoothTime, dt)
+	current.Z, velocity.Z = springDamp(current.Z, goal.Z, velocity.Z, smoothTime, dt)
+	return current, velocity
+}
+
+// springQuaternion smooths each quaternion component and normalizes the result, turning the short way around
+func springQuaternion(current, goal, velocity Quaternion, smoothTime, dt float32) (Quaternion, Quaternion) {
+	if current.Dot(goal) < 0 {
+		goal = Quaternion{X: -goal.X, Y: -goal.Y, Z: -goal.Z, W: -goal.W}
+	}
+
+	current.X, velocity.X = springDamp(current.X, goal.X, velocity.X, smoothTime, dt)
+	current.Y, velocity.Y = springDamp(current.Y, goal.Y, velocity.Y, smoothTime, dt)
+	current.Z, velocity.Z = springDamp(current.Z, goal.Z, velocity.Z, smoothTime, dt)
+	current.W, velocity.W = springDamp(current.W, goal.W, velocity.W, smoothTime, dt)
+	return current.Normalize(), velocity
+}
@@ -0,0 +1,174 @@
+package opengl_exercise
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSpringDamp(t *testing.T) {
+	tests := []struct {
+		name           string
+		velocity       float32
+		smoothTime, dt float32
+	}{
+		{"frame", 0, 0.2, 1.0 / 60},
+		{"large delta", 0, 0.2, 1},
+		{"huge delta", 0, 0.2, 10},
+		{"moving towards the goal", -20, 0.2, 1.0 / 60},
+		// the spring would be carried far past the goal in one step
+		{"fast with a large delta", -100, 0.2, 1},
+		{"fast with a huge delta", -1000, 0.5, 5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			current, velocity := float32(10), test.velocity
+			for step := 0; step < 500; step++ {
+				next, nextVelocity := springDamp(current, 0, velocity, test.smoothTime, test.dt)
+				if next < 0 {
+					t.Fatalf("step %d: overshot the goal to %v", step, next)
+				}
+				if next > current {
+					t.Fatalf("step %d: moved away from the goal from %v to %v", step, current, next)
+				}
+				current, velocity = next, nextVelocity
+			}
+
+			if current > 1e-3 {
+				t.Errorf("expected to reach the goal, got %v", current)
+			}
+		})
+	}
+
+	if value, velocity := springDamp(10, 3, 5, 0, 1.0/60); value != 3 || velocity != 0 {
+		t.Errorf("expected no smoothing without a smooth time, got %v moving at %v", value, velocity)
+	}
+}
+
+func TestSpringQuaternion(t *testing.T) {
+	// angle returns the rotation between two orientations
+	angle := func(a, b Quaternion) float64 {
+		return 2 * math.Acos(math.Min(1, math.Abs(float64(a.Dot(b)))))
+	}
+
+	goal := QuaternionFromAxisAngle(Vector{Y: 1}, math.Pi*170/180)
+	tests := []struct {
+		name string
+		goal Quaternion
+		dt   float32
+	}{
+		{"frame", goal, 1.0 / 60},
+		{"large delta", goal, 1},
+		{"huge delta", goal, 10},
+		// the same orientation with all signs flipped is still reached the short way
+		{"flipped goal", Quaternion{X: -goal.X, Y: -goal.Y, Z: -goal.Z, W: -goal.W}, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			current, velocity := IdentityQuaternion(), Quaternion{}
+			remaining := angle(current, test.goal)
+
+			for step := 0; step < 500; step++ {
+				current, velocity = springQuaternion(current, test.goal, velocity, 0.1, test.dt)
+				if length := math.Sqrt(float64(current.Dot(current))); math.Abs(length-1) > matrixEpsilon {
+					t.Fatalf("step %d: expected a unit quaternion, got length %v", step, length)
+				}
+
+				// acos loses precision close to the goal, so allow for a little noise
+				next := angle(current, test.goal)
+				if next > remaining+1e-3 {
+					t.Fatalf("step %d: turned away from the goal from %v to %v radians", step, remaining, next)
+				}
+				remaining = next
+			}
+
+			if remaining > 1e-2 {
+				t.Errorf("expected to reach the goal, still %v radians away", remaining)
+			}
+		})
+	}
+}
+
+func TestFollowControllerLargeDelta(t *testing.T) {
+	target := NewTransform()
+	follow := NewFollowController(target)
+	camera := NewCamera()
+	follow.Update(camera, nil, 16*time.Millisecond)
+
+	// the target jumps, long frames must not throw the camera past it
+	target.SetPosition(Vector{X: 10})
+	previous := camera.Position.X
+	for step := 0; step < 20; step++ {
+		follow.Update(camera, nil, time.Second)
+		if camera.Position.X > 10+matrixEpsilon || camera.Position.X < previous {
+			t.Fatalf("step %d: expected x to move from %v towards 10, got %v", step, previous, camera.Position.X)
+		}
+		previous = camera.Position.X
+	}
+
+	if expected := (Vector{X: 10, Y: 2, Z: -6}); !vectorNearlyEqual(camera.Position, expected) {
+		t.Errorf("expected %+v, got %+v", expected, camera.Position)
+	}
+	if expected := direction(Vector{Y: -1, Z: 6}); !vectorNearlyEqual(camera.Forward(), expected) {
+		t.Errorf("expected to look at the target along %+v, got %+v", expected, camera.Forward())
+	}
+}
+
+func TestFollowControllerCollision(t *testing.T) {
+	// a wall across the space between the target and the camera, placed by its transform
+	wall := &Model{
+		Transform: NewTransform(),
+		layout:    DefaultVertexLayout(),
+		positions: []Vector{{-5, -5, 0}, {-5, 5, 0}, {5, -5, 0}, {5, 5, 0}},
+		indices:   []uint32{0, 1, 2, 2, 1, 3},
+	}
+	wall.calculateBounds()
+	wall.Transform.SetPosition(Vector{Z: -3})
+
+	target := NewTransform()
+	follow := NewFollowController(target)
+	follow.Colliders = []*Model{wall}
+	camera := NewCamera()
+
+	pivot := Vector{Y: 1}
+	toCamera := direction(follow.Offset.AddVector(pivot.Negative()))
+
+	follow.Update(camera, nil, 16*time.Millisecond)
+	hit, ok := wall.IntersectWorldRay(Ray{Origin: pivot, Direction: toCamera})
+	if !ok {
+		t.Fatal("expected the wall to be between the target and the camera")
+	}
+
+	// the camera stays the collision radius in front of the hit point
+	expected := pivot.AddVector(toCamera.MultiplyScalar(hit.Distance - follow.CollisionRadius))
+	if !vectorNearlyEqual(camera.Position, expected) {
+		t.Errorf("expected %+v, got %+v", expected, camera.Position)
+	}
+	if camera.Position.Z <= -3 {
+		t.Errorf("expected the camera in front of the wall, got %+v", camera.Position)
+	}
+
+	// a wall right behind the target can't pull the camera closer than the minimum distance
+	wall.Transform.SetPosition(Vector{Z: -0.1})
+	follow.Update(camera, nil, 16*time.Millisecond)
+	if distance := camera.Position.AddVector(pivot.Negative()).Length(); math.Abs(float64(distance-follow.MinDistance)) > matrixEpsilon {
+		t.Errorf("expected the minimum distance %v, got %v", follow.MinDistance, distance)
+	}
+
+	// once the wall is gone the camera eases back out without passing its offset, even in long frames
+	follow.Colliders = nil
+	previous := follow.MinDistance
+	for step := 0; step < 20; step++ {
+		follow.Update(camera, nil, 500*time.Millisecond)
+		distance := camera.Position.AddVector(pivot.Negative()).Length()
+		if distance < previous-matrixEpsilon || distance > follow.Offset.AddVector(pivot.Negative()).Length()+matrixEpsilon {
+			t.Fatalf("step %d: expected the distance to grow from %v up to the offset, got %v", step, previous, distance)
+		}
+		previous = distance
+	}
+	if !vectorNearlyEqual(camera.Position, follow.Offset) {
+		t.Errorf("expected the camera back at %+v, got %+v", follow.Offset, camera.Position)
+	}
+}
@@ -0,0 +1,139 @@
+package opengl_exercise
+
+import (
+	"math"
+	"time"
+)
+
+// OrbitController keeps a camera circling around a target point.
+// Dragging with RotateButton turns around the target, dragging with PanButton moves the target and the wheel zooms.
+type OrbitController struct {
+	Target   Vector
+	Distance float32
+
+	// Yaw and Pitch place the camera around the target in radians, positive pitch looks down on it
+	Yaw   float32
+	Pitch float32
+
+	MinPitch    float32
+	MaxPitch    float32
+	MinDistance float32
+	MaxDistance float32
+
+	// RotateSpeed is in radians per pixel of mouse movement
+	RotateSpeed float32
+
+	// PanSpeed is in world units per pixel at a distance of one unit, so panning keeps up with the view at any zoom level
+	PanSpeed float32
+
+	// ZoomSpeed is the fraction of the distance covered by one wheel notch
+	ZoomSpeed float32
+
+	// Damping makes the camera ease towards the input instead of following it directly, higher values catch up faster.
+	// Zero disables smoothing.
+	Damping float32
+
+	RotateButton MouseButton
+	PanButton    MouseButton
+
+	// the smoothed state the camera is actually placed with
+	current     orbitState
+	initialized bool
+}
+
+type orbitState struct {
+	target     Vector
+	distance   float32
+	yaw, pitch float32
+}
+
+// NewOrbitController returns a controller circling the target at the given distance with usable defaults
+func NewOrbitController(target Vector, distance float32) *OrbitController {
+	return &OrbitController{
+		Target:       target,
+		Distance:     distance,
+		MinPitch:     -89 * math.Pi / 180,
+		MaxPitch:     89 * math.Pi / 180,
+		MinDistance:  0.5,
+		MaxDistance:  500,
+		RotateSpeed:  0.01,
+		PanSpeed:     0.002,
+		ZoomSpeed:    0.1,
+		Damping:      12,
+		RotateButton: MouseLeft,
+		PanButton:    MouseRight,
+	}
+}
+
+// Update applies the mouse input of this frame and places the camera
+func (o *OrbitController) Update(camera *Camera, input *Input, delta time.Duration) {
+	dx, dy := input.MouseDelta()
+
+	if input.IsMouseButtonDown(o.RotateButton) {
+		o.Yaw += float32(dx) * o.RotateSpeed
+		o.Pitch += float32(dy) * o.RotateSpeed
+	}
+
+	if input.IsMouseButtonDown(o.PanButton) && (dx != 0 || dy != 0) {
+		// move the target in the view plane so it follows the cursor
+		orientation := QuaternionFromYawPitchRoll(o.Yaw, o.Pitch, 0)
+		right := orientation.Rotate(Vector{X: 1})
+		up := orientation.Rotate(Vector{Y: 1})
+		scale := o.PanSpeed * o.Distance
+
+		o.Target = o.Target.AddVector(right.MultiplyScalar(-float32(dx) * scale)).AddVector(up.MultiplyScalar(float32(dy) * scale))
+	}
+
+	if wheel := input.WheelDelta(); wheel != 0 {
+		// zoom by a fraction of the distance so it feels the same close up and far away
+		o.Distance *= float32(math.Pow(float64(1-o.ZoomSpeed), float64(wheel)))
+	}
+
+	o.clamp()
+	o.smooth(delta)
+	o.Apply(camera)
+}
+
+// Sync moves the orbit so it continues from where the camera is now, looking at a target Distance ahead of it.
+// The next Update places the camera without easing, for example after another controller moved the camera.
+func (o *OrbitController) Sync(camera *Camera) {
+	if yaw, pitch, ok := lookYawPitch(camera.Forward()); ok {
+		o.Yaw, o.Pitch = yaw, pitch
+	}
+	o.clamp()
+
+	forward := QuaternionFromYawPitchRoll(o.Yaw, o.Pitch, 0).Rotate(Vector{Z: 1})
+	o.Target = camera.Position.AddVector(forward.MultiplyScalar(o.Distance))
+	o.initialized = false
+}
+
+// Apply places the camera according to the current smoothed state
+func (o *OrbitController) Apply(camera *Camera) {
+	orientation := QuaternionFromYawPitchRoll(o.current.yaw, o.current.pitch, 0)
+	forward := orientation.Rotate(Vector{Z: 1})
+
+	camera.SetOrientation(orientation)
+	camera.Position = o.current.target.AddVector(forward.MultiplyScalar(-o.current.distance))
+}
+
+func (o *OrbitController) clamp() {
+	o.Pitch = float32(math.Max(float64(o.MinPitch), math.Min(float64(o.MaxPitch), float64(o.Pitch))))
+	o.Distance = float32(math.Max(float64(o.MinDistance), math.Min(float64(o.MaxDistance), float64(o.Distance))))
+}
+
+func (o *OrbitController) smooth(delta time.Duration) {
+	goal := orbitState{target: o.Target, distance: o.Distance, yaw: o.Yaw, pitch: o.Pitch}
+	if !o.initialized || o.Damping <= 0 {
+		o.current = goal
+		o.initialized = true
+		return
+	}
+
+	// exponential decay towards the goal, which behaves the same at any frame rate
+	t := float32(1 - math.Exp(-float64(o.Damping)*delta.Seconds()))
+
+	o.current.target = o.current.target.AddVector(goal.target.AddVector(o.current.target.Negative()).MultiplyScalar(t))
+	o.current.distance += (goal.distance - o.current.distance) * t
+	o.current.yaw += (goal.yaw - o.current.yaw) * t
+	o.current.pitch += (goal.pitch - o.current.pitch) * t
+}
@@ -0,0 +1,45 @@
+package opengl_exercise
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestOrbitControllerSync(t *testing.T) {
+	input, err := NewInput()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the orbit has been left somewhere else while another controller moved the camera
+	orbit := NewOrbitController(Vector{X: 50}, 10)
+	orbit.Update(NewCamera(), input, 16*time.Millisecond)
+
+	camera := NewCamera()
+	camera.Position = Vector{3, 4, -5}
+	camera.LookAt(Vector{0, 1, 2})
+	forward := camera.Forward()
+
+	orbit.Sync(camera)
+	orbit.Update(camera, input, 16*time.Millisecond)
+
+	// the camera stays put without easing from the old orbit and circles a target ahead of it
+	if !vectorNearlyEqual(camera.Position, Vector{3, 4, -5}) {
+		t.Errorf("expected the camera to stay at %+v, got %+v", Vector{3, 4, -5}, camera.Position)
+	}
+	if !vectorNearlyEqual(camera.Forward(), forward) {
+		t.Errorf("expected the camera to keep looking along %+v, got %+v", forward, camera.Forward())
+	}
+	if expected := camera.Position.AddVector(forward.MultiplyScalar(10)); !vectorNearlyEqual(orbit.Target, expected) {
+		t.Errorf("expected the target at %+v, got %+v", expected, orbit.Target)
+	}
+
+	// looking straight down is clamped to the pitch limit, the camera still stays put
+	camera.LookDirection(Vector{Y: -1})
+	orbit.Sync(camera)
+	orbit.Update(camera, input, 16*time.Millisecond)
+	if math.Abs(float64(orbit.Pitch-orbit.MaxPitch)) > matrixEpsilon || !vectorNearlyEqual(camera.Position, Vector{3, 4, -5}) {
+		t.Errorf("expected the pitch %v at %+v, got %v at %+v", orbit.MaxPitch, Vector{3, 4, -5}, orbit.Pitch, camera.Position)
+	}
+}
@@ -0,0 +1,71 @@
+package opengl_exercise
+
+import (
+	"time"
+)
+
+// CameraPath moves a camera along a curve over time
+type CameraPath struct {
+	// Position is the curve the camera travels along
+	Position Curve
+
+	// Target is an optional curve the camera keeps looking at, without it the camera looks along the direction of travel
+	Target Curve
+
+	// Duration is how long it takes to travel the whole path
+	Duration time.Duration
+
+	// Easing shapes the progress over time, linear when nil
+	Easing EasingFunc
+
+	// Loop starts the path over once it reaches the end
+	Loop bool
+
+	elapsed time.Duration
+}
+
+// Update advances the path by the frame delta and moves the camera to the new point
+func (p *CameraPath) Update(camera *Camera, delta time.Duration) {
+	p.elapsed += delta
+	if p.Loop && p.Duration > 0 {
+		p.elapsed %= p.Duration
+	} else if p.elapsed > p.Duration {
+		p.elapsed = p.Duration
+	}
+
+	p.Apply(camera, p.Progress())
+}
+
+// Progress returns how far along the path the camera is, from 0 to 1 before easing
+func (p *CameraPath) Progress() float32 {
+	if p.Duration <= 0 {
+		return 1
+	}
+
+	return float32(p.elapsed) / float32(p.Duration)
+}
+
+// Finished returns whether a path that doesn't loop has reached its end
+func (p *CameraPath) Finished() bool {
+	return !p.Loop && p.elapsed >= p.Duration
+}
+
+// Reset moves back to the start of the path
+func (p *CameraPath) Reset() {
+	p.elapsed = 0
+}
+
+// Apply places the camera at progress t of the path
+func (p *CameraPath) Apply(camera *Camera, t float32) {
+	if p.Easing != nil {
+		t = p.Easing(t)
+	}
+
+	camera.Position = p.Position.Point(t)
+
+	if p.Target != nil {
+		camera.LookAt(p.Target.Point(t))
+	} else if direction := p.Position.Tangent(t); direction != (Vector{}) {
+		camera.LookDirection(direction)
+	}
+}
@@ -0,0 +1,58 @@
+package opengl_exercise
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCameraPath(t *testing.T) {
+	line := &BezierSpline{Points: []Vector{{0, 0, 0}, {1, 0, 0}, {2, 0, 0}, {3, 0, 0}}}
+
+	tests := []struct {
+		name    string
+		path    CameraPath
+		updates []time.Duration
+		// expected is the camera position after the updates
+		expected Vector
+		finished bool
+	}{
+		{"start", CameraPath{Position: line, Duration: 2 * time.Second}, nil, Vector{0, 0, 0}, false},
+		{"halfway", CameraPath{Position: line, Duration: 2 * time.Second}, []time.Duration{time.Second}, Vector{1.5, 0, 0}, false},
+		{"eased", CameraPath{Position: line, Duration: 2 * time.Second, Easing: EaseInQuad}, []time.Duration{time.Second}, Vector{0.75, 0, 0}, false},
+		{"stops at the end", CameraPath{Position: line, Duration: 2 * time.Second}, []time.Duration{time.Second, 5 * time.Second}, Vector{3, 0, 0}, true},
+		{"loops", CameraPath{Position: line, Duration: 2 * time.Second, Loop: true}, []time.Duration{time.Second, 1500 * time.Millisecond}, Vector{0.75, 0, 0}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			camera := NewCamera()
+			path := test.path
+			path.Apply(camera, 0)
+			for _, delta := range test.updates {
+				path.Update(camera, delta)
+			}
+
+			if !vectorNearlyEqual(camera.Position, test.expected) {
+				t.Errorf("expected %+v, got %+v", test.expected, camera.Position)
+			}
+			if path.Finished() != test.finished {
+				t.Errorf("expected finished %v, got %v", test.finished, path.Finished())
+			}
+
+			// without a target the camera looks along the path
+			if forward := camera.Forward(); !vectorNearlyEqual(forward, Vector{X: 1}) {
+				t.Errorf("expected the camera to look along the path, got %+v", forward)
+			}
+		})
+	}
+
+	t.Run("target", func(t *testing.T) {
+		camera := NewCamera()
+		path := CameraPath{Position: line, Target: &CatmullRomSpline{Points: []Vector{{0, 0, 10}}}, Duration: time.Second}
+		path.Update(camera, time.Second)
+
+		if expected := direction(Vector{-3, 0, 10}); !vectorNearlyEqual(camera.Forward(), expected) {
+			t.Errorf("expected the camera to look at the target along %+v, got %+v", expected, camera.Forward())
+		}
+	})
+}
@@ -0,0 +1,247 @@
+package opengl_exercise
+
+import (
+	"math"
+	"testing"
+)
+
+func vectorNearlyEqual(a, b Vector) bool {
+	diff := a.AddVector(b.Negative())
+	return diff.Dot(diff) < matrixEpsilon*matrixEpsilon
+}
+
+func TestCameraViewMatrix(t *testing.T) {
+	tests := []struct {
+		name     string
+		position Vector
+		rotation Vector
+	}{
+		{"origin", Vector{}, Vector{}},
+		{"moved back", Vector{Z: -10}, Vector{}},
+		{"yaw", Vector{1, 2, 3}, Vector{Y: 45}},
+		{"pitch", Vector{-4, 0, 2}, Vector{X: 30}},
+		{"roll", Vector{0, 5, 0}, Vector{Z: -60}},
+		{"all", Vector{3, -1, 7}, Vector{X: 20, Y: -130, Z: 15}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			camera := NewCamera()
+			camera.Position = test.position
+			camera.Rotation = test.rotation
+			camera.Render()
+
+			// the view matrix must undo placing the camera, which rotates it and then moves it into position
+			radians := test.rotation.MultiplyScalar(math.Pi / 180)
+			rotation := camera.matrixRotationYawPitchRoll(radians.Y, radians.X, radians.Z)
+			translation := TranslationMatrix(test.position.X, test.position.Y, test.position.Z)
+			cameraMatrix := rotation.Multiply(&translation)
+
+			expected, err := cameraMatrix.Inverse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if view := camera.ViewMatrix(); !matrixNearlyEqual(view, expected) {
+				t.Errorf("expected view matrix %v, got %v", expected, view)
+			}
+
+			// the quaternion mode has to end up with the same view
+			camera.RotationMode = RotationQuaternion
+			camera.lastRotation = Vector{X: 1000}
+			camera.Render()
+			if view := camera.ViewMatrix(); !matrixNearlyEqual(view, expected) {
+				t.Errorf("quaternion mode: expected view matrix %v, got %v", expected, view)
+			}
+		})
+	}
+}
+
+func TestLookAtMatrix(t *testing.T) {
+	tests := []struct {
+		name   string
+		build  func(eye, target, up Vector) Matrix
+		eye    Vector
+		target Vector
+		up     Vector
+		// the direction the target ends up in view space
+		forward Vector
+	}{
+		{"lh forward", LookAtLHMatrix, Vector{0, 0, -10}, Vector{}, Vector{Y: 1}, Vector{Z: 1}},
+		{"lh sideways", LookAtLHMatrix, Vector{5, 1, 2}, Vector{-3, 4, 0}, Vector{Y: 1}, Vector{Z: 1}},
+		{"lh tilted up", LookAtLHMatrix, Vector{1, 1, 1}, Vector{2, 8, 3}, Vector{Y: 1}, Vector{Z: 1}},
+		{"rh forward", LookAtRHMatrix, Vector{0, 0, 10}, Vector{}, Vector{Y: 1}, Vector{Z: -1}},
+		{"rh sideways", LookAtRHMatrix, Vector{5, 1, 2}, Vector{-3, 4, 0}, Vector{Y: 1}, Vector{Z: -1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			view := test.build(test.eye, test.target, test.up)
+
+			// the eye moves to the origin
+			if eye := view.TransformPoint(test.eye); !vectorNearlyEqual(eye, Vector{}) {
+				t.Errorf("eye ended up at %v", eye)
+			}
+
+			// the target lies straight ahead at its original distance
+			offset := test.target.AddVector(test.eye.Negative())
+			distance := float32(math.Sqrt(float64(offset.Dot(offset))))
+			if target := view.TransformPoint(test.target); !vectorNearlyEqual(target, test.forward.MultiplyScalar(distance)) {
+				t.Errorf("target ended up at %v", target)
+			}
+
+			// up stays in the upper half of the view and the rotation part is orthonormal
+			if up := view.TransformDirection(test.up); up.Y <= 0 || math.Abs(float64(up.X)) > matrixEpsilon {
+				t.Errorf("up ended up as %v", up)
+			}
+
+			if det := view.Determinant(); math.Abs(float64(det-1)) > matrixEpsilon {
+				t.Errorf("expected a rigid transform, determinant is %f", det)
+			}
+		})
+	}
+}
+
+func TestYawPitchRoll(t *testing.T) {
+	tests := []struct {
+		name             string
+		yaw, pitch, roll float32
+	}{
+		{"zero", 0, 0, 0},
+		{"yaw", 1, 0, 0},
+		{"pitch", 0, -0.5, 0},
+		{"roll", 0, 0, 2.5},
+		{"all", -2, 0.7, 1.1},
+		{"behind", 3, -1.2, -3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := RotationYawPitchRollMatrix(test.yaw, test.pitch, test.roll)
+			yaw, pitch, roll := m.YawPitchRoll()
+			if math.Abs(float64(yaw-test.yaw))+math.Abs(float64(pitch-test.pitch))+math.Abs(float64(roll-test.roll)) > matrixEpsilon {
+				t.Errorf("expected %f %f %f, got %f %f %f", test.yaw, test.pitch, test.roll, yaw, pitch, roll)
+			}
+
+			q := QuaternionFromYawPitchRoll(test.yaw, test.pitch, test.roll)
+			if !matrixNearlyEqual(q.Matrix(), m) {
+				t.Errorf("quaternion matrix %v differs from %v", q.Matrix(), m)
+			}
+
+			yaw, pitch, roll = q.YawPitchRoll()
+			if rebuilt := RotationYawPitchRollMatrix(yaw, pitch, roll); !matrixNearlyEqual(rebuilt, m) {
+				t.Errorf("quaternion angles rebuild %v instead of %v", rebuilt, m)
+			}
+		})
+	}
+
+	// looking straight up or down only the combined turn can be recovered, but it must rebuild the same matrix
+	for _, pitch := range []float32{math.Pi / 2, -math.Pi / 2} {
+		m := RotationYawPitchRollMatrix(0.4, pitch, 0.3)
+		yaw, gotPitch, roll := m.YawPitchRoll()
+		if rebuilt := RotationYawPitchRollMatrix(yaw, gotPitch, roll); !matrixNearlyEqual(rebuilt, m) {
+			t.Errorf("pitch %f: rebuilt %v instead of %v", pitch, rebuilt, m)
+		}
+	}
+}
+
+func TestQuaternionAxisAngle(t *testing.T) {
+	tests := []struct {
+		name  string
+		axis  Vector
+		angle float32
+	}{
+		{"x", Vector{X: 1}, 0.5},
+		{"y", Vector{Y: 1}, 2},
+		{"diagonal", Vector{1, 1, 1}.Normalize(), 1.25},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			axis, angle := QuaternionFromAxisAngle(test.axis, test.angle).AxisAngle()
+			if !vectorNearlyEqual(axis, test.axis) || math.Abs(float64(angle-test.angle)) > matrixEpsilon {
+				t.Errorf("expected %v %f, got %v %f", test.axis, test.angle, axis, angle)
+			}
+		})
+	}
+
+	// rotating around y matches the existing rotation helper
+	if q := QuaternionFromAxisAngle(Vector{Y: 1}, 0.8); !matrixNearlyEqual(q.Matrix(), NewOpenGL().rotationMatrix(0.8)) {
+		t.Errorf("axis angle rotation around y differs from rotationMatrix")
+	}
+}
+
+func TestCameraProjectionMatrix(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(c *Camera)
+		expected Matrix
+	}{
+		{"default", func(c *Camera) {}, PerspectiveFovLHMatrix(math.Pi/4, 4.0/3.0, 0.1, 1000)},
+		{"wide lens", func(c *Camera) {
+			c.FOV, c.Aspect, c.Near, c.Far = math.Pi/2, 2, 1, 50
+		}, PerspectiveFovLHMatrix(math.Pi/2, 2, 1, 50)},
+		{"infinite", func(c *Camera) { c.Far = 0 }, PerspectiveFovInfiniteLHMatrix(math.Pi/4, 4.0/3.0, 0.1)},
+		{"reversed", func(c *Camera) { c.ReversedZ = true }, PerspectiveFovReversedZLHMatrix(math.Pi/4, 4.0/3.0, 0.1, 1000)},
+		{"orthographic", func(c *Camera) {
+			c.Projection = OrthographicProjection
+			c.OrthoHeight, c.Aspect = 6, 2
+		}, OrthoLHMatrix(12, 6, 0.1, 1000)},
+		{"zoomed", func(c *Camera) {
+			c.FOV = math.Pi / 2
+			c.Zoom(2)
+		}, PerspectiveFovLHMatrix(2*float32(math.Atan(0.5)), 4.0/3.0, 0.1, 1000)},
+		{"zoomed orthographic", func(c *Camera) {
+			c.Projection = OrthographicProjection
+			c.Zoom(2)
+		}, OrthoLHMatrix(5*4.0/3.0, 5, 0.1, 1000)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			camera := NewCamera()
+			test.setup(camera)
+
+			if projection := camera.ProjectionMatrix(); !matrixNearlyEqual(projection, test.expected) {
+				t.Errorf("expected projection %v, got %v", test.expected, projection)
+			}
+		})
+	}
+}
+
+func TestCameraProjectionMatrixForAspect(t *testing.T) {
+	for _, projection := range []ProjectionMode{PerspectiveProjection, OrthographicProjection} {
+		camera := NewCamera()
+		camera.Projection = projection
+		camera.Aspect = 1.5
+
+		wide := camera.ProjectionMatrixForAspect(2)
+		if camera.Aspect != 1.5 {
+			t.Errorf("expected the camera aspect to stay 1.5, got %v", camera.Aspect)
+		}
+
+		camera.Aspect = 2
+		if expected := camera.ProjectionMatrix(); !matrixNearlyEqual(wide, expected) {
+			t.Errorf("expected projection %v, got %v", expected, wide)
+		}
+	}
+}
+
+func TestCameraSetProjectionMatrix(t *testing.T) {
+	camera := NewCamera()
+	lens := camera.ProjectionMatrix()
+
+	// an overlay projection is used for every viewport shape until it is reset
+	overlay := OrthoOffCenterLHMatrix(0, 800, 0, 600, 0, 1)
+	camera.SetProjectionMatrix(overlay)
+	for _, aspect := range []float32{camera.Aspect, 0.5, 2} {
+		if projection := camera.ProjectionMatrixForAspect(aspect); !matrixNearlyEqual(projection, overlay) {
+			t.Errorf("aspect %v: expected the overlay projection %v, got %v", aspect, overlay, projection)
+		}
+	}
+
+	camera.ResetProjectionMatrix()
+	if projection := camera.ProjectionMatrix(); !matrixNearlyEqual(projection, lens) {
+		t.Errorf("expected the lens projection %v, got %v", lens, projection)
+	}
+}
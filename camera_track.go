@@ -0,0 +1,465 @@
+package opengl_exercise
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// Interpolation selects how a camera track gets from one keyframe to the next
+type Interpolation int
+
+const (
+	// InterpolationSmooth moves through the keyframe positions on a Catmull-Rom spline and slerps the orientation.
+	// The tangents are scaled by the time between the keyframes, so the speed doesn't jump at keyframes spaced unevenly.
+	InterpolationSmooth Interpolation = iota
+
+	// InterpolationLinear moves in a straight line and slerps the orientation
+	InterpolationLinear
+
+	// InterpolationStep holds the keyframe until the next one is reached
+	InterpolationStep
+)
+
+var interpolationNames = map[Interpolation]string{
+	InterpolationSmooth: "smooth",
+	InterpolationLinear: "linear",
+	InterpolationStep:   "step",
+}
+
+func (i Interpolation) String() string {
+	if name, ok := interpolationNames[i]; ok {
+		return name
+	}
+
+	return "unknown"
+}
+
+func (i Interpolation) MarshalJSON() ([]byte, error) {
+	name, ok := interpolationNames[i]
+	if !ok {
+		return nil, fmt.Errorf("unknown interpolation %d", int(i))
+	}
+
+	return json.Marshal(name)
+}
+
+func (i *Interpolation) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+
+	for interpolation, n := range interpolationNames {
+		if n == name {
+			*i = interpolation
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown interpolation '%s'", name)
+}
+
+// easingFuncs names the easing functions so tracks can refer to them in JSON
+var easingFuncs = map[string]EasingFunc{
+	"linear":       EaseLinear,
+	"inQuad":       EaseInQuad,
+	"outQuad":      EaseOutQuad,
+	"inOutQuad":    EaseInOutQuad,
+	"inCubic":      EaseInCubic,
+	"outCubic":     EaseOutCubic,
+	"inOutCubic":   EaseInOutCubic,
+	"inQuart":      EaseInQuart,
+	"outQuart":     EaseOutQuart,
+	"inOutQuart":   EaseInOutQuart,
+	"inSine":       EaseInSine,
+	"outSine":      EaseOutSine,
+	"inOutSine":    EaseInOutSine,
+	"inExpo":       EaseInExpo,
+	"outExpo":      EaseOutExpo,
+	"inOutExpo":    EaseInOutExpo,
+	"inCirc":       EaseInCirc,
+	"outCirc":      EaseOutCirc,
+	"inOutCirc":    EaseInOutCirc,
+	"inBack":       EaseInBack,
+	"outBack":      EaseOutBack,
+	"inOutBack":    EaseInOutBack,
+	"inElastic":    EaseInElastic,
+	"outElastic":   EaseOutElastic,
+	"inOutElastic": EaseInOutElastic,
+	"inBounce":     EaseInBounce,
+	"outBounce":    EaseOutBounce,
+	"inOutBounce":  EaseInOutBounce,
+}
+
+// CameraKeyframe is the camera state at a point of a CameraTrack
+type CameraKeyframe struct {
+	Time        time.Duration
+	Position    Vector
+	Orientation Quaternion
+
+	// FOV is the vertical field of view in radians
+	FOV float32
+
+	// Interpolation and Easing shape the move from this keyframe to the next one.
+	// Easing is the name of an easing function such as "inOutCubic", linear when empty.
+	Interpolation Interpolation
+	Easing        string
+}
+
+// cameraKeyframeJSON is the saved form of a keyframe, with the time in seconds and vectors as arrays
+type cameraKeyframeJSON struct {
+	Time          float64       `json:"time"`
+	Position      [3]float32    `json:"position"`
+	Orientation   [4]float32    `json:"orientation"`
+	FOV           float32       `json:"fov"`
+	Interpolation Interpolation `json:"interpolation"`
+	Easing        string        `json:"easing,omitempty"`
+}
+
+// CameraTrack replays camera moves recorded as keyframes.
+// It implements CameraController so it can be driven by Graphics.Frame.
+type CameraTrack struct {
+	// Keyframes are sorted by time, use AddKeyframe to keep them that way
+	Keyframes []CameraKeyframe
+
+	// Loop starts the track over once it reaches the end
+	Loop bool
+
+	// Speed scales the playback rate, negative values play the track backwards
+	Speed float32
+
+	playing bool
+	time    time.Duration
+}
+
+// cameraTrackJSON is the saved form of a track
+type cameraTrackJSON struct {
+	Loop      bool                 `json:"loop"`
+	Speed     float32              `json:"speed"`
+	Keyframes []cameraKeyframeJSON `json:"keyframes"`
+}
+
+func NewCameraTrack() *CameraTrack {
+	return &CameraTrack{Speed: 1}
+}
+
+// AddKeyframe inserts a keyframe at its time, replacing a keyframe with the same time
+func (t *CameraTrack) AddKeyframe(keyframe CameraKeyframe) {
+	i := sort.Search(len(t.Keyframes), func(i int) bool {
+		return t.Keyframes[i].Time >= keyframe.Time
+	})
+
+	if i < len(t.Keyframes) && t.Keyframes[i].Time == keyframe.Time {
+		t.Keyframes[i] = keyframe
+		return
+	}
+
+	t.Keyframes = append(t.Keyframes, CameraKeyframe{})
+	copy(t.Keyframes[i+1:], t.Keyframes[i:])
+	t.Keyframes[i] = keyframe
+}
+
+// AddCameraKeyframe records the current state of a camera at the given time
+func (t *CameraTrack) AddCameraKeyframe(camera *Camera, at time.Duration, interpolation Interpolation) {
+	t.AddKeyframe(CameraKeyframe{
+		Time:          at,
+		Position:      camera.Position,
+		Orientation:   camera.currentOrientation(),
+		FOV:           camera.FOV,
+		Interpolation: interpolation,
+	})
+}
+
+// Duration returns the time of the last keyframe
+func (t *CameraTrack) Duration() time.Duration {
+	if len(t.Keyframes) == 0 {
+		return 0
+	}
+
+	return t.Keyframes[len(t.Keyframes)-1].Time
+}
+
+// Play starts or resumes playback, a finished track starts over
+func (t *CameraTrack) Play() {
+	if t.Finished() {
+		t.Rewind()
+	}
+
+	t.playing = true
+}
+
+// Pause stops playback at the current time
+func (t *CameraTrack) Pause() {
+	t.playing = false
+}
+
+// Stop pauses playback and rewinds the track
+func (t *CameraTrack) Stop() {
+	t.playing = false
+	t.Rewind()
+}
+
+// Rewind moves to the start of the track, or to the end when playing backwards
+func (t *CameraTrack) Rewind() {
+	if t.Speed < 0 {
+		t.time = t.Duration()
+	} else {
+		t.time = 0
+	}
+}
+
+func (t *CameraTrack) Playing() bool {
+	return t.playing
+}
+
+// Seek jumps to a time of the track, the camera moves there on the next Update
+func (t *CameraTrack) Seek(at time.Duration) {
+	t.time = t.wrap(at)
+}
+
+// Time returns the current playback time
+func (t *CameraTrack) Time() time.Duration {
+	return t.time
+}
+
+// Finished returns whether a track that doesn't loop has played to its end
+func (t *CameraTrack) Finished() bool {
+	if t.Loop {
+		return false
+	}
+
+	if t.Speed < 0 {
+		return t.time <= 0
+	}
+
+	return t.time >= t.Duration()
+}
+
+// Update advances playback by the frame delta and moves the camera to the new time
+func (t *CameraTrack) Update(camera *Camera, input *Input, delta time.Duration) {
+	if len(t.Keyframes) == 0 {
+		return
+	}
+
+	if t.playing {
+		t.time = t.wrap(t.time + time.Duration(float64(delta)*float64(t.Speed)))
+
+		// pause at the end so Play starts over
+		if t.Finished() {
+			t.playing = false
+		}
+	}
+
+	t.Apply(camera, t.time)
+}
+
+// wrap keeps a time inside the track, looping or clamping it
+func (t *CameraTrack) wrap(at time.Duration) time.Duration {
+	duration := t.Duration()
+	if duration <= 0 {
+		return 0
+	}
+
+	if t.Loop {
+		at %= duration
+		if at < 0 {
+			at += duration
+		}
+		return at
+	}
+
+	if at < 0 {
+		return 0
+	}
+	if at > duration {
+		return duration
+	}
+	return at
+}
+
+// Apply places the camera where the track is at the given time
+func (t *CameraTrack) Apply(camera *Camera, at time.Duration) {
+	position, orientation, fov := t.Sample(at)
+
+	camera.Position = position
+	camera.SetOrientation(orientation)
+	camera.FOV = fov
+}
+
+// Sample interpolates the keyframes at the given time
+func (t *CameraTrack) Sample(at time.Duration) (position Vector, orientation Quaternion, fov float32) {
+	count := len(t.Keyframes)
+	if count == 0 {
+		return Vector{}, IdentityQuaternion(), math.Pi / 4
+	}
+
+	// find the keyframe the time is after
+	next := sort.Search(count, func(i int) bool {
+		return t.Keyframes[i].Time > at
+	})
+	if next == 0 {
+		first := t.Keyframes[0]
+		return first.Position, first.Orientation, first.FOV
+	}
+	if next == count {
+		last := t.Keyframes[count-1]
+		return last.Position, last.Orientation, last.FOV
+	}
+
+	k0, k1 := t.Keyframes[next-1], t.Keyframes[next]
+	if k0.Interpolation == InterpolationStep {
+		return k0.Position, k0.Orientation, k0.FOV
+	}
+
+	s := float32(at-k0.Time) / float32(k1.Time-k0.Time)
+	if easing, ok := easingFuncs[k0.Easing]; ok {
+		s = easing(s)
+	}
+
+	if k0.Interpolation == InterpolationSmooth {
+		// the velocities are per second, the Hermite tangents per segment
+		duration := float32((k1.Time - k0.Time).Seconds())
+		m0 := t.velocity(next - 1).MultiplyScalar(duration)
+		m1 := t.velocity(next).MultiplyScalar(duration)
+		position = Hermite(k0.Position, m0, k1.Position, m1, s)
+	} else {
+		position = k0.Position.AddVector(k1.Position.AddVector(k0.Position.Negative()).MultiplyScalar(s))
+	}
+
+	orientation = k0.Orientation.Normalize().Slerp(k1.Orientation.Normalize(), s)
+	fov = k0.FOV + (k1.FOV-k0.FOV)*s
+
+	return position, orientation, fov
+}
+
+// velocity returns the Catmull-Rom tangent at a keyframe in units per second, the distance between its neighbours
+// divided by the time between them. The first and last keyframes are repeated at the ends, one interval away.
+func (t *CameraTrack) velocity(i int) Vector {
+	previous, next := i, i
+	if i > 0 {
+		previous = i - 1
+	}
+	if i+1 < len(t.Keyframes) {
+		next = i + 1
+	}
+
+	span := (t.Keyframes[next].Time - t.Keyframes[previous].Time).Seconds()
+	if previous == i || next == i {
+		span *= 2
+	}
+	if span <= 0 {
+		return Vector{}
+	}
+
+	return t.Keyframes[next].Position.AddVector(t.Keyframes[previous].Position.Negative()).MultiplyScalar(float32(1 / span))
+}
+
+func (t *CameraTrack) MarshalJSON() ([]byte, error) {
+	track := cameraTrackJSON{
+		Loop:      t.Loop,
+		Speed:     t.Speed,
+		Keyframes: make([]cameraKeyframeJSON, len(t.Keyframes)),
+	}
+
+	for i, k := range t.Keyframes {
+		track.Keyframes[i] = cameraKeyframeJSON{
+			Time:          k.Time.Seconds(),
+			Position:      [3]float32{k.Position.X, k.Position.Y, k.Position.Z},
+			Orientation:   [4]float32{k.Orientation.X, k.Orientation.Y, k.Orientation.Z, k.Orientation.W},
+			FOV:           k.FOV,
+			Interpolation: k.Interpolation,
+			Easing:        k.Easing,
+		}
+	}
+
+	return json.Marshal(track)
+}
+
+func (t *CameraTrack) UnmarshalJSON(data []byte) error {
+	track := cameraTrackJSON{Speed: 1}
+	if err := json.Unmarshal(data, &track); err != nil {
+		return err
+	}
+
+	t.Loop = track.Loop
+	t.Speed = track.Speed
+	t.Keyframes = nil
+	for i, k := range track.Keyframes {
+		if k.Easing != "" {
+			if _, ok := easingFuncs[k.Easing]; !ok {
+				return fmt.Errorf("keyframe %d: unknown easing '%s'", i, k.Easing)
+			}
+		}
+
+		t.AddKeyframe(CameraKeyframe{
+			Time:          time.Duration(k.Time * float64(time.Second)),
+			Position:      Vector{X: k.Position[0], Y: k.Position[1], Z: k.Position[2]},
+			Orientation:   Quaternion{X: k.Orientation[0], Y: k.Orientation[1], Z: k.Orientation[2], W: k.Orientation[3]}.Normalize(),
+			FOV:           k.FOV,
+			Interpolation: k.Interpolation,
+			Easing:        k.Easing,
+		})
+	}
+
+	t.time = t.wrap(t.time)
+
+	return nil
+}
+
+// WriteTo writes the track as indented JSON
+func (t *CameraTrack) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(append(data, '\n'))
+	return int64(n), err
+}
+
+// Save writes the track to a JSON file
+func (t *CameraTrack) Save(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := t.WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// ReadCameraTrack reads a track written by CameraTrack.WriteTo
+func ReadCameraTrack(r io.Reader) (*CameraTrack, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	track := NewCameraTrack()
+	if err := json.Unmarshal(data, track); err != nil {
+		return nil, err
+	}
+
+	return track, nil
+}
+
+// LoadCameraTrack reads a track from a JSON file written by CameraTrack.Save
+func LoadCameraTrack(path string) (*CameraTrack, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadCameraTrack(file)
+}
@@ -0,0 +1,121 @@
+package opengl_exercise
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCameraTrackSmooth(t *testing.T) {
+	positions := []Vector{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {3, 1, 2}}
+
+	t.Run("even keyframes", func(t *testing.T) {
+		// evenly spaced keyframes follow the uniform Catmull-Rom spline
+		track := NewCameraTrack()
+		for i, position := range positions {
+			track.AddKeyframe(CameraKeyframe{Time: time.Duration(i) * time.Second, Position: position, Orientation: IdentityQuaternion()})
+		}
+
+		for _, s := range []float32{0, 0.25, 0.5, 0.75} {
+			position, _, _ := track.Sample(time.Second + time.Duration(s*float32(time.Second)))
+			if expected := CatmullRom(positions[0], positions[1], positions[2], positions[3], s); !vectorNearlyEqual(position, expected) {
+				t.Errorf("s %v: expected %+v, got %+v", s, expected, position)
+			}
+		}
+	})
+
+	t.Run("uneven keyframes", func(t *testing.T) {
+		track := NewCameraTrack()
+		times := []time.Duration{0, 100 * time.Millisecond, 3 * time.Second, 3500 * time.Millisecond}
+		for i, position := range positions {
+			track.AddKeyframe(CameraKeyframe{Time: times[i], Position: position, Orientation: IdentityQuaternion()})
+		}
+
+		// velocity estimates the velocity on the side of a time the step goes to, by second order differences
+		const step = time.Millisecond
+		velocity := func(at, step time.Duration) Vector {
+			p0, _, _ := track.Sample(at)
+			p1, _, _ := track.Sample(at + step)
+			p2, _, _ := track.Sample(at + 2*step)
+			return p1.MultiplyScalar(4).AddVector(p0.MultiplyScalar(-3)).AddVector(p2.Negative()).MultiplyScalar(float32(time.Second / (2 * step)))
+		}
+
+		// the velocity on both sides of every inner keyframe is the same
+		for i := 1; i+1 < len(times); i++ {
+			if at, _, _ := track.Sample(times[i]); !vectorNearlyEqual(at, positions[i]) {
+				t.Errorf("keyframe %d: expected %+v, got %+v", i, positions[i], at)
+			}
+
+			incoming := velocity(times[i], -step)
+			outgoing := velocity(times[i], step)
+			if difference := incoming.AddVector(outgoing.Negative()).Length(); difference > 0.05*outgoing.Length() {
+				t.Errorf("keyframe %d: the velocity jumps from %+v to %+v", i, incoming, outgoing)
+			}
+		}
+	})
+}
+
+func TestCameraTrackJSON(t *testing.T) {
+	track := NewCameraTrack()
+	track.Loop = true
+	track.Speed = -0.5
+	track.AddKeyframe(CameraKeyframe{
+		Time:          1500 * time.Millisecond,
+		Position:      Vector{1, 2, 3},
+		Orientation:   QuaternionFromAxisAngle(Vector{Y: 1}, math.Pi/3),
+		FOV:           math.Pi / 3,
+		Interpolation: InterpolationLinear,
+		Easing:        "inOutCubic",
+	})
+	track.AddKeyframe(CameraKeyframe{
+		Time:          0,
+		Position:      Vector{-1, 0, 0.5},
+		Orientation:   IdentityQuaternion(),
+		FOV:           math.Pi / 4,
+		Interpolation: InterpolationStep,
+	})
+	track.AddKeyframe(CameraKeyframe{
+		Time:        4 * time.Second,
+		Position:    Vector{0, 10, 0},
+		Orientation: QuaternionFromAxisAngle(Vector{X: 1}, -math.Pi/6),
+		FOV:         math.Pi / 2,
+	})
+
+	var buffer bytes.Buffer
+	if _, err := track.WriteTo(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ReadCameraTrack(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.Loop != track.Loop || loaded.Speed != track.Speed {
+		t.Errorf("expected loop %v and speed %v, got %v and %v", track.Loop, track.Speed, loaded.Loop, loaded.Speed)
+	}
+	if len(loaded.Keyframes) != len(track.Keyframes) {
+		t.Fatalf("expected %d keyframes, got %d", len(track.Keyframes), len(loaded.Keyframes))
+	}
+	for i, expected := range track.Keyframes {
+		k := loaded.Keyframes[i]
+		orientation := Vector{k.Orientation.X, k.Orientation.Y, k.Orientation.Z}
+		expectedOrientation := Vector{expected.Orientation.X, expected.Orientation.Y, expected.Orientation.Z}
+		if k.Time != expected.Time || k.Position != expected.Position || k.FOV != expected.FOV || k.Interpolation != expected.Interpolation || k.Easing != expected.Easing {
+			t.Errorf("keyframe %d: expected %+v, got %+v", i, expected, k)
+		}
+		if !vectorNearlyEqual(orientation, expectedOrientation) || math.Abs(float64(k.Orientation.W-expected.Orientation.W)) > matrixEpsilon {
+			t.Errorf("keyframe %d: expected the orientation %+v, got %+v", i, expected.Orientation, k.Orientation)
+		}
+	}
+
+	// an unknown easing or interpolation is rejected
+	for _, data := range []string{
+		`{"keyframes": [{"time": 0, "interpolation": "smooth", "easing": "wobbly"}]}`,
+		`{"keyframes": [{"time": 0, "interpolation": "cubic"}]}`,
+	} {
+		if _, err := ReadCameraTrack(bytes.NewBufferString(data)); err == nil {
+			t.Errorf("expected %s to be rejected", data)
+		}
+	}
+}
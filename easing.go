@@ -0,0 +1,155 @@
+package opengl_exercise
+
+import (
+	"math"
+)
+
+// EasingFunc maps linear progress from 0 to 1 onto eased progress, which starts at 0 and ends at 1 but may overshoot in between
+type EasingFunc func(t float32) float32
+
+func EaseLinear(t float32) float32 {
+	return t
+}
+
+func EaseInQuad(t float32) float32 {
+	return t * t
+}
+
+func EaseOutQuad(t float32) float32 {
+	return 1 - EaseInQuad(1-t)
+}
+
+func EaseInOutQuad(t float32) float32 {
+	return easeInOut(EaseInQuad, t)
+}
+
+func EaseInCubic(t float32) float32 {
+	return t * t * t
+}
+
+func EaseOutCubic(t float32) float32 {
+	return 1 - EaseInCubic(1-t)
+}
+
+func EaseInOutCubic(t float32) float32 {
+	return easeInOut(EaseInCubic, t)
+}
+
+func EaseInQuart(t float32) float32 {
+	return t * t * t * t
+}
+
+func EaseOutQuart(t float32) float32 {
+	return 1 - EaseInQuart(1-t)
+}
+
+func EaseInOutQuart(t float32) float32 {
+	return easeInOut(EaseInQuart, t)
+}
+
+func EaseInSine(t float32) float32 {
+	return 1 - float32(math.Cos(float64(t)*math.Pi/2))
+}
+
+func EaseOutSine(t float32) float32 {
+	return float32(math.Sin(float64(t) * math.Pi / 2))
+}
+
+func EaseInOutSine(t float32) float32 {
+	return 0.5 - 0.5*float32(math.Cos(float64(t)*math.Pi))
+}
+
+func EaseInExpo(t float32) float32 {
+	if t <= 0 {
+		return 0
+	}
+
+	return float32(math.Pow(2, 10*float64(t)-10))
+}
+
+func EaseOutExpo(t float32) float32 {
+	return 1 - EaseInExpo(1-t)
+}
+
+func EaseInOutExpo(t float32) float32 {
+	return easeInOut(EaseInExpo, t)
+}
+
+func EaseInCirc(t float32) float32 {
+	return 1 - float32(math.Sqrt(math.Max(0, 1-float64(t*t))))
+}
+
+func EaseOutCirc(t float32) float32 {
+	return 1 - EaseInCirc(1-t)
+}
+
+func EaseInOutCirc(t float32) float32 {
+	return easeInOut(EaseInCirc, t)
+}
+
+// EaseInBack pulls back slightly before moving forward
+func EaseInBack(t float32) float32 {
+	const overshoot = 1.70158
+	return t * t * ((overshoot+1)*t - overshoot)
+}
+
+func EaseOutBack(t float32) float32 {
+	return 1 - EaseInBack(1-t)
+}
+
+func EaseInOutBack(t float32) float32 {
+	return easeInOut(EaseInBack, t)
+}
+
+// EaseInElastic wobbles with growing amplitude before snapping to the end
+func EaseInElastic(t float32) float32 {
+	if t <= 0 || t >= 1 {
+		return t
+	}
+
+	return -float32(math.Pow(2, 10*float64(t)-10) * math.Sin((float64(t)*10-10.75)*(2*math.Pi/3)))
+}
+
+func EaseOutElastic(t float32) float32 {
+	return 1 - EaseInElastic(1-t)
+}
+
+func EaseInOutElastic(t float32) float32 {
+	return easeInOut(EaseInElastic, t)
+}
+
+// EaseOutBounce settles at the end like a ball bouncing on the floor
+func EaseOutBounce(t float32) float32 {
+	const n, d = 7.5625, 2.75
+
+	switch {
+	case t < 1/d:
+		return n * t * t
+	case t < 2/d:
+		t -= 1.5 / d
+		return n*t*t + 0.75
+	case t < 2.5/d:
+		t -= 2.25 / d
+		return n*t*t + 0.9375
+	default:
+		t -= 2.625 / d
+		return n*t*t + 0.984375
+	}
+}
+
+func EaseInBounce(t float32) float32 {
+	return 1 - EaseOutBounce(1-t)
+}
+
+func EaseInOutBounce(t float32) float32 {
+	return easeInOut(EaseInBounce, t)
+}
+
+// easeInOut runs the ease in function over the first half and its mirror over the second half
+func easeInOut(easeIn EasingFunc, t float32) float32 {
+	if t < 0.5 {
+		return easeIn(t*2) * 0.5
+	}
+
+	return 1 - easeIn((1-t)*2)*0.5
+}
@@ -0,0 +1,68 @@
+package opengl_exercise
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEasingEndpoints(t *testing.T) {
+	const epsilon = 1e-6
+
+	for name, easing := range easingFuncs {
+		t.Run(name, func(t *testing.T) {
+			if start := easing(0); math.Abs(float64(start)) > epsilon {
+				t.Errorf("expected 0 at the start, got %v", start)
+			}
+			if end := easing(1); math.Abs(float64(end-1)) > epsilon {
+				t.Errorf("expected 1 at the end, got %v", end)
+			}
+		})
+	}
+}
+
+func TestEasingInOut(t *testing.T) {
+	tests := []struct {
+		name          string
+		in, out, both EasingFunc
+	}{
+		{"quad", EaseInQuad, EaseOutQuad, EaseInOutQuad},
+		{"cubic", EaseInCubic, EaseOutCubic, EaseInOutCubic},
+		{"quart", EaseInQuart, EaseOutQuart, EaseInOutQuart},
+		{"sine", EaseInSine, EaseOutSine, EaseInOutSine},
+		{"expo", EaseInExpo, EaseOutExpo, EaseInOutExpo},
+		{"circ", EaseInCirc, EaseOutCirc, EaseInOutCirc},
+		{"back", EaseInBack, EaseOutBack, EaseInOutBack},
+		{"elastic", EaseInElastic, EaseOutElastic, EaseInOutElastic},
+		{"bounce", EaseInBounce, EaseOutBounce, EaseInOutBounce},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, at := range []float32{0.1, 0.25, 0.4, 0.5, 0.7} {
+				// ease out is ease in played backwards
+				if in, out := test.in(at), test.out(1-at); math.Abs(float64(in+out-1)) > matrixEpsilon {
+					t.Errorf("t %v: expected ease in %v and ease out %v to add up to 1", at, in, out)
+				}
+				// the in out curve is point symmetric around the middle
+				if a, b := test.both(at), test.both(1-at); math.Abs(float64(a+b-1)) > matrixEpsilon {
+					t.Errorf("t %v: expected %v and %v to add up to 1", at, a, b)
+				}
+			}
+			if middle := test.both(0.5); math.Abs(float64(middle-0.5)) > matrixEpsilon {
+				t.Errorf("expected 0.5 in the middle, got %v", middle)
+			}
+		})
+	}
+
+	// the polynomial curves never leave the 0 to 1 range and never turn back
+	for _, easing := range []EasingFunc{EaseInQuad, EaseOutCubic, EaseInOutQuart, EaseInOutSine, EaseInOutExpo, EaseInOutCirc} {
+		previous := float32(0)
+		for i := 1; i <= 100; i++ {
+			value := easing(float32(i) / 100)
+			if value < previous || value > 1 {
+				t.Fatalf("t %v: expected a value from %v to 1, got %v", float32(i)/100, previous, value)
+			}
+			previous = value
+		}
+	}
+}
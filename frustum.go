@@ -0,0 +1,149 @@
+package opengl_exercise
+
+import (
+	"math"
+)
+
+// Containment is the result of testing a volume against a Frustum
+type Containment int
+
+const (
+	Outside Containment = iota
+	Intersecting
+	Inside
+)
+
+func (c Containment) String() string {
+	switch c {
+	case Outside:
+		return "outside"
+	case Intersecting:
+		return "intersecting"
+	case Inside:
+		return "inside"
+	default:
+		return "unknown"
+	}
+}
+
+// Plane is the set of points p where Normal.Dot(p) + D is zero, points on the side the normal faces have a positive distance
+type Plane struct {
+	Normal Vector
+	D      float32
+}
+
+// Normalize scales the plane so Distance returns real distances
+func (p Plane) Normalize() Plane {
+	length := float32(math.Sqrt(float64(p.Normal.Dot(p.Normal))))
+	if length < 0.000001 {
+		return p
+	}
+
+	return Plane{
+		Normal: p.Normal.MultiplyScalar(1 / length),
+		D:      p.D / length,
+	}
+}
+
+// Distance returns the signed distance from the plane to a point
+func (p Plane) Distance(point Vector) float32 {
+	return p.Normal.Dot(point) + p.D
+}
+
+const (
+	FrustumLeft = iota
+	FrustumRight
+	FrustumBottom
+	FrustumTop
+	FrustumNear
+	FrustumFar
+)
+
+// Frustum is the volume visible through a projection, stored as six planes that all face inwards
+type Frustum [6]Plane
+
+// NewFrustum extracts the frustum planes from a combined view and projection matrix.
+// The planes come out in the space the matrix transforms from, so passing world*view*projection gives planes in model space.
+func NewFrustum(viewProjection *Matrix) Frustum {
+	m := viewProjection
+
+	// a point is visible when -w <= x <= w, -w <= y <= w and 0 <= z <= w in clip space.
+	// each of those comparisons is a plane made of the matrix columns.
+	column := func(i int) Plane {
+		return Plane{Normal: Vector{X: m[i], Y: m[4+i], Z: m[8+i]}, D: m[12+i]}
+	}
+	add := func(a, b Plane) Plane {
+		return Plane{Normal: a.Normal.AddVector(b.Normal), D: a.D + b.D}
+	}
+	subtract := func(a, b Plane) Plane {
+		return Plane{Normal: a.Normal.AddVector(b.Normal.Negative()), D: a.D - b.D}
+	}
+
+	x, y, z, w := column(0), column(1), column(2), column(3)
+
+	var f Frustum
+	f[FrustumLeft] = add(w, x).Normalize()
+	f[FrustumRight] = subtract(w, x).Normalize()
+	f[FrustumBottom] = add(w, y).Normalize()
+	f[FrustumTop] = subtract(w, y).Normalize()
+	f[FrustumNear] = z.Normalize()
+	f[FrustumFar] = subtract(w, z).Normalize()
+
+	return f
+}
+
+// ContainsPoint tests whether a point is inside the frustum
+func (f *Frustum) ContainsPoint(point Vector) Containment {
+	for _, plane := range f {
+		if plane.Distance(point) < 0 {
+			return Outside
+		}
+	}
+
+	return Inside
+}
+
+// ContainsSphere tests a sphere against the frustum
+func (f *Frustum) ContainsSphere(center Vector, radius float32) Containment {
+	result := Inside
+	for _, plane := range f {
+		distance := plane.Distance(center)
+		if distance < -radius {
+			return Outside
+		}
+
+		if distance < radius {
+			result = Intersecting
+		}
+	}
+
+	return result
+}
+
+// ContainsAABB tests an axis aligned box given by its minimum and maximum corners against the frustum
+func (f *Frustum) ContainsAABB(min, max Vector) Containment {
+	result := Inside
+	for _, plane := range f {
+		// the corners furthest along and against the plane normal
+		positive, negative := max, min
+		if plane.Normal.X < 0 {
+			positive.X, negative.X = min.X, max.X
+		}
+		if plane.Normal.Y < 0 {
+			positive.Y, negative.Y = min.Y, max.Y
+		}
+		if plane.Normal.Z < 0 {
+			positive.Z, negative.Z = min.Z, max.Z
+		}
+
+		if plane.Distance(positive) < 0 {
+			return Outside
+		}
+
+		if plane.Distance(negative) < 0 {
+			result = Intersecting
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,156 @@
+package opengl_exercise
+
+import (
+	"math"
+	"testing"
+)
+
+// planeNearlyEqual compares the distances relative to their size, planes far from the origin lose absolute precision
+func planeNearlyEqual(a, b Plane) bool {
+	return vectorNearlyEqual(a.Normal, b.Normal) && math.Abs(float64(a.D-b.D)) < matrixEpsilon*math.Max(1, math.Abs(float64(b.D)))
+}
+
+func TestNewFrustum(t *testing.T) {
+	// a square 90 degree view, so the side planes lean at 45 degrees
+	const near, far = 1, 100
+	diagonal := float32(1 / math.Sqrt(2))
+	sides := [4]Plane{
+		FrustumLeft:   {Normal: Vector{X: diagonal, Z: diagonal}},
+		FrustumRight:  {Normal: Vector{X: -diagonal, Z: diagonal}},
+		FrustumBottom: {Normal: Vector{Y: diagonal, Z: diagonal}},
+		FrustumTop:    {Normal: Vector{Y: -diagonal, Z: diagonal}},
+	}
+	nearPlane := Plane{Normal: Vector{Z: 1}, D: -near}
+	farPlane := Plane{Normal: Vector{Z: -1}, D: far}
+
+	tests := []struct {
+		name       string
+		projection Matrix
+		// the depth planes in the order the clip space range gives them, reversed-Z swaps them
+		near, far Plane
+	}{
+		{"perspective", PerspectiveFovLHMatrix(math.Pi/2, 1, near, far), nearPlane, farPlane},
+		{"reversed z", PerspectiveFovReversedZLHMatrix(math.Pi/2, 1, near, far), farPlane, nearPlane},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			frustum := NewFrustum(&test.projection)
+
+			for i, expected := range sides {
+				if !planeNearlyEqual(frustum[i], expected) {
+					t.Errorf("plane %d: expected %+v, got %+v", i, expected, frustum[i])
+				}
+			}
+			if !planeNearlyEqual(frustum[FrustumNear], test.near) {
+				t.Errorf("near plane: expected %+v, got %+v", test.near, frustum[FrustumNear])
+			}
+			if !planeNearlyEqual(frustum[FrustumFar], test.far) {
+				t.Errorf("far plane: expected %+v, got %+v", test.far, frustum[FrustumFar])
+			}
+		})
+	}
+
+	t.Run("model space", func(t *testing.T) {
+		// a model 50 units in front of the camera has its origin in the middle of the view
+		projection := PerspectiveFovLHMatrix(math.Pi/2, 1, near, far)
+		world := TranslationMatrix(0, 0, 50)
+		worldProjection := world.Multiply(&projection)
+		frustum := NewFrustum(&worldProjection)
+
+		if expected := (Plane{Normal: Vector{Z: 1}, D: 49}); !planeNearlyEqual(frustum[FrustumNear], expected) {
+			t.Errorf("near plane: expected %+v, got %+v", expected, frustum[FrustumNear])
+		}
+		if distance := frustum[FrustumLeft].Distance(Vector{}); math.Abs(float64(distance-50*diagonal)) > matrixEpsilon {
+			t.Errorf("expected the origin %v from the left plane, got %v", 50*diagonal, distance)
+		}
+	})
+}
+
+func TestFrustumContains(t *testing.T) {
+	const near, far = 1, 100
+	projections := []struct {
+		name       string
+		projection Matrix
+	}{
+		{"perspective", PerspectiveFovLHMatrix(math.Pi/2, 1, near, far)},
+		{"reversed z", PerspectiveFovReversedZLHMatrix(math.Pi/2, 1, near, far)},
+	}
+
+	points := []struct {
+		name     string
+		point    Vector
+		expected Containment
+	}{
+		{"center", Vector{Z: 50}, Inside},
+		{"close to the corner", Vector{X: 49, Y: -49, Z: 50}, Inside},
+		{"before the near plane", Vector{Z: 0.5}, Outside},
+		{"behind the camera", Vector{Z: -10}, Outside},
+		{"beyond the far plane", Vector{Z: 150}, Outside},
+		{"left", Vector{X: -60, Z: 50}, Outside},
+		{"above", Vector{Y: 60, Z: 50}, Outside},
+	}
+
+	spheres := []struct {
+		name     string
+		center   Vector
+		radius   float32
+		expected Containment
+	}{
+		{"inside", Vector{Z: 50}, 1, Inside},
+		{"across the near plane", Vector{Z: 1}, 0.5, Intersecting},
+		{"across the far plane", Vector{Z: 100}, 5, Intersecting},
+		{"across the right plane", Vector{X: 51, Z: 50}, 2, Intersecting},
+		{"behind the camera", Vector{Z: -5}, 1, Outside},
+		{"just outside the top plane", Vector{Y: 53, Z: 50}, 2, Outside},
+		{"around the frustum", Vector{Z: 50}, 500, Intersecting},
+	}
+
+	boxes := []struct {
+		name     string
+		min, max Vector
+		expected Containment
+	}{
+		{"inside", Vector{-1, -1, 40}, Vector{1, 1, 60}, Inside},
+		{"across the near plane", Vector{-1, -1, -1}, Vector{1, 1, 2}, Intersecting},
+		{"across the far plane", Vector{-1, -1, 90}, Vector{1, 1, 110}, Intersecting},
+		{"across the left plane", Vector{-60, -1, 40}, Vector{-40, 1, 45}, Intersecting},
+		{"left", Vector{-80, -1, 40}, Vector{-70, 1, 45}, Outside},
+		{"behind the camera", Vector{-10, -10, -20}, Vector{10, 10, -5}, Outside},
+		{"around the frustum", Vector{-500, -500, -500}, Vector{500, 500, 500}, Intersecting},
+	}
+
+	for _, projection := range projections {
+		frustum := NewFrustum(&projection.projection)
+
+		t.Run(projection.name, func(t *testing.T) {
+			for _, test := range points {
+				if result := frustum.ContainsPoint(test.point); result != test.expected {
+					t.Errorf("point %s: expected %v, got %v", test.name, test.expected, result)
+				}
+			}
+			for _, test := range spheres {
+				if result := frustum.ContainsSphere(test.center, test.radius); result != test.expected {
+					t.Errorf("sphere %s: expected %v, got %v", test.name, test.expected, result)
+				}
+			}
+			for _, test := range boxes {
+				if result := frustum.ContainsAABB(test.min, test.max); result != test.expected {
+					t.Errorf("box %s: expected %v, got %v", test.name, test.expected, result)
+				}
+			}
+		})
+	}
+
+	t.Run("infinite far plane", func(t *testing.T) {
+		for _, projection := range []Matrix{PerspectiveFovInfiniteLHMatrix(math.Pi/2, 1, near), PerspectiveFovInfiniteReversedZLHMatrix(math.Pi/2, 1, near)} {
+			frustum := NewFrustum(&projection)
+			if result := frustum.ContainsPoint(Vector{Z: 1e6}); result != Inside {
+				t.Errorf("expected a far away point to be inside, got %v", result)
+			}
+			if result := frustum.ContainsSphere(Vector{Z: 0.5}, 0.1); result != Outside {
+				t.Errorf("expected a sphere before the near plane to be outside, got %v", result)
+			}
+		}
+	})
+}
@@ -0,0 +1,1331 @@
+package opengl_exercise
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// the parts of a glTF 2.0 document that are loaded, names follow the specification
+type gltfDocument struct {
+	Asset struct {
+		Version    string `json:"version"`
+		MinVersion string `json:"minVersion"`
+	} `json:"asset"`
+
+	ExtensionsRequired []string `json:"extensionsRequired"`
+
+	Scene       *int             `json:"scene"`
+	Scenes      []gltfScene      `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes"`
+	Meshes      []gltfMesh       `json:"meshes"`
+	Accessors   []gltfAccessor   `json:"accessors"`
+	BufferViews []gltfBufferView `json:"bufferViews"`
+	Buffers     []gltfBuffer     `json:"buffers"`
+	Materials   []gltfMaterial   `json:"materials"`
+	Textures    []gltfTexture    `json:"textures"`
+	Images      []gltfImage      `json:"images"`
+	Cameras     []gltfCamera     `json:"cameras"`
+	Animations  []gltfAnimation  `json:"animations"`
+}
+
+type gltfScene struct {
+	Name  string `json:"name"`
+	Nodes []int  `json:"nodes"`
+}
+
+type gltfNode struct {
+	Name        string    `json:"name"`
+	Children    []int     `json:"children"`
+	Matrix      []float32 `json:"matrix"`
+	Translation []float32 `json:"translation"`
+	Rotation    []float32 `json:"rotation"`
+	Scale       []float32 `json:"scale"`
+	Mesh        *int      `json:"mesh"`
+	Camera      *int      `json:"camera"`
+}
+
+type gltfMesh struct {
+	Name       string          `json:"name"`
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices"`
+	Material   *int           `json:"material"`
+	Mode       *int           `json:"mode"`
+}
+
+type gltfAccessor struct {
+	BufferView    *int        `json:"bufferView"`
+	ByteOffset    int         `json:"byteOffset"`
+	ComponentType int         `json:"componentType"`
+	Normalized    bool        `json:"normalized"`
+	Count         int         `json:"count"`
+	Type          string      `json:"type"`
+	Sparse        *gltfSparse `json:"sparse"`
+}
+
+type gltfSparse struct {
+	Count   int `json:"count"`
+	Indices struct {
+		BufferView    int `json:"bufferView"`
+		ByteOffset    int `json:"byteOffset"`
+		ComponentType int `json:"componentType"`
+	} `json:"indices"`
+	Values struct {
+		BufferView int `json:"bufferView"`
+		ByteOffset int `json:"byteOffset"`
+	} `json:"values"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride"`
+}
+
+type gltfBuffer struct {
+	URI        string `json:"uri"`
+	ByteLength int    `json:"byteLength"`
+}
+
+type gltfMaterial struct {
+	Name                 string `json:"name"`
+	PbrMetallicRoughness *struct {
+		BaseColorFactor          []float32        `json:"baseColorFactor"`
+		BaseColorTexture         *gltfTextureInfo `json:"baseColorTexture"`
+		MetallicFactor           *float32         `json:"metallicFactor"`
+		RoughnessFactor          *float32         `json:"roughnessFactor"`
+		MetallicRoughnessTexture *gltfTextureInfo `json:"metallicRoughnessTexture"`
+	} `json:"pbrMetallicRoughness"`
+	NormalTexture    *gltfTextureInfo `json:"normalTexture"`
+	OcclusionTexture *gltfTextureInfo `json:"occlusionTexture"`
+	EmissiveTexture  *gltfTextureInfo `json:"emissiveTexture"`
+	EmissiveFactor   []float32        `json:"emissiveFactor"`
+	AlphaMode        string           `json:"alphaMode"`
+	AlphaCutoff      *float32         `json:"alphaCutoff"`
+	DoubleSided      bool             `json:"doubleSided"`
+}
+
+type gltfTextureInfo struct {
+	Index    int `json:"index"`
+	TexCoord int `json:"texCoord"`
+}
+
+type gltfTexture struct {
+	Source *int `json:"source"`
+}
+
+type gltfImage struct {
+	Name       string `json:"name"`
+	URI        string `json:"uri"`
+	MimeType   string `json:"mimeType"`
+	BufferView *int   `json:"bufferView"`
+}
+
+type gltfCamera struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Perspective *struct {
+		AspectRatio float32  `json:"aspectRatio"`
+		Yfov        float32  `json:"yfov"`
+		Zfar        *float32 `json:"zfar"`
+		Znear       float32  `json:"znear"`
+	} `json:"perspective"`
+	Orthographic *struct {
+		Xmag  float32 `json:"xmag"`
+		Ymag  float32 `json:"ymag"`
+		Zfar  float32 `json:"zfar"`
+		Znear float32 `json:"znear"`
+	} `json:"orthographic"`
+}
+
+type gltfAnimation struct {
+	Name     string `json:"name"`
+	Channels []struct {
+		Sampler int `json:"sampler"`
+		Target  struct {
+			Node *int   `json:"node"`
+			Path string `json:"path"`
+		} `json:"target"`
+	} `json:"channels"`
+	Samplers []struct {
+		Input         int    `json:"input"`
+		Interpolation string `json:"interpolation"`
+		Output        int    `json:"output"`
+	} `json:"samplers"`
+}
+
+const (
+	gltfByte          = 5120
+	gltfUnsignedByte  = 5121
+	gltfShort         = 5122
+	gltfUnsignedShort = 5123
+	gltfUnsignedInt   = 5125
+	gltfFloat         = 5126
+)
+
+// gltfMaxZeroValues limits the values of an accessor without a buffer view, which are allocated without any data
+// in the file to back them
+const gltfMaxZeroValues = 1 << 24
+
+var gltfComponentSizes = map[int]int{
+	gltfByte:          1,
+	gltfUnsignedByte:  1,
+	gltfShort:         2,
+	gltfUnsignedShort: 2,
+	gltfUnsignedInt:   4,
+	gltfFloat:         4,
+}
+
+var gltfTypeComponents = map[string]int{
+	"SCALAR": 1,
+	"VEC2":   2,
+	"VEC3":   3,
+	"VEC4":   4,
+	"MAT2":   4,
+	"MAT3":   9,
+	"MAT4":   16,
+}
+
+const (
+	glbMagic     = 0x46546C67 // "glTF"
+	glbChunkJSON = 0x4E4F534A // "JSON"
+	glbChunkBIN  = 0x004E4942 // "BIN\x00"
+)
+
+// LoadGLTF reads a .gltf or .glb file, external buffers and images are looked up next to it
+func LoadGLTF(path string) (*Scene, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	dir := filepath.Dir(path)
+	scene, err := ReadGLTF(file, func(uri string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join(dir, filepath.FromSlash(uri)))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return scene, nil
+}
+
+// ReadGLTF reads glTF 2.0 JSON or a GLB container. openFile opens buffers referred to by a relative URI,
+// when it is nil only embedded data can be used.
+//
+// glTF is right-handed, so z is negated and the triangle corners are swapped like for OBJ files.
+// Texture coordinates are flipped vertically because glTF puts their origin at the top left.
+func ReadGLTF(r io.Reader, openFile func(uri string) (io.ReadCloser, error)) (*Scene, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	loader := &gltfLoader{openFile: openFile, scene: &Scene{}}
+
+	jsonData := data
+	if len(data) >= 4 && binary.LittleEndian.Uint32(data) == glbMagic {
+		if jsonData, loader.bin, err = parseGLB(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := json.Unmarshal(jsonData, &loader.doc); err != nil {
+		return nil, fmt.Errorf("gltf: invalid JSON: %v", err)
+	}
+
+	if err := loader.load(); err != nil {
+		return nil, err
+	}
+
+	return loader.scene, nil
+}
+
+// parseGLB splits a binary glTF container into its JSON and binary chunk
+func parseGLB(data []byte) (jsonChunk, binChunk []byte, err error) {
+	if len(data) < 12 {
+		return nil, nil, errors.New("glb: file too short for the header")
+	}
+
+	if version := binary.LittleEndian.Uint32(data[4:]); version != 2 {
+		return nil, nil, fmt.Errorf("glb: unsupported container version %d", version)
+	}
+
+	length := binary.LittleEndian.Uint32(data[8:])
+	if uint64(length) > uint64(len(data)) || length < 12 {
+		return nil, nil, fmt.Errorf("glb: header length %d does not match the file size %d", length, len(data))
+	}
+	data = data[:length]
+
+	for offset, index := 12, 0; offset < len(data); index++ {
+		if len(data)-offset < 8 {
+			return nil, nil, fmt.Errorf("glb: chunk %d header is cut off", index)
+		}
+
+		chunkLength := int(binary.LittleEndian.Uint32(data[offset:]))
+		chunkType := binary.LittleEndian.Uint32(data[offset+4:])
+		offset += 8
+		if chunkLength > len(data)-offset {
+			return nil, nil, fmt.Errorf("glb: chunk %d is longer than the file", index)
+		}
+		chunk := data[offset : offset+chunkLength]
+		offset += chunkLength
+
+		switch {
+		case index == 0 && chunkType != glbChunkJSON:
+			return nil, nil, errors.New("glb: the first chunk must be JSON")
+		case index == 0:
+			jsonChunk = chunk
+		case index == 1 && chunkType == glbChunkBIN:
+			binChunk = chunk
+		case chunkType == glbChunkJSON || chunkType == glbChunkBIN:
+			return nil, nil, fmt.Errorf("glb: unexpected chunk %d of type %08x", index, chunkType)
+		}
+
+		// chunks of unknown types are skipped
+	}
+
+	if jsonChunk == nil {
+		return nil, nil, errors.New("glb: missing JSON chunk")
+	}
+
+	return jsonChunk, binChunk, nil
+}
+
+type gltfLoader struct {
+	doc      gltfDocument
+	bin      []byte
+	buffers  [][]byte
+	openFile func(uri string) (io.ReadCloser, error)
+
+	scene *Scene
+}
+
+func (l *gltfLoader) load() error {
+	if err := l.checkAsset(); err != nil {
+		return err
+	}
+
+	steps := []func() error{
+		l.loadBuffers,
+		l.checkBufferViews,
+		l.checkAccessors,
+		l.loadImages,
+		l.loadMaterials,
+		l.loadMeshes,
+		l.loadCameras,
+		l.loadNodes,
+		l.loadScene,
+		l.loadAnimations,
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func gltfErrorf(format string, args ...interface{}) error {
+	return fmt.Errorf("gltf: "+format, args...)
+}
+
+// checkIndex validates a reference from one glTF object to another
+func checkIndex(kind string, index, count int) error {
+	if index < 0 || index >= count {
+		return gltfErrorf("%s index %d out of range, %d defined", kind, index, count)
+	}
+
+	return nil
+}
+
+func (l *gltfLoader) checkAsset() error {
+	major := func(version string) (int, error) {
+		parts := strings.SplitN(version, ".", 2)
+		if len(parts) != 2 {
+			return 0, gltfErrorf("invalid asset version '%s'", version)
+		}
+		return strconv.Atoi(parts[0])
+	}
+
+	if l.doc.Asset.Version == "" {
+		return gltfErrorf("asset.version is required")
+	}
+
+	if version, err := major(l.doc.Asset.Version); err != nil || version != 2 {
+		return gltfErrorf("unsupported version '%s'", l.doc.Asset.Version)
+	}
+
+	if l.doc.Asset.MinVersion != "" && l.doc.Asset.MinVersion != "2.0" {
+		return gltfErrorf("unsupported minimum version '%s'", l.doc.Asset.MinVersion)
+	}
+
+	for _, extension := range l.doc.ExtensionsRequired {
+		return gltfErrorf("required extension '%s' is not supported", extension)
+	}
+
+	return nil
+}
+
+func (l *gltfLoader) loadBuffers() error {
+	l.buffers = make([][]byte, len(l.doc.Buffers))
+	for i, buffer := range l.doc.Buffers {
+		if buffer.ByteLength < 1 {
+			return gltfErrorf("buffers[%d]: byteLength must be at least 1", i)
+		}
+
+		var data []byte
+		var err error
+		switch {
+		case buffer.URI == "" && i == 0 && l.bin != nil:
+			data = l.bin
+		case buffer.URI == "":
+			return gltfErrorf("buffers[%d]: missing uri", i)
+		default:
+			if data, _, err = l.readURI(buffer.URI); err != nil {
+				return gltfErrorf("buffers[%d]: %v", i, err)
+			}
+		}
+
+		// the binary chunk may be padded by up to three bytes
+		if len(data) < buffer.ByteLength {
+			return gltfErrorf("buffers[%d]: byteLength is %d but only %d bytes are available", i, buffer.ByteLength, len(data))
+		}
+		l.buffers[i] = data[:buffer.ByteLength]
+	}
+
+	return nil
+}
+
+// readURI returns the contents of a data URI or of a file relative to the glTF file
+func (l *gltfLoader) readURI(uri string) (data []byte, mimeType string, err error) {
+	if strings.HasPrefix(uri, "data:") {
+		comma := strings.IndexByte(uri, ',')
+		if comma < 0 || !strings.HasSuffix(uri[:comma], ";base64") {
+			return nil, "", errors.New("only base64 data URIs are supported")
+		}
+
+		mimeType = strings.TrimSuffix(uri[len("data:"):comma], ";base64")
+		data, err = base64.StdEncoding.DecodeString(uri[comma+1:])
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid base64 data: %v", err)
+		}
+		return data, mimeType, nil
+	}
+
+	if l.openFile == nil {
+		return nil, "", fmt.Errorf("no way to open external file '%s'", uri)
+	}
+
+	path, err := url.PathUnescape(uri)
+	if err != nil {
+		return nil, "", err
+	}
+
+	file, err := l.openFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer file.Close()
+
+	data, err = ioutil.ReadAll(file)
+	return data, "", err
+}
+
+func (l *gltfLoader) checkBufferViews() error {
+	for i, view := range l.doc.BufferViews {
+		if err := checkIndex("buffer", view.Buffer, len(l.buffers)); err != nil {
+			return gltfErrorf("bufferViews[%d]: %v", i, err)
+		}
+
+		// compared without adding the two, which could overflow for lengths taken from the file
+		if view.ByteLength < 1 || view.ByteOffset < 0 || view.ByteLength > len(l.buffers[view.Buffer]) || view.ByteOffset > len(l.buffers[view.Buffer])-view.ByteLength {
+			return gltfErrorf("bufferViews[%d]: range %d+%d does not fit into buffer %d", i, view.ByteOffset, view.ByteLength, view.Buffer)
+		}
+
+		if view.ByteStride != 0 && (view.ByteStride < 4 || view.ByteStride > 252 || view.ByteStride%4 != 0) {
+			return gltfErrorf("bufferViews[%d]: byteStride %d must be a multiple of 4 between 4 and 252", i, view.ByteStride)
+		}
+	}
+
+	return nil
+}
+
+// gltfLayout describes how the elements of an accessor are stored
+type gltfLayout struct {
+	components    int
+	componentSize int
+
+	// matrix columns are padded to four bytes
+	columns, rows int
+	columnStride  int
+	elementSize   int
+}
+
+func accessorLayout(accessor *gltfAccessor) (gltfLayout, error) {
+	componentSize, ok := gltfComponentSizes[accessor.ComponentType]
+	if !ok {
+		return gltfLayout{}, fmt.Errorf("invalid componentType %d", accessor.ComponentType)
+	}
+
+	components, ok := gltfTypeComponents[accessor.Type]
+	if !ok {
+		return gltfLayout{}, fmt.Errorf("invalid type '%s'", accessor.Type)
+	}
+
+	layout := gltfLayout{components: components, componentSize: componentSize, columns: 1, rows: components}
+	if strings.HasPrefix(accessor.Type, "MAT") {
+		layout.columns = int(math.Sqrt(float64(components)))
+		layout.rows = layout.columns
+	}
+
+	layout.columnStride = layout.rows * componentSize
+	if layout.columns > 1 {
+		layout.columnStride = (layout.columnStride + 3) &^ 3
+	}
+	layout.elementSize = layout.columns * layout.columnStride
+
+	return layout, nil
+}
+
+func (l *gltfLoader) checkAccessors() error {
+	for i := range l.doc.Accessors {
+		if err := l.checkAccessor(i); err != nil {
+			return gltfErrorf("accessors[%d]: %v", i, err)
+		}
+	}
+
+	return nil
+}
+
+func (l *gltfLoader) checkAccessor(index int) error {
+	accessor := &l.doc.Accessors[index]
+
+	layout, err := accessorLayout(accessor)
+	if err != nil {
+		return err
+	}
+
+	if accessor.Count < 1 {
+		return errors.New("count must be at least 1")
+	}
+
+	if accessor.Normalized && (accessor.ComponentType == gltfFloat || accessor.ComponentType == gltfUnsignedInt) {
+		return errors.New("only byte and short components can be normalized")
+	}
+
+	if accessor.BufferView != nil {
+		if err := checkIndex("bufferView", *accessor.BufferView, len(l.doc.BufferViews)); err != nil {
+			return err
+		}
+		view := &l.doc.BufferViews[*accessor.BufferView]
+
+		if accessor.ByteOffset < 0 || accessor.ByteOffset%layout.componentSize != 0 || (view.ByteOffset+accessor.ByteOffset)%layout.componentSize != 0 {
+			return fmt.Errorf("byteOffset %d is not aligned to the component size", accessor.ByteOffset)
+		}
+
+		stride := view.ByteStride
+		if stride == 0 {
+			stride = layout.elementSize
+		} else if stride < layout.elementSize {
+			return fmt.Errorf("byteStride %d is smaller than an element", stride)
+		}
+
+		// the count is compared to the number of elements that fit, multiplying it could overflow
+		if accessor.ByteOffset > view.ByteLength-layout.elementSize || accessor.Count-1 > (view.ByteLength-accessor.ByteOffset-layout.elementSize)/stride {
+			return errors.New("elements do not fit into the buffer view")
+		}
+	} else if accessor.Count > gltfMaxZeroValues/layout.components {
+		// without a buffer view no data limits the count, and all of its values are allocated
+		return fmt.Errorf("count %d is too large for an accessor without a bufferView", accessor.Count)
+	}
+
+	if sparse := accessor.Sparse; sparse != nil {
+		if sparse.Count < 1 || sparse.Count > accessor.Count {
+			return fmt.Errorf("sparse count %d must be between 1 and %d", sparse.Count, accessor.Count)
+		}
+
+		indexSize, ok := gltfComponentSizes[sparse.Indices.ComponentType]
+		if !ok || sparse.Indices.ComponentType == gltfFloat || sparse.Indices.ComponentType == gltfByte || sparse.Indices.ComponentType == gltfShort {
+			return fmt.Errorf("invalid sparse index componentType %d", sparse.Indices.ComponentType)
+		}
+
+		for _, part := range []struct {
+			name        string
+			view, start int
+			elementSize int
+		}{
+			{"indices", sparse.Indices.BufferView, sparse.Indices.ByteOffset, indexSize},
+			{"values", sparse.Values.BufferView, sparse.Values.ByteOffset, layout.elementSize},
+		} {
+			if err := checkIndex("sparse "+part.name+" bufferView", part.view, len(l.doc.BufferViews)); err != nil {
+				return err
+			}
+
+			length := l.doc.BufferViews[part.view].ByteLength
+			if part.start < 0 || part.start > length || sparse.Count > (length-part.start)/part.elementSize {
+				return fmt.Errorf("sparse %s do not fit into the buffer view", part.name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// readComponent decodes one component, converting normalized integers to the 0 to 1 or -1 to 1 range
+func readComponent(data []byte, componentType int, normalized bool) float64 {
+	switch componentType {
+	case gltfByte:
+		value := float64(int8(data[0]))
+		if normalized {
+			return math.Max(value/127, -1)
+		}
+		return value
+	case gltfUnsignedByte:
+		value := float64(data[0])
+		if normalized {
+			return value / 255
+		}
+		return value
+	case gltfShort:
+		value := float64(int16(binary.LittleEndian.Uint16(data)))
+		if normalized {
+			return math.Max(value/32767, -1)
+		}
+		return value
+	case gltfUnsignedShort:
+		value := float64(binary.LittleEndian.Uint16(data))
+		if normalized {
+			return value / 65535
+		}
+		return value
+	case gltfUnsignedInt:
+		return float64(binary.LittleEndian.Uint32(data))
+	default:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(data)))
+	}
+}
+
+// readElements decodes count tightly packed or strided elements starting at data
+func readElements(data []byte, stride, count int, layout gltfLayout, componentType int, normalized bool) []float64 {
+	values := make([]float64, 0, count*layout.components)
+	for i := 0; i < count; i++ {
+		element := data[i*stride:]
+		for column := 0; column < layout.columns; column++ {
+			for row := 0; row < layout.rows; row++ {
+				offset := column*layout.columnStride + row*layout.componentSize
+				values = append(values, readComponent(element[offset:], componentType, normalized))
+			}
+		}
+	}
+
+	return values
+}
+
+// accessorValues decodes an accessor that passed checkAccessor, applying its sparse substitution
+func (l *gltfLoader) accessorValues(index int) ([]float64, *gltfAccessor, error) {
+	accessor := &l.doc.Accessors[index]
+	layout, _ := accessorLayout(accessor)
+
+	var values []float64
+	if accessor.BufferView == nil {
+		// accessors without a buffer view are all zeros, usually with sparse values on top
+		values = make([]float64, accessor.Count*layout.components)
+	} else {
+		view := &l.doc.BufferViews[*accessor.BufferView]
+		stride := view.ByteStride
+		if stride == 0 {
+			stride = layout.elementSize
+		}
+
+		data := l.buffers[view.Buffer][view.ByteOffset+accessor.ByteOffset:]
+		values = readElements(data, stride, accessor.Count, layout, accessor.ComponentType, accessor.Normalized)
+	}
+
+	if sparse := accessor.Sparse; sparse != nil {
+		indexView := &l.doc.BufferViews[sparse.Indices.BufferView]
+		indexSize := gltfComponentSizes[sparse.Indices.ComponentType]
+		indexData := l.buffers[indexView.Buffer][indexView.ByteOffset+sparse.Indices.ByteOffset:]
+		indices := readElements(indexData, indexSize, sparse.Count, gltfLayout{components: 1, componentSize: indexSize, columns: 1, rows: 1}, sparse.Indices.ComponentType, false)
+
+		valueView := &l.doc.BufferViews[sparse.Values.BufferView]
+		valueData := l.buffers[valueView.Buffer][valueView.ByteOffset+sparse.Values.ByteOffset:]
+		sparseValues := readElements(valueData, layout.elementSize, sparse.Count, layout, accessor.ComponentType, accessor.Normalized)
+
+		previous := -1
+		for i, indexValue := range indices {
+			element := int(indexValue)
+			if element <= previous || element >= accessor.Count {
+				return nil, nil, gltfErrorf("accessors[%d]: sparse indices must be increasing and below %d", index, accessor.Count)
+			}
+			previous = element
+
+			copy(values[element*layout.components:(element+1)*layout.components], sparseValues[i*layout.components:(i+1)*layout.components])
+		}
+	}
+
+	return values, accessor, nil
+}
+
+// readFloats decodes an accessor after checking that it has one of the allowed types and component types
+func (l *gltfLoader) readFloats(index int, types []string, floatOnly bool) ([]float32, int, error) {
+	if err := checkIndex("accessor", index, len(l.doc.Accessors)); err != nil {
+		return nil, 0, err
+	}
+
+	values, accessor, err := l.accessorValues(index)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	typeAllowed := false
+	for _, t := range types {
+		typeAllowed = typeAllowed || accessor.Type == t
+	}
+	if !typeAllowed {
+		return nil, 0, gltfErrorf("accessors[%d]: type %s is not one of %v", index, accessor.Type, types)
+	}
+
+	if accessor.ComponentType != gltfFloat && (floatOnly || !accessor.Normalized) {
+		return nil, 0, gltfErrorf("accessors[%d]: components must be floats or normalized integers", index)
+	}
+
+	floats := make([]float32, len(values))
+	for i, value := range values {
+		floats[i] = float32(value)
+	}
+
+	return floats, gltfTypeComponents[accessor.Type], nil
+}
+
+// readIndices decodes a scalar unsigned integer accessor
+func (l *gltfLoader) readIndices(index int) ([]uint32, error) {
+	if err := checkIndex("accessor", index, len(l.doc.Accessors)); err != nil {
+		return nil, err
+	}
+
+	values, accessor, err := l.accessorValues(index)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case accessor.Type != "SCALAR":
+		return nil, gltfErrorf("accessors[%d]: indices must be scalars", index)
+	case accessor.Normalized:
+		return nil, gltfErrorf("accessors[%d]: indices can not be normalized", index)
+	case accessor.ComponentType != gltfUnsignedByte && accessor.ComponentType != gltfUnsignedShort && accessor.ComponentType != gltfUnsignedInt:
+		return nil, gltfErrorf("accessors[%d]: indices must be unsigned integers", index)
+	}
+
+	indices := make([]uint32, len(values))
+	for i, value := range values {
+		indices[i] = uint32(value)
+	}
+
+	return indices, nil
+}
+
+func (l *gltfLoader) loadImages() error {
+	for i, image := range l.doc.Images {
+		sceneImage := SceneImage{Name: image.Name, MimeType: image.MimeType}
+
+		switch {
+		case image.URI != "" && image.BufferView != nil:
+			return gltfErrorf("images[%d]: uri and bufferView can not be used together", i)
+
+		case strings.HasPrefix(image.URI, "data:"):
+			data, mimeType, err := l.readURI(image.URI)
+			if err != nil {
+				return gltfErrorf("images[%d]: %v", i, err)
+			}
+			sceneImage.Data, sceneImage.MimeType = data, mimeType
+
+		case image.URI != "":
+			// external images are left to the texture loader
+			uri, err := url.PathUnescape(image.URI)
+			if err != nil {
+				return gltfErrorf("images[%d]: %v", i, err)
+			}
+			sceneImage.URI = uri
+
+		case image.BufferView != nil:
+			if err := checkIndex("bufferView", *image.BufferView, len(l.doc.BufferViews)); err != nil {
+				return gltfErrorf("images[%d]: %v", i, err)
+			}
+			if image.MimeType == "" {
+				return gltfErrorf("images[%d]: mimeType is required with a bufferView", i)
+			}
+
+			view := &l.doc.BufferViews[*image.BufferView]
+			sceneImage.Data = l.buffers[view.Buffer][view.ByteOffset : view.ByteOffset+view.ByteLength]
+
+		default:
+			return gltfErrorf("images[%d]: needs either uri or bufferView", i)
+		}
+
+		l.scene.Images = append(l.scene.Images, sceneImage)
+	}
+
+	return nil
+}
+
+// texturePath names the image used by a texture, see Scene.Images
+func (l *gltfLoader) texturePath(info *gltfTextureInfo) (string, error) {
+	if info == nil {
+		return "", nil
+	}
+
+	if err := checkIndex("texture", info.Index, len(l.doc.Textures)); err != nil {
+		return "", err
+	}
+
+	source := l.doc.Textures[info.Index].Source
+	if source == nil {
+		return "", nil
+	}
+	if err := checkIndex("image", *source, len(l.scene.Images)); err != nil {
+		return "", err
+	}
+
+	if uri := l.scene.Images[*source].URI; uri != "" {
+		return uri, nil
+	}
+
+	return fmt.Sprintf("image:%d", *source), nil
+}
+
+func (l *gltfLoader) loadMaterials() error {
+	for i, m := range l.doc.Materials {
+		material := NewMaterial(m.Name)
+		material.Metallic = 1
+		material.DoubleSided = m.DoubleSided
+
+		if m.AlphaMode != "" {
+			if m.AlphaMode != "OPAQUE" && m.AlphaMode != "MASK" && m.AlphaMode != "BLEND" {
+				return gltfErrorf("materials[%d]: invalid alphaMode '%s'", i, m.AlphaMode)
+			}
+			material.AlphaMode = m.AlphaMode
+		}
+		if m.AlphaCutoff != nil {
+			if *m.AlphaCutoff < 0 {
+				return gltfErrorf("materials[%d]: alphaCutoff can not be negative", i)
+			}
+			material.AlphaCutoff = *m.AlphaCutoff
+		}
+
+		if len(m.EmissiveFactor) != 0 {
+			if len(m.EmissiveFactor) != 3 {
+				return gltfErrorf("materials[%d]: emissiveFactor needs 3 values", i)
+			}
+			material.Emissive = Vector{X: m.EmissiveFactor[0], Y: m.EmissiveFactor[1], Z: m.EmissiveFactor[2]}
+		}
+
+		type textureSlot struct {
+			info *gltfTextureInfo
+			path *string
+		}
+		textures := []textureSlot{
+			{m.NormalTexture, &material.NormalTexture},
+			{m.OcclusionTexture, &material.OcclusionTexture},
+			{m.EmissiveTexture, &material.EmissiveTexture},
+		}
+
+		if pbr := m.PbrMetallicRoughness; pbr != nil {
+			if len(pbr.BaseColorFactor) != 0 {
+				if len(pbr.BaseColorFactor) != 4 {
+					return gltfErrorf("materials[%d]: baseColorFactor needs 4 values", i)
+				}
+				material.Diffuse = Vector{X: pbr.BaseColorFactor[0], Y: pbr.BaseColorFactor[1], Z: pbr.BaseColorFactor[2]}
+				material.Opacity = pbr.BaseColorFactor[3]
+			}
+			if pbr.MetallicFactor != nil {
+				material.Metallic = *pbr.MetallicFactor
+			}
+			if pbr.RoughnessFactor != nil {
+				material.Roughness = *pbr.RoughnessFactor
+			}
+
+			textures = append(textures,
+				textureSlot{pbr.BaseColorTexture, &material.DiffuseTexture},
+				textureSlot{pbr.MetallicRoughnessTexture, &material.MetallicRoughnessTexture},
+			)
+		}
+
+		for _, texture := range textures {
+			path, err := l.texturePath(texture.info)
+			if err != nil {
+				return gltfErrorf("materials[%d]: %v", i, err)
+			}
+			*texture.path = path
+		}
+
+		l.scene.Materials = append(l.scene.Materials, material)
+	}
+
+	return nil
+}
+
+func (l *gltfLoader) loadMeshes() error {
+	for i, m := range l.doc.Meshes {
+		if len(m.Primitives) == 0 {
+			return gltfErrorf("meshes[%d]: needs at least one primitive", i)
+		}
+
+		mesh := &Mesh{}
+		for j := range m.Primitives {
+			if err := l.loadPrimitive(mesh, m.Name, &m.Primitives[j]); err != nil {
+				return gltfErrorf("meshes[%d].primitives[%d]: %v", i, j, err)
+			}
+		}
+
+		l.scene.Meshes = append(l.scene.Meshes, mesh)
+	}
+
+	return nil
+}
+
+func (l *gltfLoader) loadPrimitive(mesh *Mesh, name string, primitive *gltfPrimitive) error {
+	mode := 4
+	if primitive.Mode != nil {
+		mode = *primitive.Mode
+	}
+	if mode < 0 || mode > 6 {
+		return fmt.Errorf("invalid mode %d", mode)
+	}
+	if mode < 4 {
+		// points and lines are not drawn
+		return nil
+	}
+
+	var material *Material
+	if primitive.Material != nil {
+		if err := checkIndex("material", *primitive.Material, len(l.scene.Materials)); err != nil {
+			return err
+		}
+		material = l.scene.Materials[*primitive.Material]
+	}
+
+	positionIndex, ok := primitive.Attributes["POSITION"]
+	if !ok {
+		return errors.New("missing POSITION attribute")
+	}
+	positions, _, err := l.readFloats(positionIndex, []string{"VEC3"}, true)
+	if err != nil {
+		return err
+	}
+	count := len(positions) / 3
+
+	// every attribute has to provide a value for each vertex
+	readAttribute := func(name string, types []string, floatOnly bool) ([]float32, int, error) {
+		index, ok := primitive.Attributes[name]
+		if !ok {
+			return nil, 0, nil
+		}
+
+		values, components, err := l.readFloats(index, types, floatOnly)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(values) != count*components {
+			return nil, 0, fmt.Errorf("%s has a different count than POSITION", name)
+		}
+
+		return values, components, nil
+	}
+
+	normals, _, err := readAttribute("NORMAL", []string{"VEC3"}, true)
+	if err != nil {
+		return err
+	}
+	texcoords, _, err := readAttribute("TEXCOORD_0", []string{"VEC2"}, false)
+	if err != nil {
+		return err
+	}
+	colors, colorComponents, err := readAttribute("COLOR_0", []string{"VEC3", "VEC4"}, false)
+	if err != nil {
+		return err
+	}
+
+	base := uint32(len(mesh.Vertices))
+	for i := 0; i < count; i++ {
+		vertex := Vertex{
+			X: positions[i*3],
+			Y: positions[i*3+1],
+			Z: -positions[i*3+2],
+			R: 1, G: 1, B: 1,
+		}
+
+		if colors != nil {
+			vertex.R, vertex.G, vertex.B = colors[i*colorComponents], colors[i*colorComponents+1], colors[i*colorComponents+2]
+		} else if material != nil {
+			vertex.R, vertex.G, vertex.B = material.Diffuse.X, material.Diffuse.Y, material.Diffuse.Z
+		}
+
+		if normals != nil {
+			vertex.NX, vertex.NY, vertex.NZ = normals[i*3], normals[i*3+1], -normals[i*3+2]
+		}
+
+		if texcoords != nil {
+			vertex.U, vertex.V = texcoords[i*2], 1-texcoords[i*2+1]
+		}
+
+		mesh.Vertices = append(mesh.Vertices, vertex)
+	}
+
+	var indices []uint32
+	if primitive.Indices != nil {
+		if indices, err = l.readIndices(*primitive.Indices); err != nil {
+			return err
+		}
+		for _, index := range indices {
+			if int(index) >= count {
+				return fmt.Errorf("index %d out of range, %d vertices", index, count)
+			}
+		}
+	} else {
+		indices = make([]uint32, count)
+		for i := range indices {
+			indices[i] = uint32(i)
+		}
+	}
+
+	// the last two corners of every triangle are swapped to turn the counter clockwise front faces clockwise
+	start := len(mesh.Indices)
+	switch mode {
+	case 4:
+		for i := 0; i+2 < len(indices); i += 3 {
+			mesh.Indices = append(mesh.Indices, base+indices[i], base+indices[i+2], base+indices[i+1])
+		}
+	case 5:
+		// every other triangle of a strip is flipped to keep the winding
+		for i := 0; i+2 < len(indices); i++ {
+			mesh.Indices = append(mesh.Indices, base+indices[i], base+indices[i+2-i%2], base+indices[i+1+i%2])
+		}
+	case 6:
+		for i := 1; i+1 < len(indices); i++ {
+			mesh.Indices = append(mesh.Indices, base+indices[i], base+indices[0], base+indices[i+1])
+		}
+	}
+
+	if count := len(mesh.Indices) - start; count > 0 {
+		mesh.Groups = append(mesh.Groups, MeshGroup{Name: name, Material: material, Start: start, Count: count})
+	}
+
+	return nil
+}
+
+func (l *gltfLoader) loadCameras() error {
+	for i, c := range l.doc.Cameras {
+		camera := &SceneCamera{Name: c.Name}
+
+		switch {
+		case c.Type == "perspective" && c.Perspective != nil:
+			p := c.Perspective
+			if p.Yfov <= 0 || p.Znear <= 0 || p.AspectRatio < 0 {
+				return gltfErrorf("cameras[%d]: yfov, znear and aspectRatio must be positive", i)
+			}
+			if p.Zfar != nil && *p.Zfar <= p.Znear {
+				return gltfErrorf("cameras[%d]: zfar must be greater than znear", i)
+			}
+
+			camera.Projection = PerspectiveProjection
+			camera.FOV, camera.Aspect, camera.Near = p.Yfov, p.AspectRatio, p.Znear
+			if p.Zfar != nil {
+				camera.Far = *p.Zfar
+			}
+
+		case c.Type == "orthographic" && c.Orthographic != nil:
+			o := c.Orthographic
+			if o.Xmag == 0 || o.Ymag == 0 || o.Znear < 0 || o.Zfar <= o.Znear {
+				return gltfErrorf("cameras[%d]: invalid orthographic volume", i)
+			}
+
+			camera.Projection = OrthographicProjection
+			camera.OrthoHeight = 2 * float32(math.Abs(float64(o.Ymag)))
+			camera.Aspect = float32(math.Abs(float64(o.Xmag / o.Ymag)))
+			camera.Near, camera.Far = o.Znear, o.Zfar
+
+		default:
+			return gltfErrorf("cameras[%d]: type must be perspective or orthographic with matching properties", i)
+		}
+
+		l.scene.Cameras = append(l.scene.Cameras, camera)
+	}
+
+	return nil
+}
+
+func (l *gltfLoader) loadNodes() error {
+	for i, n := range l.doc.Nodes {
+		node := &SceneNode{Name: n.Name, Transform: NewTransform()}
+
+		if err := setNodeTransform(node.Transform, &n); err != nil {
+			return gltfErrorf("nodes[%d]: %v", i, err)
+		}
+
+		if n.Mesh != nil {
+			if err := checkIndex("mesh", *n.Mesh, len(l.scene.Meshes)); err != nil {
+				return gltfErrorf("nodes[%d]: %v", i, err)
+			}
+			if mesh := l.scene.Meshes[*n.Mesh]; len(mesh.Indices) > 0 {
+				node.Mesh = mesh
+			}
+		}
+
+		if n.Camera != nil {
+			if err := checkIndex("camera", *n.Camera, len(l.scene.Cameras)); err != nil {
+				return gltfErrorf("nodes[%d]: %v", i, err)
+			}
+			node.Camera = l.scene.Cameras[*n.Camera]
+			if node.Camera.Node == nil {
+				node.Camera.Node = node
+			}
+		}
+
+		l.scene.Nodes = append(l.scene.Nodes, node)
+	}
+
+	// link the hierarchy, which has to be a set of disjoint trees
+	for i, n := range l.doc.Nodes {
+		node := l.scene.Nodes[i]
+		for _, child := range n.Children {
+			if err := checkIndex("child node", child, len(l.scene.Nodes)); err != nil {
+				return gltfErrorf("nodes[%d]: %v", i, err)
+			}
+
+			childNode := l.scene.Nodes[child]
+			if childNode.Parent != nil {
+				return gltfErrorf("nodes[%d]: node %d already has a parent", i, child)
+			}
+			if err := childNode.Transform.SetParent(node.Transform); err != nil {
+				return gltfErrorf("nodes[%d]: %v", i, err)
+			}
+
+			childNode.Parent = node
+			node.Children = append(node.Children, childNode)
+		}
+	}
+
+	return nil
+}
+
+// setNodeTransform converts the node matrix or translation, rotation and scale to the left-handed space
+func setNodeTransform(transform *Transform, n *gltfNode) error {
+	hasTRS := n.Translation != nil || n.Rotation != nil || n.Scale != nil
+	if n.Matrix != nil {
+		if hasTRS {
+			return errors.New("matrix can not be combined with translation, rotation or scale")
+		}
+		if len(n.Matrix) != 16 {
+			return errors.New("matrix needs 16 values")
+		}
+
+		// the column major matrix for column vectors has the same layout as Matrix,
+		// negating the third row and column mirrors it along z
+		var m Matrix
+		for i, value := range n.Matrix {
+			if (i/4 == 2) != (i%4 == 2) {
+				value = -value
+			}
+			m[i] = value
+		}
+
+		translation, rotation, scale, err := m.Decompose()
+		if err != nil {
+			return errors.New("matrix can not be decomposed into translation, rotation and scale")
+		}
+
+		transform.SetPosition(translation)
+		transform.SetRotation(rotation)
+		transform.SetScale(scale)
+		return nil
+	}
+
+	if n.Translation != nil {
+		if len(n.Translation) != 3 {
+			return errors.New("translation needs 3 values")
+		}
+		transform.SetPosition(Vector{X: n.Translation[0], Y: n.Translation[1], Z: -n.Translation[2]})
+	}
+
+	if n.Rotation != nil {
+		if len(n.Rotation) != 4 {
+			return errors.New("rotation needs 4 values")
+		}
+		transform.SetRotation(Quaternion{X: -n.Rotation[0], Y: -n.Rotation[1], Z: n.Rotation[2], W: n.Rotation[3]}.Normalize())
+	}
+
+	if n.Scale != nil {
+		if len(n.Scale) != 3 {
+			return errors.New("scale needs 3 values")
+		}
+		transform.SetScale(Vector{X: n.Scale[0], Y: n.Scale[1], Z: n.Scale[2]})
+	}
+
+	return nil
+}
+
+func (l *gltfLoader) loadScene() error {
+	for i, scene := range l.doc.Scenes {
+		for _, node := range scene.Nodes {
+			if err := checkIndex("node", node, len(l.scene.Nodes)); err != nil {
+				return gltfErrorf("scenes[%d]: %v", i, err)
+			}
+			if l.scene.Nodes[node].Parent != nil {
+				return gltfErrorf("scenes[%d]: node %d is not a root node", i, node)
+			}
+		}
+	}
+
+	if l.doc.Scene != nil {
+		if err := checkIndex("scene", *l.doc.Scene, len(l.doc.Scenes)); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case l.doc.Scene != nil:
+		l.scene.Roots = l.rootNodes(l.doc.Scenes[*l.doc.Scene].Nodes)
+	case len(l.doc.Scenes) > 0:
+		l.scene.Roots = l.rootNodes(l.doc.Scenes[0].Nodes)
+	default:
+		// without scenes every node without a parent is shown
+		for _, node := range l.scene.Nodes {
+			if node.Parent == nil {
+				l.scene.Roots = append(l.scene.Roots, node)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (l *gltfLoader) rootNodes(indices []int) []*SceneNode {
+	roots := make([]*SceneNode, len(indices))
+	for i, index := range indices {
+		roots[i] = l.scene.Nodes[index]
+	}
+
+	return roots
+}
+
+func (l *gltfLoader) loadAnimations() error {
+	for i, a := range l.doc.Animations {
+		animation := &Animation{Name: a.Name}
+
+		for j := range a.Channels {
+			if err := l.loadChannel(animation, &a, j); err != nil {
+				return gltfErrorf("animations[%d].channels[%d]: %v", i, j, err)
+			}
+		}
+
+		l.scene.Animations = append(l.scene.Animations, animation)
+	}
+
+	return nil
+}
+
+func (l *gltfLoader) loadChannel(animation *Animation, a *gltfAnimation, index int) error {
+	channel := a.Channels[index]
+	if err := checkIndex("sampler", channel.Sampler, len(a.Samplers)); err != nil {
+		return err
+	}
+	sampler := a.Samplers[channel.Sampler]
+
+	if channel.Target.Node == nil {
+		// targets defined by extensions are not supported
+		return nil
+	}
+	if err := checkIndex("node", *channel.Target.Node, len(l.scene.Nodes)); err != nil {
+		return err
+	}
+
+	interpolation := AnimationInterpolation(sampler.Interpolation)
+	switch interpolation {
+	case "":
+		interpolation = AnimationLinear
+	case AnimationLinear, AnimationStep, AnimationCubicSpline:
+	default:
+		return fmt.Errorf("invalid interpolation '%s'", sampler.Interpolation)
+	}
+
+	path := AnimationPath(channel.Target.Path)
+	var types []string
+	switch path {
+	case AnimationTranslation, AnimationScale:
+		types = []string{"VEC3"}
+	case AnimationRotation:
+		types = []string{"VEC4"}
+	case AnimationWeights:
+		types = []string{"SCALAR"}
+	default:
+		return fmt.Errorf("invalid path '%s'", channel.Target.Path)
+	}
+
+	times, _, err := l.readFloats(sampler.Input, []string{"SCALAR"}, true)
+	if err != nil {
+		return err
+	}
+	for k := 1; k < len(times); k++ {
+		if times[k] <= times[k-1] {
+			return errors.New("input times must be strictly increasing")
+		}
+	}
+
+	values, components, err := l.readFloats(sampler.Output, types, path == AnimationTranslation || path == AnimationScale)
+	if err != nil {
+		return err
+	}
+
+	perKeyframe := components
+	if interpolation == AnimationCubicSpline {
+		perKeyframe *= 3
+	}
+	if path == AnimationWeights {
+		if len(values)%(len(times)*perKeyframe) != 0 {
+			return errors.New("output count does not match the input count")
+		}
+	} else if len(values) != len(times)*perKeyframe {
+		return errors.New("output count does not match the input count")
+	}
+
+	// mirror along z like the node transforms, which works for the tangents as well
+	switch path {
+	case AnimationTranslation:
+		for k := 2; k < len(values); k += 3 {
+			values[k] = -values[k]
+		}
+	case AnimationRotation:
+		for k := 0; k < len(values); k += 4 {
+			values[k], values[k+1] = -values[k], -values[k+1]
+		}
+	}
+
+	animation.Channels = append(animation.Channels, AnimationChannel{
+		Node:          l.scene.Nodes[*channel.Target.Node],
+		Path:          path,
+		Interpolation: interpolation,
+		Times:         times,
+		Values:        values,
+	})
+
+	return nil
+}
@@ -0,0 +1,273 @@
+package opengl_exercise
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadGLTF(t *testing.T) {
+	for _, path := range []string{"testdata/triangle.gltf", "testdata/triangle.glb"} {
+		t.Run(path, func(t *testing.T) {
+			scene, err := LoadGLTF(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(scene.Nodes) != 3 || len(scene.Roots) != 1 || scene.Roots[0].Name != "root" {
+				t.Fatalf("unexpected nodes %+v, roots %+v", scene.Nodes, scene.Roots)
+			}
+
+			root := scene.Roots[0]
+			triangle := scene.FindNode("triangle")
+			if len(root.Children) != 2 || triangle == nil || triangle.Parent != root || triangle.Transform.Parent() != root.Transform {
+				t.Fatalf("hierarchy was not linked: %+v", root.Children)
+			}
+
+			// z is mirrored into the left-handed space
+			if got := triangle.Transform.WorldPosition(); got != (Vector{1, 2, -3}) {
+				t.Errorf("expected triangle at (1, 2, -3), got %+v", got)
+			}
+
+			mesh := triangle.Mesh
+			if mesh == nil || len(mesh.Vertices) != 3 || len(mesh.Indices) != 3 || len(mesh.Groups) != 1 {
+				t.Fatalf("unexpected mesh %+v", mesh)
+			}
+
+			expected := []Vertex{
+				{X: 0, Y: 0, Z: 0, R: 1, NZ: -1, U: 0, V: 0},
+				{X: 1, Y: 0, Z: 0, R: 1, NZ: -1, U: 1, V: 0},
+				{X: 0, Y: 1, Z: 0, R: 1, NZ: -1, U: 0, V: 1},
+			}
+			for i, vertex := range mesh.Vertices {
+				if vertex != expected[i] {
+					t.Errorf("vertex %d: expected %+v, got %+v", i, expected[i], vertex)
+				}
+			}
+
+			// the triangle is clockwise seen from the side its normal points to
+			v0, v1, v2 := mesh.Vertices[mesh.Indices[0]], mesh.Vertices[mesh.Indices[1]], mesh.Vertices[mesh.Indices[2]]
+			edge1 := Vector{v1.X - v0.X, v1.Y - v0.Y, v1.Z - v0.Z}
+			edge2 := Vector{v2.X - v0.X, v2.Y - v0.Y, v2.Z - v0.Z}
+			if face := edge1.Cross(edge2); face.Dot(Vector{v0.NX, v0.NY, v0.NZ}) <= 0 {
+				t.Errorf("triangle is not clockwise around its normal: %+v", face)
+			}
+
+			material := mesh.Groups[0].Material
+			if material == nil || material.Name != "red" || material.Diffuse != (Vector{1, 0, 0}) || material.Opacity != 0.5 ||
+				material.Metallic != 0.25 || material.Roughness != 0.75 || material.AlphaMode != "BLEND" || !material.DoubleSided {
+				t.Errorf("unexpected material %+v", material)
+			}
+
+			if material.DiffuseTexture != "image:0" || len(scene.Images) != 1 || string(scene.Images[0].Data) != "\x89PNG" || scene.Images[0].MimeType != "image/png" {
+				t.Errorf("unexpected texture %q with images %+v", material.DiffuseTexture, scene.Images)
+			}
+
+			if len(scene.Cameras) != 1 {
+				t.Fatalf("expected one camera, got %d", len(scene.Cameras))
+			}
+			lens := scene.Cameras[0]
+			if lens.Projection != PerspectiveProjection || lens.FOV != 0.8 || lens.Near != 0.1 || lens.Far != 100 || lens.Aspect != 1.5 || lens.Node.Name != "camera" {
+				t.Errorf("unexpected camera %+v", lens)
+			}
+
+			camera := NewCamera()
+			lens.Apply(camera)
+			if camera.Position != (Vector{1, 2, -8}) || camera.FOV != 0.8 {
+				t.Errorf("camera was placed at %+v with fov %f", camera.Position, camera.FOV)
+			}
+
+			if len(scene.Animations) != 1 || len(scene.Animations[0].Channels) != 1 {
+				t.Fatalf("unexpected animations %+v", scene.Animations)
+			}
+			animation := scene.Animations[0]
+			if animation.Duration() != time.Second {
+				t.Errorf("expected a duration of 1s, got %v", animation.Duration())
+			}
+
+			animation.Apply(500 * time.Millisecond)
+			if got := triangle.Transform.Position(); got != (Vector{0, 0, -1}) {
+				t.Errorf("expected animated position (0, 0, -1), got %+v", got)
+			}
+		})
+	}
+}
+
+func TestLoadGLTFSparse(t *testing.T) {
+	scene, err := LoadGLTF("testdata/sparse.gltf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mesh := scene.Nodes[0].Mesh
+	if mesh == nil || len(mesh.Vertices) != 3 {
+		t.Fatalf("unexpected mesh %+v", mesh)
+	}
+
+	// without indices the vertices are drawn in order, turned clockwise
+	if len(mesh.Indices) != 3 || mesh.Indices[0] != 0 || mesh.Indices[1] != 2 || mesh.Indices[2] != 1 {
+		t.Errorf("unexpected indices %v", mesh.Indices)
+	}
+
+	positions := []Vector{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}
+	normals := []Vector{{0, 0, 0}, {9, 9, -9}, {0, 0, 0}}
+	for i, vertex := range mesh.Vertices {
+		if position := (Vector{vertex.X, vertex.Y, vertex.Z}); position != positions[i] {
+			t.Errorf("vertex %d: expected position %+v, got %+v", i, positions[i], position)
+		}
+		if normal := (Vector{vertex.NX, vertex.NY, vertex.NZ}); normal != normals[i] {
+			t.Errorf("vertex %d: expected normal %+v, got %+v", i, normals[i], normal)
+		}
+	}
+}
+
+func TestAnimationInterpolation(t *testing.T) {
+	node := &SceneNode{Transform: NewTransform()}
+	quarter := QuaternionFromAxisAngle(Vector{Y: 1}, math.Pi/2)
+
+	tests := []struct {
+		name     string
+		channel  AnimationChannel
+		at       time.Duration
+		position Vector
+		rotation Quaternion
+	}{
+		{"step", AnimationChannel{Path: AnimationTranslation, Interpolation: AnimationStep, Times: []float32{0, 1}, Values: []float32{1, 1, 1, 3, 3, 3}},
+			900 * time.Millisecond, Vector{1, 1, 1}, IdentityQuaternion()},
+		{"linear", AnimationChannel{Path: AnimationTranslation, Interpolation: AnimationLinear, Times: []float32{0, 2}, Values: []float32{0, 0, 0, 4, 2, 0}},
+			time.Second, Vector{2, 1, 0}, IdentityQuaternion()},
+		{"after the end", AnimationChannel{Path: AnimationTranslation, Interpolation: AnimationLinear, Times: []float32{0, 1}, Values: []float32{0, 0, 0, 4, 2, 0}},
+			5 * time.Second, Vector{4, 2, 0}, IdentityQuaternion()},
+		{"cubic spline", AnimationChannel{Path: AnimationTranslation, Interpolation: AnimationCubicSpline, Times: []float32{0, 1},
+			// in-tangent, value, out-tangent per keyframe, flat tangents give a smooth step
+			Values: []float32{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0}},
+			500 * time.Millisecond, Vector{1, 0, 0}, IdentityQuaternion()},
+		{"slerp", AnimationChannel{Path: AnimationRotation, Interpolation: AnimationLinear, Times: []float32{0, 1}, Values: []float32{0, 0, 0, 1, quarter.X, quarter.Y, quarter.Z, quarter.W}},
+			500 * time.Millisecond, Vector{}, QuaternionFromAxisAngle(Vector{Y: 1}, math.Pi/4)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node.Transform.SetPosition(Vector{})
+			node.Transform.SetRotation(IdentityQuaternion())
+
+			test.channel.Node = node
+			animation := Animation{Channels: []AnimationChannel{test.channel}}
+			animation.Apply(test.at)
+
+			if got := node.Transform.Position(); !vectorNearlyEqual(got, test.position) {
+				t.Errorf("expected position %+v, got %+v", test.position, got)
+			}
+			if got := node.Transform.Rotation(); math.Abs(float64(got.Dot(test.rotation))) < 1-matrixEpsilon {
+				t.Errorf("expected rotation %+v, got %+v", test.rotation, got)
+			}
+		})
+	}
+}
+
+// gltfDataURI embeds binary data the way glTF files do
+func gltfDataURI(data []byte) string {
+	return "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+func TestReadGLTFInvalid(t *testing.T) {
+	// three float positions followed by three unsigned short indices
+	var buffer bytes.Buffer
+	binary.Write(&buffer, binary.LittleEndian, []float32{0, 0, 0, 1, 0, 0, 0, 1, 0})
+	binary.Write(&buffer, binary.LittleEndian, []uint16{0, 1, 7})
+	buffers := fmt.Sprintf(`"buffers": [{"byteLength": %d, "uri": %q}],
+		"bufferViews": [{"buffer": 0, "byteLength": 36}, {"buffer": 0, "byteOffset": 36, "byteLength": 6}]`, buffer.Len(), gltfDataURI(buffer.Bytes()))
+
+	tests := []struct {
+		name  string
+		json  string
+		error string
+	}{
+		{"not json", `{"asset": `, "invalid JSON"},
+		{"missing version", `{"asset": {}}`, "asset.version is required"},
+		{"version 1", `{"asset": {"version": "1.0"}}`, "unsupported version"},
+		{"required extension", `{"asset": {"version": "2.0"}, "extensionsRequired": ["KHR_draco_mesh_compression"]}`, "not supported"},
+		{"buffer too short", `{"asset": {"version": "2.0"}, "buffers": [{"byteLength": 8, "uri": "data:application/octet-stream;base64,AAAA"}]}`, "only 3 bytes"},
+		{"buffer view out of range", `{"asset": {"version": "2.0"}, ` + buffers + `, "accessors": [{"bufferView": 5, "componentType": 5126, "count": 3, "type": "VEC3"}]}`, "bufferView index 5 out of range"},
+		{"accessor too long", `{"asset": {"version": "2.0"}, ` + buffers + `, "accessors": [{"bufferView": 0, "componentType": 5126, "count": 4, "type": "VEC3"}]}`, "do not fit"},
+		// counts and offsets large enough to overflow the bounds when multiplied or added
+		{"huge count", `{"asset": {"version": "2.0"}, ` + buffers + `, "accessors": [{"bufferView": 0, "componentType": 5126, "count": 768614336404564651, "type": "VEC3"}]}`, "do not fit"},
+		{"huge view offset", `{"asset": {"version": "2.0"}, "buffers": [{"byteLength": 3, "uri": "data:application/octet-stream;base64,AAAA"}],
+			"bufferViews": [{"buffer": 0, "byteOffset": 9223372036854775807, "byteLength": 2}]}`, "does not fit into buffer"},
+		{"huge sparse offset", `{"asset": {"version": "2.0"}, ` + buffers + `, "accessors": [{"componentType": 5126, "count": 3, "type": "VEC3",
+			"sparse": {"count": 1, "indices": {"bufferView": 1, "componentType": 5123}, "values": {"bufferView": 0, "byteOffset": 9223372036854775807}}}]}`, "sparse values do not fit"},
+		{"huge count without view", `{"asset": {"version": "2.0"}, "accessors": [{"componentType": 5126, "count": 1000000000000, "type": "VEC3"}]}`, "too large"},
+		{"misaligned accessor", `{"asset": {"version": "2.0"}, ` + buffers + `, "accessors": [{"bufferView": 0, "byteOffset": 2, "componentType": 5126, "count": 1, "type": "VEC3"}]}`, "not aligned"},
+		{"normalized float", `{"asset": {"version": "2.0"}, ` + buffers + `, "accessors": [{"bufferView": 0, "componentType": 5126, "normalized": true, "count": 3, "type": "VEC3"}]}`, "normalized"},
+		{"invalid type", `{"asset": {"version": "2.0"}, ` + buffers + `, "accessors": [{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC5"}]}`, "invalid type"},
+		{"index out of range", `{"asset": {"version": "2.0"}, ` + buffers + `,
+			"accessors": [{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"}, {"bufferView": 1, "componentType": 5123, "count": 3, "type": "SCALAR"}],
+			"meshes": [{"primitives": [{"attributes": {"POSITION": 0}, "indices": 1}]}]}`, "index 7 out of range"},
+		{"missing position", `{"asset": {"version": "2.0"}, "meshes": [{"primitives": [{"attributes": {}}]}]}`, "missing POSITION"},
+		{"two parents", `{"asset": {"version": "2.0"}, "nodes": [{"children": [2]}, {"children": [2]}, {}]}`, "already has a parent"},
+		{"cycle", `{"asset": {"version": "2.0"}, "nodes": [{"children": [1]}, {"children": [0]}]}`, "nodes[1]"},
+		{"matrix and translation", `{"asset": {"version": "2.0"}, "nodes": [{"matrix": [1,0,0,0, 0,1,0,0, 0,0,1,0, 0,0,0,1], "translation": [1, 2, 3]}]}`, "can not be combined"},
+		{"scene with child node", `{"asset": {"version": "2.0"}, "nodes": [{"children": [1]}, {}], "scenes": [{"nodes": [1]}]}`, "not a root node"},
+		{"camera far before near", `{"asset": {"version": "2.0"}, "cameras": [{"type": "perspective", "perspective": {"yfov": 1, "znear": 1, "zfar": 0.5}}]}`, "zfar must be greater"},
+		{"camera type", `{"asset": {"version": "2.0"}, "cameras": [{"type": "orthographic", "perspective": {"yfov": 1, "znear": 1}}]}`, "cameras[0]"},
+		{"alpha mode", `{"asset": {"version": "2.0"}, "materials": [{"alphaMode": "ADDITIVE"}]}`, "invalid alphaMode"},
+		{"animation path", `{"asset": {"version": "2.0"}, ` + buffers + `, "nodes": [{}],
+			"accessors": [{"bufferView": 0, "componentType": 5126, "count": 3, "type": "SCALAR"}],
+			"animations": [{"channels": [{"sampler": 0, "target": {"node": 0, "path": "color"}}], "samplers": [{"input": 0, "output": 0}]}]}`, "invalid path"},
+		{"animation output count", `{"asset": {"version": "2.0"}, ` + buffers + `, "nodes": [{}],
+			"accessors": [{"bufferView": 0, "byteOffset": 8, "componentType": 5126, "count": 2, "type": "SCALAR"}, {"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"}],
+			"animations": [{"channels": [{"sampler": 0, "target": {"node": 0, "path": "translation"}}], "samplers": [{"input": 0, "output": 1}]}]}`, "output count"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ReadGLTF(strings.NewReader(test.json), nil)
+			if err == nil || !strings.Contains(err.Error(), test.error) {
+				t.Errorf("expected error containing %q, got %v", test.error, err)
+			}
+		})
+	}
+}
+
+func TestReadGLBInvalid(t *testing.T) {
+	valid, err := ioutil.ReadFile("testdata/triangle.glb")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withVersion := append([]byte(nil), valid...)
+	binary.LittleEndian.PutUint32(withVersion[4:], 1)
+
+	withLength := append([]byte(nil), valid...)
+	binary.LittleEndian.PutUint32(withLength[8:], uint32(len(valid)+4))
+
+	withChunk := append([]byte(nil), valid...)
+	binary.LittleEndian.PutUint32(withChunk[16:], 0x004E4942)
+
+	tests := []struct {
+		name  string
+		data  []byte
+		error string
+	}{
+		{"header cut off", valid[:8], "too short"},
+		{"version", withVersion, "container version 1"},
+		{"length", withLength, "does not match"},
+		{"chunk cut off", valid[:len(valid)-4], "does not match"},
+		{"first chunk not json", withChunk, "first chunk must be JSON"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ReadGLTF(bytes.NewReader(test.data), nil)
+			if err == nil || !strings.Contains(err.Error(), test.error) {
+				t.Errorf("expected error containing %q, got %v", test.error, err)
+			}
+		})
+	}
+}
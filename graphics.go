@@ -3,6 +3,7 @@ package opengl_exercise
 import (
 	"fmt"
 	"os"
+	"sort"
 	"time"
 
 	"github.com/nullbus/opengl_exercise/gl"
@@ -18,15 +19,31 @@ var vbufferData = [...]float32{
 }
 
 type Graphics struct {
-	opengl *OpenGL
-	camera *Camera
-	model  *Model
-	shader *ColorShader
+	opengl     *OpenGL
+	input      *Input
+	camera     *Camera
+	controller CameraController
+	models     []*Model
+	shader     *ColorShader
+
+	// the window areas the scene is drawn to, each through its own camera
+	viewports []*Viewport
+
+	flyController    *FlyController
+	orbitController  *OrbitController
+	followController *FollowController
+
+	// number of models skipped by frustum culling in the last frame, summed over all viewports
+	culledModels int
+
+	// number of viewports that had a camera and an area to draw in the last frame
+	renderedViewports int
 }
 
-func NewGraphics(opengl *OpenGL, hwnd w32.HWND) (*Graphics, error) {
+func NewGraphics(opengl *OpenGL, input *Input, hwnd w32.HWND) (*Graphics, error) {
 	graphics := &Graphics{
 		opengl: opengl,
+		input:  input,
 	}
 
 	return graphics, graphics.initialize()
@@ -39,10 +56,29 @@ func (g *Graphics) initialize() (err error) {
 	// initial position of the camera
 	g.camera.Position = Vector{Z: -10}
 
+	// start with the same lens as the default projection
+	width, height := g.opengl.ScreenSize()
+	g.camera.FOV = g.opengl.FieldOfView()
+	g.camera.Near, g.camera.Far = g.opengl.ClipPlanes()
+	g.camera.Aspect = float32(width) / float32(height)
+
+	// the camera covers the whole window until more viewports are added
+	g.viewports = []*Viewport{NewViewport(0, 0, 1, 1, g.camera)}
+
+	// fly around the scene from the initial camera position, or orbit around the origin
+	g.flyController = NewFlyController()
+	g.orbitController = NewOrbitController(Vector{}, 10)
+	g.controller = g.flyController
+
 	// create model
-	if g.model, err = NewModel(); err != nil {
+	model, err := NewModel()
+	if err != nil {
 		return err
 	}
+	g.models = append(g.models, model)
+
+	// follow the model from behind
+	g.followController = NewFollowController(model.Transform)
 
 	// create color shader
 	if g.shader, err = NewColorShader(); err != nil {
@@ -59,9 +95,89 @@ func check() {
 }
 
 func (g *Graphics) Frame(delta time.Duration) error {
+	// switch between flying, orbiting and following
+	if g.input.IsKeyDown(w32.VK_F1) && g.controller != g.flyController {
+		g.flyController.Reset()
+		g.controller = g.flyController
+	} else if g.input.IsKeyDown(w32.VK_F2) && g.controller != g.orbitController {
+		g.orbitController.Sync(g.camera)
+		g.controller = g.orbitController
+	} else if g.input.IsKeyDown(w32.VK_F3) && g.controller != g.followController {
+		g.followController.Snap()
+		g.controller = g.followController
+	}
+
+	// move the camera according to the input
+	if g.controller != nil {
+		g.controller.Update(g.camera, g.input, delta)
+	}
+
 	return g.render()
 }
 
+// SetController replaces what moves the camera every frame, nil leaves the camera where it is
+func (g *Graphics) SetController(controller CameraController) {
+	g.controller = controller
+}
+
+// AddViewport adds a window area that shows the scene through the viewport camera
+func (g *Graphics) AddViewport(viewport *Viewport) {
+	g.viewports = append(g.viewports, viewport)
+}
+
+// RemoveViewport stops drawing the viewport, it returns false when the viewport was not added before
+func (g *Graphics) RemoveViewport(viewport *Viewport) bool {
+	for i, v := range g.viewports {
+		if v == viewport {
+			g.viewports = append(g.viewports[:i], g.viewports[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetViewports replaces all viewports, for example with the result of SplitScreenViewports
+func (g *Graphics) SetViewports(viewports ...*Viewport) {
+	g.viewports = append([]*Viewport(nil), viewports...)
+}
+
+// Viewports returns the viewports in the order they were added
+func (g *Graphics) Viewports() []*Viewport {
+	return g.viewports
+}
+
+// ViewportAt returns the topmost viewport under a window pixel measured from the top left corner, or nil
+func (g *Graphics) ViewportAt(x, y int) *Viewport {
+	width, height := g.opengl.ScreenSize()
+
+	var found *Viewport
+	for _, viewport := range g.viewports {
+		if !viewport.Contains(x, y, width, height) {
+			continue
+		}
+
+		// later viewports with the same order are drawn over earlier ones
+		if found == nil || viewport.Order >= found.Order {
+			found = viewport
+		}
+	}
+
+	return found
+}
+
+// PickRay returns the world space ray through a window pixel together with the viewport it was picked in.
+// The viewport is nil when no viewport covers the pixel.
+func (g *Graphics) PickRay(x, y int) (Ray, *Viewport, error) {
+	viewport := g.ViewportAt(x, y)
+	if viewport == nil || viewport.Camera == nil {
+		return Ray{}, nil, nil
+	}
+
+	ray, err := viewport.PickRay(g.opengl, x, y)
+	return ray, viewport, err
+}
+
 func (g *Graphics) Shutdown() {
 	// release the color shader object
 	if g.shader != nil {
@@ -69,52 +185,145 @@ func (g *Graphics) Shutdown() {
 		g.shader = nil
 	}
 
-	// release the model object
-	if g.model != nil {
-		g.model.Shutdown()
-		g.model = nil
+	// release the model objects
+	for _, model := range g.models {
+		model.Shutdown()
 	}
+	g.models = nil
 
 	// release the camera object
 	if g.camera != nil {
 		g.camera = nil
 	}
+	g.viewports = nil
 
-	// release the pointer to the opengl class object
+	// release the pointers to the opengl and input class objects
+	g.controller = nil
+	g.flyController = nil
+	g.orbitController = nil
+	g.followController = nil
 	g.opengl = nil
+	g.input = nil
+}
+
+// CulledModels returns how many models were outside the view frustum in the last frame
+func (g *Graphics) CulledModels() int {
+	return g.culledModels
 }
 
 func (g *Graphics) render() error {
 	// clear buffers to begin the scene
 	g.opengl.BeginScene(0, 0, 0, 1)
 
+	// set color shader as the current shader program
+	g.shader.SetShader()
+
+	// draw the viewports with a lower order first so the others end up on top
+	viewports := append([]*Viewport(nil), g.viewports...)
+	sort.SliceStable(viewports, func(i, j int) bool {
+		return viewports[i].Order < viewports[j].Order
+	})
+
+	g.culledModels, g.renderedViewports = 0, 0
+	for _, viewport := range viewports {
+		if viewport.Camera == nil {
+			continue
+		}
+
+		if err := g.renderViewport(viewport); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("culled: %d of %d models in %d viewports\n\n", g.culledModels, len(g.models)*g.renderedViewports, g.renderedViewports)
+
+	// go back to the whole window before presenting
+	g.opengl.ResetViewport()
+
+	// present rendered scene to the screen
+	g.opengl.EndScene()
+
+	return nil
+}
+
+// renderViewport draws the models into one viewport through its camera
+func (g *Graphics) renderViewport(viewport *Viewport) error {
+	camera := viewport.Camera
+
+	// limit drawing and clearing to the viewport rectangle
+	screenWidth, screenHeight := g.opengl.ScreenSize()
+	x, y, width, height := viewport.Pixels(screenWidth, screenHeight)
+	if width <= 0 || height <= 0 {
+		return nil
+	}
+	g.renderedViewports++
+	g.opengl.SetViewport(x, y, width, height)
+	g.opengl.Clear(viewport.ClearColor[0], viewport.ClearColor[1], viewport.ClearColor[2], viewport.ClearColor[3])
+
 	// generate view matrix baseed on the camera's position
-	g.camera.Render()
+	camera.Render()
 
-	// get matrices from opengl and camera objects
-	worldMatrix := g.opengl.WorldMatrix()
-	viewMatrix := g.camera.ViewMatrix()
-	projectionMatrix := g.opengl.ProjectionMatrix()
+	// the depth buffer has to match the depth range of the camera projection
+	if camera.ReversedZ != g.opengl.ReversedZ() {
+		if err := g.opengl.SetReversedZ(camera.ReversedZ); err != nil {
+			return err
+		}
 
-	// set color shader as the current shader program and set the matrices that will use for rendering
-	g.shader.SetShader()
-	if err := g.shader.SetShaderParams(worldMatrix, viewMatrix, projectionMatrix); err != nil {
-		return err
+		// the depth clear value changed with the depth range
+		g.opengl.Clear(viewport.ClearColor[0], viewport.ClearColor[1], viewport.ClearColor[2], viewport.ClearColor[3])
 	}
 
+	// get matrices from the camera object
+	viewMatrix := camera.ViewMatrix()
+
+	// the projection has to match the shape of the viewport or the image is stretched, the camera itself is left alone
+	// since several viewports may share it
+	projectionMatrix := camera.ProjectionMatrixForAspect(float32(width) / float32(height))
+	viewProjection := viewMatrix.Multiply(&projectionMatrix)
+
 	fmt.Println("-----------------------------------------------")
-	worldMatrix.Print(os.Stdout)
+	fmt.Printf("viewport: %d %d %d %d\n", x, y, width, height)
 	viewMatrix.Print(os.Stdout)
 	projectionMatrix.Print(os.Stdout)
-	fmt.Printf("%+v\n", Vector{-1, -1, 0}.MultiplyMatrix(&worldMatrix).MultiplyMatrix(&viewMatrix).MultiplyMatrix(&projectionMatrix))
-	fmt.Printf("%+v\n", Vector{0, 1, 0}.MultiplyMatrix(&worldMatrix).MultiplyMatrix(&viewMatrix).MultiplyMatrix(&projectionMatrix))
-	fmt.Printf("%+v\n\n", Vector{1, -1, 0}.MultiplyMatrix(&worldMatrix).MultiplyMatrix(&viewMatrix).MultiplyMatrix(&projectionMatrix))
 
-	// render the model using shader
-	g.model.Render()
+	// render the models that are at least partially visible using shader
+	for _, model := range g.models {
+		// each model is placed in the world by its own transform
+		worldMatrix := model.Transform.WorldMatrix()
+		worldViewProjection := worldMatrix.Multiply(&viewProjection)
 
-	// present rendered scene to the screen
-	g.opengl.EndScene()
+		// build the frustum in model space so the model bounds can be tested without transforming them
+		frustum := NewFrustum(&worldViewProjection)
+		bounds := model.Bounds()
+		if frustum.ContainsAABB(bounds.Min, bounds.Max) == Outside {
+			g.culledModels++
+			continue
+		}
+
+		// set the matrices that will use for rendering
+		if err := g.shader.SetShaderParams(worldMatrix, viewMatrix, projectionMatrix); err != nil {
+			return err
+		}
+
+		worldMatrix.Print(os.Stdout)
+		g.printProjectedVertices(model, &worldViewProjection)
+
+		model.Render(camera, height)
+	}
 
 	return nil
 }
+
+// printProjectedVertices shows where the first few model vertices end up in clip space, normalized device coordinates and window pixels
+func (g *Graphics) printProjectedVertices(model *Model, worldViewProjection *Matrix) {
+	for i, position := range model.positions {
+		if i == 3 {
+			break
+		}
+
+		clip := worldViewProjection.TransformHomogeneous(position.Vector4(1))
+		ndc := clip.PerspectiveDivide()
+		fmt.Printf("clip: %+v ndc: %+v window: %+v\n", clip, ndc, g.opengl.WindowCoordinates(ndc))
+	}
+	fmt.Println()
+}
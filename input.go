@@ -1,7 +1,26 @@
 package opengl_exercise
 
+// MouseButton identifies one of the mouse buttons tracked by Input
+type MouseButton int
+
+const (
+	MouseLeft MouseButton = iota
+	MouseRight
+	MouseMiddle
+)
+
 type Input struct {
 	keys [256]bool
+
+	mouseButtons [3]bool
+	mouseX       int
+	mouseY       int
+	mouseMoved   bool
+
+	// movement accumulated since the last call to EndFrame
+	mouseDeltaX int
+	mouseDeltaY int
+	wheelDelta  float32
 }
 
 func NewInput() (*Input, error) {
@@ -22,3 +41,56 @@ func (i *Input) KeyUp(key uint32) {
 func (i *Input) IsKeyDown(key uint32) bool {
 	return i.keys[key]
 }
+
+// MouseMove saves the cursor position in window pixels and accumulates how far it moved
+func (i *Input) MouseMove(x, y int) {
+	// the first position has nothing to be compared against
+	if i.mouseMoved {
+		i.mouseDeltaX += x - i.mouseX
+		i.mouseDeltaY += y - i.mouseY
+	}
+
+	i.mouseX, i.mouseY = x, y
+	i.mouseMoved = true
+}
+
+// MouseButtonDown saves state if a mouse button is pressed
+func (i *Input) MouseButtonDown(button MouseButton) {
+	i.mouseButtons[button] = true
+}
+
+// MouseButtonUp clears state if a mouse button is released
+func (i *Input) MouseButtonUp(button MouseButton) {
+	i.mouseButtons[button] = false
+}
+
+// IsMouseButtonDown returns what state the mouse button is in
+func (i *Input) IsMouseButtonDown(button MouseButton) bool {
+	return i.mouseButtons[button]
+}
+
+// MouseWheel accumulates wheel movement in notches, positive when rolled away from the user
+func (i *Input) MouseWheel(notches float32) {
+	i.wheelDelta += notches
+}
+
+// MousePosition returns the cursor position in window pixels, measured from the top left corner
+func (i *Input) MousePosition() (x, y int) {
+	return i.mouseX, i.mouseY
+}
+
+// MouseDelta returns how far the cursor moved during this frame
+func (i *Input) MouseDelta() (dx, dy int) {
+	return i.mouseDeltaX, i.mouseDeltaY
+}
+
+// WheelDelta returns how many notches the wheel turned during this frame
+func (i *Input) WheelDelta() float32 {
+	return i.wheelDelta
+}
+
+// EndFrame clears the movement accumulated during the frame
+func (i *Input) EndFrame() {
+	i.mouseDeltaX, i.mouseDeltaY = 0, 0
+	i.wheelDelta = 0
+}
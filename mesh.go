@@ -0,0 +1,136 @@
+package opengl_exercise
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Mesh is model geometry kept in memory, ready to be uploaded with NewModelFromMesh.
+// Triangles are wound clockwise when seen from the front, matching the front face set up in InitializeOpenGL.
+type Mesh struct {
+	Vertices []Vertex
+	Indices  []uint32
+
+	// Groups split the indices into ranges that share a material, together they cover all indices
+	Groups []MeshGroup
+}
+
+// MeshGroup is a range of mesh indices drawn with the same material
+type MeshGroup struct {
+	Name     string
+	Material *Material
+
+	// Start and Count select the indices of the group
+	Start, Count int
+}
+
+// Material describes the surface of a mesh group, colors are linear RGB
+type Material struct {
+	Name string
+
+	Ambient   Vector
+	Diffuse   Vector
+	Specular  Vector
+	Emissive  Vector
+	Shininess float32
+	Opacity   float32
+
+	// Metallic and Roughness are the factors of physically based materials, Diffuse is their base color
+	Metallic  float32
+	Roughness float32
+
+	// DoubleSided materials are visible from behind, AlphaMode is "OPAQUE", "MASK" or "BLEND" like in glTF
+	DoubleSided bool
+	AlphaMode   string
+	AlphaCutoff float32
+
+	// texture paths as written in the material file
+	AmbientTexture           string
+	DiffuseTexture           string
+	SpecularTexture          string
+	NormalTexture            string
+	MetallicRoughnessTexture string
+	OcclusionTexture         string
+	EmissiveTexture          string
+}
+
+// NewMaterial returns a white, opaque material
+func NewMaterial(name string) *Material {
+	return &Material{
+		Name:        name,
+		Ambient:     Vector{X: 1, Y: 1, Z: 1},
+		Diffuse:     Vector{X: 1, Y: 1, Z: 1},
+		Opacity:     1,
+		Roughness:   1,
+		AlphaMode:   "OPAQUE",
+		AlphaCutoff: 0.5,
+	}
+}
+
+// ParseError is returned by the model file readers, it points to the line that could not be read
+type ParseError struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+
+	return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// MeshEncoding selects how the mesh writers store data
+type MeshEncoding int
+
+const (
+	EncodingASCII MeshEncoding = iota
+	EncodingBinaryLittleEndian
+	EncodingBinaryBigEndian
+)
+
+// LoadModel reads a mesh file and uploads it, the format is picked by the file extension
+func LoadModel(path string) (*Model, error) {
+	var mesh *Mesh
+	var err error
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".obj":
+		mesh, err = LoadOBJ(path)
+	case ".stl":
+		mesh, err = LoadSTL(path)
+	case ".ply":
+		mesh, err = LoadPLY(path)
+	default:
+		return nil, fmt.Errorf("%s: unknown mesh format", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return NewModelFromMesh(mesh)
+}
+
+// triangleNormal returns the normal of the side a clockwise triangle is seen from
+func triangleNormal(a, b, c Vector) Vector {
+	return direction(b.AddVector(a.Negative()).Cross(c.AddVector(a.Negative())))
+}
+
+// appendFlatTriangle adds a triangle with its own three vertices, which all get the face normal
+func (m *Mesh) appendFlatTriangle(a, b, c Vertex) {
+	normal := triangleNormal(Vector{a.X, a.Y, a.Z}, Vector{b.X, b.Y, b.Z}, Vector{c.X, c.Y, c.Z})
+
+	base := uint32(len(m.Vertices))
+	for _, vertex := range []Vertex{a, b, c} {
+		vertex.NX, vertex.NY, vertex.NZ = normal.X, normal.Y, normal.Z
+		m.Vertices = append(m.Vertices, vertex)
+	}
+	m.Indices = append(m.Indices, base, base+1, base+2)
+}
@@ -1,19 +1,47 @@
 package opengl_exercise
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"unsafe"
 
 	"github.com/nullbus/opengl_exercise/gl"
 )
 
 type Vertex struct {
-	X, Y, Z float32
-	R, G, B float32
+	X, Y, Z    float32
+	R, G, B    float32
+	NX, NY, NZ float32
+	U, V       float32
+
+	// TX, TY, TZ is the tangent along increasing U, TW the sign of the bitangent like in MikkTSpace
+	TX, TY, TZ, TW float32
 }
 
 type Model struct {
-	vertices []Vertex
-	indices  []uint32
+	// Transform places the model in the world
+	Transform *Transform
+
+	// LODPixelError is how many pixels a simplified level may be off before Render picks a more detailed one
+	LODPixelError float32
+
+	// layout describes the uploaded vertices, vertices is only kept for models made of Vertex structs
+	layout    VertexLayout
+	vertices  []Vertex
+	positions []Vector
+	indices   []uint32
+	groups    []MeshGroup
+
+	// lods are the ranges of the index buffer to draw at each level of detail, empty without GenerateLODs
+	lods []ModelLOD
+
+	// primitive is what the indices draw, dynamic holds the buffers of models made with NewDynamicModel
+	primitive Primitive
+	dynamic   *dynamicBuffers
+
+	bounds         AABB
+	boundingSphere BoundingSphere
 
 	vertexArray  uint32
 	vertexBuffer uint32
@@ -21,27 +49,74 @@ type Model struct {
 }
 
 func NewModel() (*Model, error) {
-	model := &Model{}
+	model := &Model{
+		Transform:     NewTransform(),
+		LODPixelError: 1,
+	}
 
 	// initialize the vetex and index buffer and that hold the geometry fot the triangle
 	return model, model.initialize()
 }
 
+// NewModelFromMesh uploads the geometry of a mesh, for example one returned by LoadOBJ
+func NewModelFromMesh(mesh *Mesh) (*Model, error) {
+	if len(mesh.Vertices) == 0 || len(mesh.Indices) == 0 {
+		return nil, errors.New("mesh has no triangles")
+	}
+
+	model := &Model{
+		Transform:     NewTransform(),
+		LODPixelError: 1,
+		layout:        DefaultVertexLayout(),
+		vertices:      mesh.Vertices,
+		indices:       mesh.Indices,
+		groups:        mesh.Groups,
+	}
+
+	// calculate the extents of the model for culling
+	model.positions = vertexPositions(model.vertices)
+	model.calculateBounds()
+
+	return model, model.initializeBuffers(unsafe.Pointer(&model.vertices[0]), len(model.vertices)*int(unsafe.Sizeof(Vertex{})))
+}
+
+// NewModelFromData uploads interleaved vertices in any layout, for formats the Vertex struct does not cover
+func NewModelFromData(layout VertexLayout, data []byte, indices []uint32) (*Model, error) {
+	if err := layout.Validate(); err != nil {
+		return nil, err
+	}
+	if len(data) == 0 || len(data)%layout.Stride() != 0 {
+		return nil, fmt.Errorf("vertex data of %d bytes is not a multiple of the %d byte vertex size", len(data), layout.Stride())
+	}
+	if len(indices) == 0 {
+		return nil, errors.New("model has no triangles")
+	}
+
+	model := &Model{
+		Transform:     NewTransform(),
+		LODPixelError: 1,
+		layout:        layout,
+		positions:     layout.Positions(data),
+		indices:       indices,
+	}
+	for _, index := range indices {
+		if int(index) >= len(model.positions) {
+			return nil, fmt.Errorf("index %d out of range of %d vertices", index, len(model.positions))
+		}
+	}
+
+	// calculate the extents of the model for culling
+	model.calculateBounds()
+
+	return model, model.initializeBuffers(unsafe.Pointer(&data[0]), len(data))
+}
+
 func (m *Model) initialize() error {
 	// load vertex array with data
 	m.vertices = []Vertex{
-		{
-			-1, -1, 0,
-			0, 1, 0,
-		},
-		{
-			0, 1, 0,
-			0, 1, 0,
-		},
-		{
-			1, -1, 0,
-			0, 1, 0,
-		},
+		{X: -1, Y: -1, Z: 0, G: 1, NZ: -1},
+		{X: 0, Y: 1, Z: 0, G: 1, NZ: -1, U: 0.5, V: 1},
+		{X: 1, Y: -1, Z: 0, G: 1, NZ: -1, U: 1},
 	}
 
 	// load index array with data
@@ -51,6 +126,16 @@ func (m *Model) initialize() error {
 		2, // bottom right
 	}
 
+	// calculate the extents of the model for culling
+	m.layout = DefaultVertexLayout()
+	m.positions = vertexPositions(m.vertices)
+	m.calculateBounds()
+
+	return m.initializeBuffers(unsafe.Pointer(&m.vertices[0]), len(m.vertices)*int(unsafe.Sizeof(Vertex{})))
+}
+
+// initializeBuffers uploads the vertices and indices of the model
+func (m *Model) initializeBuffers(vertices unsafe.Pointer, size int) error {
 	// allocate opengl vertex array object
 	gl.GenVertexArrays(1, &m.vertexArray)
 
@@ -62,20 +147,10 @@ func (m *Model) initialize() error {
 
 	// bind vertex buffer and load the vertex data into the vertex buffer
 	gl.BindBuffer(gl.ARRAY_BUFFER, m.vertexBuffer)
-	gl.BufferData(gl.ARRAY_BUFFER, len(m.vertices)*int(unsafe.Sizeof(Vertex{})), unsafe.Pointer(&m.vertices[0]), gl.STATIC_DRAW)
-
-	// enable two vertex array attributes
-	gl.EnableVertexAttribArray(0) // vertex position
-	gl.EnableVertexAttribArray(1) // vertex color
+	gl.BufferData(gl.ARRAY_BUFFER, size, vertices, gl.STATIC_DRAW)
 
-	// specify the location and format of the position portion of the vertex buffer
-	stride := int32(unsafe.Sizeof(Vertex{}))
-	gl.BindBuffer(gl.ARRAY_BUFFER, m.vertexBuffer)
-	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, stride, nil)
-
-	// specify the location and format of the color portion of the vertex buffer
-	gl.BindBuffer(gl.ARRAY_BUFFER, m.vertexBuffer)
-	gl.VertexAttribPointer(1, 3, gl.FLOAT, false, stride, unsafe.Pointer(uintptr(3*4 /*sizeof(float32)*/)))
+	// specify the location and format of every attribute in the vertex buffer
+	enableVertexLayout(m.layout)
 
 	// generate an id for the index buffer
 	gl.GenBuffers(1, &m.indexBuffer)
@@ -87,10 +162,219 @@ func (m *Model) initialize() error {
 	return nil
 }
 
+// Bounds returns the box around the model vertices in model space
+func (m *Model) Bounds() AABB {
+	return m.bounds
+}
+
+// Groups returns the index ranges of the model that use the same material
+func (m *Model) Groups() []MeshGroup {
+	return m.groups
+}
+
+// BoundingSphere returns the sphere around the model vertices in model space
+func (m *Model) BoundingSphere() BoundingSphere {
+	return m.boundingSphere
+}
+
+// UpdateVertices replaces the vertex data of the model and recalculates its bounds
+func (m *Model) UpdateVertices(vertices []Vertex) error {
+	if m.vertices == nil {
+		return errors.New("model was not created from Vertex structs, use UpdateVertexData")
+	}
+
+	if m.dynamic != nil {
+		if err := m.updateDynamic(vertexBytes(vertices), nil, false); err != nil {
+			return err
+		}
+
+		// dynamic models keep their own copy, the caller may reuse the slice for the next frame
+		m.vertices = append(m.vertices[:0], vertices...)
+		return nil
+	}
+
+	if len(vertices) == 0 {
+		return errors.New("model needs at least one vertex")
+	}
+	if err := checkIndices(m.indices, len(vertices)); err != nil {
+		return err
+	}
+
+	m.uploadVertices(unsafe.Pointer(&vertices[0]), len(vertices)*int(unsafe.Sizeof(Vertex{})), len(vertices))
+
+	// keep a copy, the caller may reuse the slice
+	m.vertices = append([]Vertex(nil), vertices...)
+	m.positions = vertexPositions(vertices)
+	m.calculateBounds()
+
+	return nil
+}
+
+// Mesh returns a copy of the geometry of a model made of Vertex structs, or nil for models uploaded with NewModelFromData
+func (m *Model) Mesh() *Mesh {
+	if m.vertices == nil {
+		return nil
+	}
+
+	return &Mesh{
+		Vertices: append([]Vertex(nil), m.vertices...),
+		Indices:  append([]uint32(nil), m.indices...),
+		Groups:   append([]MeshGroup(nil), m.groups...),
+	}
+}
+
+// UpdateMesh replaces the vertices, indices and groups of a model made of Vertex structs
+func (m *Model) UpdateMesh(mesh *Mesh) error {
+	if m.vertices == nil {
+		return errors.New("model was not created from Vertex structs, use UpdateVertexData")
+	}
+
+	if m.dynamic != nil {
+		if err := m.updateDynamic(vertexBytes(mesh.Vertices), mesh.Indices, true); err != nil {
+			return err
+		}
+
+		m.vertices = append(m.vertices[:0], mesh.Vertices...)
+		m.groups = mesh.Groups
+		return nil
+	}
+
+	if len(mesh.Vertices) == 0 || len(mesh.Indices) == 0 {
+		return errors.New("mesh has no triangles")
+	}
+	if err := checkIndices(mesh.Indices, len(mesh.Vertices)); err != nil {
+		return err
+	}
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, m.vertexBuffer)
+	gl.BufferData(gl.ARRAY_BUFFER, len(mesh.Vertices)*int(unsafe.Sizeof(Vertex{})), unsafe.Pointer(&mesh.Vertices[0]), gl.STATIC_DRAW)
+
+	// the element array binding is part of the vertex array object
+	gl.BindVertexArray(m.vertexArray)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.indexBuffer)
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(mesh.Indices)*4 /*sizeof(uint32)*/, unsafe.Pointer(&mesh.Indices[0]), gl.STATIC_DRAW)
+
+	// the simplified levels were made from the old triangles
+	m.vertices = append([]Vertex(nil), mesh.Vertices...)
+	m.indices = append([]uint32(nil), mesh.Indices...)
+	m.groups = mesh.Groups
+	m.lods = nil
+	m.positions = vertexPositions(m.vertices)
+	m.calculateBounds()
+
+	return nil
+}
+
+// UpdateVertexData replaces the vertex data of the model with interleaved vertices in its layout
+func (m *Model) UpdateVertexData(data []byte) error {
+	if m.dynamic != nil {
+		if err := m.updateDynamic(data, nil, false); err != nil {
+			return err
+		}
+
+		m.vertices = nil
+		return nil
+	}
+
+	stride := m.layout.Stride()
+	if len(data) == 0 || len(data)%stride != 0 {
+		return fmt.Errorf("vertex data of %d bytes is not a multiple of the %d byte vertex size", len(data), stride)
+	}
+	if err := checkIndices(m.indices, len(data)/stride); err != nil {
+		return err
+	}
+
+	m.uploadVertices(unsafe.Pointer(&data[0]), len(data), len(data)/stride)
+
+	// the Vertex structs no longer match what was uploaded
+	m.vertices = nil
+	m.positions = m.layout.Positions(data)
+	m.calculateBounds()
+
+	return nil
+}
+
+func (m *Model) uploadVertices(data unsafe.Pointer, size, count int) {
+	gl.BindBuffer(gl.ARRAY_BUFFER, m.vertexBuffer)
+	if count == len(m.positions) {
+		// same amount of data, so the existing storage can be overwritten
+		gl.BufferSubData(gl.ARRAY_BUFFER, 0, size, data)
+	} else {
+		gl.BufferData(gl.ARRAY_BUFFER, size, data, gl.STATIC_DRAW)
+	}
+}
+
+// Layout returns the format of the uploaded vertices
+func (m *Model) Layout() VertexLayout {
+	return m.layout
+}
+
+// IntersectRay finds the closest triangle of the model hit by a ray given in model space
+func (m *Model) IntersectRay(ray Ray) (RayHit, bool) {
+	closest := RayHit{Distance: float32(math.Inf(1))}
+	found := false
+
+	// skip the triangles when the ray misses the whole model, lines and points have no triangles to hit
+	if _, hit := m.bounds.IntersectRay(ray); !hit || m.primitive != PrimitiveTriangles {
+		return closest, false
+	}
+
+	for i := 0; i+2 < len(m.indices); i += 3 {
+		v0 := m.positions[m.indices[i]]
+		v1 := m.positions[m.indices[i+1]]
+		v2 := m.positions[m.indices[i+2]]
+
+		t, u, v, hit := intersectTriangle(ray, v0, v1, v2)
+		if !hit || t >= closest.Distance {
+			continue
+		}
+
+		closest = RayHit{
+			Triangle:    i / 3,
+			Barycentric: Vector{X: 1 - u - v, Y: u, Z: v},
+			Distance:    t,
+		}
+		found = true
+	}
+
+	return closest, found
+}
+
+// IntersectWorldRay finds the closest triangle hit by a world space ray, taking the model Transform into account
+func (m *Model) IntersectWorldRay(ray Ray) (RayHit, bool) {
+	world := m.Transform.WorldMatrix()
+	inverse, err := world.Inverse()
+	if err != nil {
+		return RayHit{}, false
+	}
+
+	return m.IntersectRay(ray.Transform(&inverse))
+}
+
+func (m *Model) calculateBounds() {
+	m.bounds = NewAABB(m.positions)
+	m.boundingSphere = NewBoundingSphere(m.positions)
+}
+
+func vertexPositions(vertices []Vertex) []Vector {
+	positions := make([]Vector, len(vertices))
+	for i, v := range vertices {
+		positions[i] = Vector{X: v.X, Y: v.Y, Z: v.Z}
+	}
+
+	return positions
+}
+
 func (m *Model) Shutdown() {
-	// disable two vertex array attributes
-	gl.DisableVertexAttribArray(0)
-	gl.DisableVertexAttribArray(1)
+	// dynamic models keep their buffers in slots
+	if m.dynamic != nil {
+		m.dynamic.release()
+	}
+
+	// disable the vertex array attributes
+	for _, attribute := range m.layout.Attributes {
+		gl.DisableVertexAttribArray(uint32(attribute.Semantic.Location()))
+	}
 
 	// release vertex buffer
 	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
@@ -105,14 +389,63 @@ func (m *Model) Shutdown() {
 	gl.DeleteVertexArrays(1, &m.vertexArray)
 }
 
-func (m *Model) Render() {
-	m.renderBuffers()
+// Render draws the model at the level of detail that fits its size on screen, see SelectLOD.
+// The camera needs to be rendered first and viewportHeight is the height of the viewport in pixels.
+func (m *Model) Render(camera *Camera, viewportHeight int32) {
+	if m.dynamic != nil {
+		m.renderDynamic()
+		return
+	}
+
+	if len(m.lods) == 0 {
+		m.renderBuffers(0, len(m.indices))
+		return
+	}
+
+	lod := m.lods[m.SelectLOD(camera, viewportHeight)]
+	m.renderBuffers(lod.Start, lod.Count)
 }
 
-func (m *Model) renderBuffers() {
+func (m *Model) renderBuffers(start, count int) {
 	// bind the vertex array object that stored all the information about the vetex and index buffers
 	gl.BindVertexArray(m.vertexArray)
 
-	// render vertex buffer using the index buffer
-	gl.DrawElements(gl.TRIANGLES, int32(len(m.indices)), gl.UNSIGNED_INT, nil)
+	// render vertex buffer using the range of the index buffer
+	gl.DrawElements(m.primitive.glMode(), int32(count), gl.UNSIGNED_INT, unsafe.Pointer(uintptr(start*4 /*sizeof(uint32)*/)))
+}
+
+// enableVertexLayout points the attributes of the bound vertex array at the bound vertex buffer
+func enableVertexLayout(layout VertexLayout) {
+	stride := int32(layout.Stride())
+	for i, attribute := range layout.Attributes {
+		index := uint32(attribute.Semantic.Location())
+		offset := unsafe.Pointer(uintptr(layout.Offset(i)))
+
+		gl.EnableVertexAttribArray(index)
+		if attribute.Type == AttributeFloat32 || attribute.Normalized {
+			gl.VertexAttribPointer(index, int32(attribute.Components), glAttributeType(attribute.Type), attribute.Normalized, stride, offset)
+		} else {
+			// integer attributes like bone indices stay integers in the shader
+			gl.VertexAttribIPointer(index, int32(attribute.Components), glAttributeType(attribute.Type), stride, offset)
+		}
+	}
+}
+
+func glAttributeType(t VertexAttributeType) uint32 {
+	switch t {
+	case AttributeInt8:
+		return gl.BYTE
+	case AttributeUint8:
+		return gl.UNSIGNED_BYTE
+	case AttributeInt16:
+		return gl.SHORT
+	case AttributeUint16:
+		return gl.UNSIGNED_SHORT
+	case AttributeInt32:
+		return gl.INT
+	case AttributeUint32:
+		return gl.UNSIGNED_INT
+	default:
+		return gl.FLOAT
+	}
 }
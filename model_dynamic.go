@@ -0,0 +1,459 @@
+package opengl_exercise
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"github.com/nullbus/opengl_exercise/gl"
+)
+
+// Primitive is the shape the vertices of a model are drawn as
+type Primitive int
+
+const (
+	PrimitiveTriangles Primitive = iota
+	PrimitiveLines
+	PrimitivePoints
+)
+
+func (p Primitive) glMode() uint32 {
+	switch p {
+	case PrimitiveLines:
+		return gl.LINES
+	case PrimitivePoints:
+		return gl.POINTS
+	default:
+		return gl.TRIANGLES
+	}
+}
+
+// BufferUsage tells the driver how often the contents of a buffer change, so it can pick the memory to keep it in
+type BufferUsage int
+
+const (
+	// UsageStatic buffers are written once and drawn many times
+	UsageStatic BufferUsage = iota
+
+	// UsageDynamic buffers are rewritten now and then
+	UsageDynamic
+
+	// UsageStream buffers are rewritten about every time they are drawn
+	UsageStream
+)
+
+func (u BufferUsage) String() string {
+	switch u {
+	case UsageStatic:
+		return "static"
+	case UsageDynamic:
+		return "dynamic"
+	case UsageStream:
+		return "stream"
+	default:
+		return "unknown"
+	}
+}
+
+func (u BufferUsage) glUsage() uint32 {
+	switch u {
+	case UsageDynamic:
+		return gl.DYNAMIC_DRAW
+	case UsageStream:
+		return gl.STREAM_DRAW
+	default:
+		return gl.STATIC_DRAW
+	}
+}
+
+// BufferUpdate is the way a dynamic model writes new geometry into its buffers
+type BufferUpdate int
+
+const (
+	// UpdateSubData overwrites the buffers in place, the driver may have to wait for earlier draws that still read them
+	UpdateSubData BufferUpdate = iota
+
+	// UpdateOrphan asks for new storage before every write, the old storage is freed once the draws reading it are done
+	UpdateOrphan
+
+	// UpdateRing writes into the next of several buffers, a fence makes sure the GPU is done with it first
+	UpdateRing
+)
+
+// DynamicOptions configures NewDynamicModel
+type DynamicOptions struct {
+	Primitive Primitive
+	Update    BufferUpdate
+
+	// RingSize is the number of buffers UpdateRing cycles through
+	RingSize int
+
+	// Usage is the first usage hint, the model switches to the one that matches how often it is really updated
+	Usage BufferUsage
+
+	// VertexCapacity and IndexCapacity make room for that many vertices and indices up front, the buffers grow when needed
+	VertexCapacity, IndexCapacity int
+}
+
+// DefaultDynamicOptions draws triangles from orphaned buffers with room for 1024 vertices and 3072 indices
+func DefaultDynamicOptions() DynamicOptions {
+	return DynamicOptions{
+		Primitive:      PrimitiveTriangles,
+		Update:         UpdateOrphan,
+		RingSize:       3,
+		Usage:          UsageDynamic,
+		VertexCapacity: 1024,
+		IndexCapacity:  3072,
+	}
+}
+
+const (
+	// usageWindow is the number of draws the usage hint of a dynamic model is checked over
+	usageWindow = 60
+
+	// ringFenceTimeout is how long to wait for the GPU to finish with a ring buffer, in nanoseconds
+	ringFenceTimeout = 1000000000
+
+	// minimumBufferSize keeps tiny buffers from growing a few bytes at a time
+	minimumBufferSize = 256
+)
+
+// dynamicBuffers holds the buffers of a dynamic model. Updates only change the copy of the geometry kept here,
+// it is uploaded when the model is drawn, so several updates in one frame cost one upload.
+type dynamicBuffers struct {
+	update  BufferUpdate
+	usage   BufferUsage
+	slots   []dynamicSlot
+	current int
+
+	// data is the latest vertex data, the versions count the updates so every slot knows what it is missing
+	data                        []byte
+	vertexVersion, indexVersion int
+
+	// draws and updatedDraws count since the usage hint was last checked
+	draws, updatedDraws int
+	updated             bool
+}
+
+// dynamicSlot is one set of buffers, there is one for UpdateSubData and UpdateOrphan and several for UpdateRing
+type dynamicSlot struct {
+	vertexArray  uint32
+	vertexBuffer uint32
+	indexBuffer  uint32
+
+	// the capacities are in bytes, usage is the hint the storage was created with
+	vertexCapacity, indexCapacity int
+	vertexVersion, indexVersion   int
+	usage                         BufferUsage
+
+	// fence is signaled once the GPU is done with the last draw from the slot
+	fence uintptr
+}
+
+// NewDynamicModel creates a model whose geometry can change every frame, for debug lines, particles or meshes deformed on the CPU.
+// It starts out empty. UpdateMesh and UpdateVertices take Vertex structs when the layout is DefaultVertexLayout,
+// UpdateGeometry and UpdateVertexData take vertices in any layout. Models without indices draw their vertices in order.
+func NewDynamicModel(layout VertexLayout, options DynamicOptions) (*Model, error) {
+	if err := layout.Validate(); err != nil {
+		return nil, err
+	}
+
+	slots := 1
+	if options.Update == UpdateRing {
+		slots = atLeast(options.RingSize, 2)
+	}
+
+	model := &Model{
+		Transform:     NewTransform(),
+		LODPixelError: 1,
+		layout:        layout,
+		primitive:     options.Primitive,
+		dynamic: &dynamicBuffers{
+			update: options.Update,
+			usage:  options.Usage,
+			slots:  make([]dynamicSlot, slots),
+		},
+	}
+	if layout.Equal(DefaultVertexLayout()) {
+		model.vertices = []Vertex{}
+	}
+
+	vertexCapacity := growCapacity(0, options.VertexCapacity*layout.Stride())
+	indexCapacity := growCapacity(0, options.IndexCapacity*4 /*sizeof(uint32)*/)
+	for i := range model.dynamic.slots {
+		model.dynamic.slots[i].initialize(layout, vertexCapacity, indexCapacity, options.Usage)
+	}
+
+	return model, nil
+}
+
+func (s *dynamicSlot) initialize(layout VertexLayout, vertexCapacity, indexCapacity int, usage BufferUsage) {
+	// the vertex array object remembers the vertex layout and the index buffer of the slot
+	gl.GenVertexArrays(1, &s.vertexArray)
+	gl.BindVertexArray(s.vertexArray)
+
+	// reserve the storage without filling it
+	gl.GenBuffers(1, &s.vertexBuffer)
+	gl.BindBuffer(gl.ARRAY_BUFFER, s.vertexBuffer)
+	gl.BufferData(gl.ARRAY_BUFFER, vertexCapacity, nil, usage.glUsage())
+	enableVertexLayout(layout)
+
+	gl.GenBuffers(1, &s.indexBuffer)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, s.indexBuffer)
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, indexCapacity, nil, usage.glUsage())
+
+	s.vertexCapacity, s.indexCapacity, s.usage = vertexCapacity, indexCapacity, usage
+}
+
+// Dynamic tells whether the model was created with NewDynamicModel
+func (m *Model) Dynamic() bool {
+	return m.dynamic != nil
+}
+
+// Usage returns the usage hint of the model buffers, dynamic models pick it by how often they are updated
+func (m *Model) Usage() BufferUsage {
+	if m.dynamic == nil {
+		return UsageStatic
+	}
+
+	return m.dynamic.usage
+}
+
+// Capacity returns how many vertices and indices fit in the buffers of the model before they have to grow
+func (m *Model) Capacity() (vertices, indices int) {
+	if m.dynamic == nil {
+		return len(m.positions), len(m.indices)
+	}
+
+	slot := m.dynamic.slots[m.dynamic.current]
+	return slot.vertexCapacity / m.layout.Stride(), slot.indexCapacity / 4 /*sizeof(uint32)*/
+}
+
+// UpdateGeometry replaces the vertices and indices of the model, the vertices are interleaved in the model layout
+func (m *Model) UpdateGeometry(data []byte, indices []uint32) error {
+	if m.dynamic != nil {
+		if err := m.updateDynamic(data, indices, true); err != nil {
+			return err
+		}
+
+		m.vertices, m.groups = nil, nil
+		return nil
+	}
+
+	stride := m.layout.Stride()
+	if len(data) == 0 || len(data)%stride != 0 {
+		return fmt.Errorf("vertex data of %d bytes is not a multiple of the %d byte vertex size", len(data), stride)
+	}
+	if len(indices) == 0 {
+		return errors.New("model has no triangles")
+	}
+	if err := checkIndices(indices, len(data)/stride); err != nil {
+		return err
+	}
+
+	m.uploadVertices(unsafe.Pointer(&data[0]), len(data), len(data)/stride)
+
+	// the element array binding is part of the vertex array object
+	gl.BindVertexArray(m.vertexArray)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.indexBuffer)
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*4 /*sizeof(uint32)*/, unsafe.Pointer(&indices[0]), gl.STATIC_DRAW)
+
+	m.vertices, m.indices, m.groups, m.lods = nil, indices, nil, nil
+	m.positions = m.layout.Positions(data)
+	m.calculateBounds()
+
+	return nil
+}
+
+// updateDynamic keeps new geometry of a dynamic model until it is drawn, indices are only replaced with replaceIndices
+func (m *Model) updateDynamic(data []byte, indices []uint32, replaceIndices bool) error {
+	stride := m.layout.Stride()
+	if len(data)%stride != 0 {
+		return fmt.Errorf("vertex data of %d bytes is not a multiple of the %d byte vertex size", len(data), stride)
+	}
+	if !replaceIndices {
+		indices = m.indices
+	}
+	if err := checkIndices(indices, len(data)/stride); err != nil {
+		return err
+	}
+
+	d := m.dynamic
+	d.data = append(d.data[:0], data...)
+	d.vertexVersion++
+	if replaceIndices {
+		m.indices = append(m.indices[:0], indices...)
+		d.indexVersion++
+	}
+	d.updated = true
+
+	m.positions = m.layout.Positions(data)
+	m.calculateBounds()
+
+	return nil
+}
+
+func checkIndices(indices []uint32, vertexCount int) error {
+	for _, index := range indices {
+		if int(index) >= vertexCount {
+			return fmt.Errorf("index %d out of range of %d vertices", index, vertexCount)
+		}
+	}
+
+	return nil
+}
+
+// vertexBytes returns the memory of the vertices, which is laid out like DefaultVertexLayout
+func vertexBytes(vertices []Vertex) []byte {
+	if len(vertices) == 0 {
+		return nil
+	}
+
+	size := len(vertices) * int(unsafe.Sizeof(Vertex{}))
+	return (*[1 << 30]byte)(unsafe.Pointer(&vertices[0]))[:size:size]
+}
+
+func (m *Model) renderDynamic() {
+	d := m.dynamic
+	slot := d.upload(m.indices)
+
+	gl.BindVertexArray(slot.vertexArray)
+	if len(m.indices) > 0 {
+		gl.DrawElements(m.primitive.glMode(), int32(len(m.indices)), gl.UNSIGNED_INT, nil)
+	} else if len(m.positions) > 0 {
+		gl.DrawArrays(m.primitive.glMode(), 0, int32(len(m.positions)))
+	}
+
+	if d.update == UpdateRing {
+		// remember when the GPU is done with this draw, the slot is not written again before that
+		if slot.fence != 0 {
+			gl.DeleteSync(slot.fence)
+		}
+		slot.fence = gl.FenceSync(gl.SYNC_GPU_COMMANDS_COMPLETE, 0)
+	}
+
+	d.trackUsage()
+}
+
+// upload brings the slot to draw from up to date with the latest geometry and returns it
+func (d *dynamicBuffers) upload(indices []uint32) *dynamicSlot {
+	slot := &d.slots[d.current]
+	if slot.vertexVersion == d.vertexVersion && slot.indexVersion == d.indexVersion && slot.usage == d.usage {
+		return slot
+	}
+
+	if d.update == UpdateRing {
+		// leave the slot the GPU may still be reading alone and wait until the next one is free
+		d.current = (d.current + 1) % len(d.slots)
+		slot = &d.slots[d.current]
+		slot.wait()
+	}
+
+	// a new usage hint needs new storage, which then has to be filled completely
+	respecify := slot.usage != d.usage
+	slot.usage = d.usage
+
+	// the element array binding is part of the vertex array object
+	gl.BindVertexArray(slot.vertexArray)
+
+	if respecify || slot.vertexVersion != d.vertexVersion {
+		gl.BindBuffer(gl.ARRAY_BUFFER, slot.vertexBuffer)
+		var data unsafe.Pointer
+		if len(d.data) > 0 {
+			data = unsafe.Pointer(&d.data[0])
+		}
+		slot.vertexCapacity = d.write(gl.ARRAY_BUFFER, slot.vertexCapacity, data, len(d.data), respecify)
+		slot.vertexVersion = d.vertexVersion
+	}
+
+	if respecify || slot.indexVersion != d.indexVersion {
+		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, slot.indexBuffer)
+		var data unsafe.Pointer
+		if len(indices) > 0 {
+			data = unsafe.Pointer(&indices[0])
+		}
+		slot.indexCapacity = d.write(gl.ELEMENT_ARRAY_BUFFER, slot.indexCapacity, data, len(indices)*4 /*sizeof(uint32)*/, respecify)
+		slot.indexVersion = d.indexVersion
+	}
+
+	return slot
+}
+
+// write copies data into the buffer bound to target and returns its capacity, which grows when the data does not fit
+func (d *dynamicBuffers) write(target uint32, capacity int, data unsafe.Pointer, size int, respecify bool) int {
+	switch {
+	case size > capacity:
+		// at least double the capacity so that slowly growing geometry does not reallocate every frame
+		capacity = growCapacity(capacity, size)
+		gl.BufferData(target, capacity, nil, d.usage.glUsage())
+	case respecify || d.update == UpdateOrphan:
+		// new storage of the same size, draws that still use the old storage keep it until they are done
+		gl.BufferData(target, capacity, nil, d.usage.glUsage())
+	}
+
+	if size > 0 {
+		gl.BufferSubData(target, 0, size, data)
+	}
+
+	return capacity
+}
+
+// growCapacity returns the capacity doubled until size fits
+func growCapacity(capacity, size int) int {
+	if capacity < minimumBufferSize {
+		capacity = minimumBufferSize
+	}
+	for capacity < size {
+		capacity *= 2
+	}
+
+	return capacity
+}
+
+// trackUsage counts the draws and the updates between them, and switches the usage hint when it no longer fits
+func (d *dynamicBuffers) trackUsage() {
+	d.draws++
+	if d.updated {
+		d.updatedDraws++
+		d.updated = false
+	}
+	if d.draws < usageWindow {
+		return
+	}
+
+	// updated for most draws is streaming, the next upload creates storage with the new hint
+	switch {
+	case d.updatedDraws*2 >= d.draws:
+		d.usage = UsageStream
+	case d.updatedDraws > 0:
+		d.usage = UsageDynamic
+	default:
+		d.usage = UsageStatic
+	}
+
+	d.draws, d.updatedDraws = 0, 0
+}
+
+// wait blocks until the GPU is done with the last draw from the slot
+func (s *dynamicSlot) wait() {
+	if s.fence == 0 {
+		return
+	}
+
+	gl.ClientWaitSync(s.fence, gl.SYNC_FLUSH_COMMANDS_BIT, ringFenceTimeout)
+	gl.DeleteSync(s.fence)
+	s.fence = 0
+}
+
+func (d *dynamicBuffers) release() {
+	for i := range d.slots {
+		slot := &d.slots[i]
+		if slot.fence != 0 {
+			gl.DeleteSync(slot.fence)
+		}
+
+		gl.DeleteBuffers(1, &slot.vertexBuffer)
+		gl.DeleteBuffers(1, &slot.indexBuffer)
+		gl.DeleteVertexArrays(1, &slot.vertexArray)
+	}
+}
@@ -0,0 +1,209 @@
+package opengl_exercise
+
+import (
+	"strings"
+	"testing"
+)
+
+// testDynamicModel returns a dynamic model in the default layout without buffers, which is enough as long as it is not drawn
+func testDynamicModel() *Model {
+	return &Model{
+		Transform: NewTransform(),
+		layout:    DefaultVertexLayout(),
+		vertices:  []Vertex{},
+		dynamic: &dynamicBuffers{
+			update: UpdateOrphan,
+			usage:  UsageDynamic,
+			slots:  make([]dynamicSlot, 1),
+		},
+	}
+}
+
+func TestGrowCapacity(t *testing.T) {
+	tests := []struct {
+		capacity, size, expected int
+	}{
+		{0, 0, minimumBufferSize},
+		{0, 10, minimumBufferSize},
+		{0, 1000, 1024},
+		{256, 256, 256},
+		{256, 257, 512},
+		{1000, 1001, 2000},
+		{1024, 5000, 8192},
+		// capacities never shrink
+		{4096, 10, 4096},
+	}
+
+	for _, test := range tests {
+		if capacity := growCapacity(test.capacity, test.size); capacity != test.expected {
+			t.Errorf("growCapacity(%d, %d): expected %d, got %d", test.capacity, test.size, test.expected, capacity)
+		}
+	}
+}
+
+func TestTrackUsage(t *testing.T) {
+	tests := []struct {
+		name string
+		// every is the number of draws between updates, zero for no updates
+		every    int
+		expected BufferUsage
+	}{
+		{"every draw", 1, UsageStream},
+		{"every other draw", 2, UsageStream},
+		{"every third draw", 3, UsageDynamic},
+		{"once a window", usageWindow, UsageDynamic},
+		{"never", 0, UsageStatic},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := &dynamicBuffers{usage: UsageDynamic}
+			if test.expected == UsageDynamic {
+				d.usage = UsageStatic
+			}
+			initial := d.usage
+
+			for draw := 0; draw < usageWindow; draw++ {
+				if test.every > 0 && draw%test.every == 0 {
+					d.updated = true
+				}
+				if draw < usageWindow-1 && d.usage != initial {
+					t.Fatalf("draw %d: the hint changed to %v before the end of the window", draw, d.usage)
+				}
+				d.trackUsage()
+			}
+
+			if d.usage != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, d.usage)
+			}
+			if d.draws != 0 || d.updatedDraws != 0 || d.updated {
+				t.Errorf("expected the counts to start over, got %d draws and %d updated", d.draws, d.updatedDraws)
+			}
+		})
+	}
+
+	t.Run("several updates in one frame", func(t *testing.T) {
+		model := testDynamicModel()
+		model.dynamic.usage = UsageStream
+		vertices := NewPlaneMesh(1, 1, 1, 1).Vertices
+		for draw := 0; draw < usageWindow; draw++ {
+			if draw%4 == 0 {
+				// the updates between two draws count once
+				for i := 0; i < 3; i++ {
+					if err := model.UpdateVertices(vertices); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			model.dynamic.trackUsage()
+		}
+		if model.Usage() != UsageDynamic {
+			t.Errorf("expected %v, got %v", UsageDynamic, model.Usage())
+		}
+	})
+}
+
+func TestDynamicModelVersions(t *testing.T) {
+	model := testDynamicModel()
+	d := model.dynamic
+	mesh := NewPlaneMesh(1, 1, 1, 1)
+
+	if err := model.UpdateMesh(mesh); err != nil {
+		t.Fatal(err)
+	}
+	if d.vertexVersion != 1 || d.indexVersion != 1 || !d.updated {
+		t.Fatalf("expected both versions to be 1 after the first update, got %d and %d", d.vertexVersion, d.indexVersion)
+	}
+
+	// moving the vertices keeps the indices
+	vertices := append([]Vertex(nil), mesh.Vertices...)
+	vertices[0].Y = 1
+	if err := model.UpdateVertices(vertices); err != nil {
+		t.Fatal(err)
+	}
+	if d.vertexVersion != 2 || d.indexVersion != 1 {
+		t.Errorf("expected the vertex version 2 and the index version 1, got %d and %d", d.vertexVersion, d.indexVersion)
+	}
+	if len(model.indices) != len(mesh.Indices) {
+		t.Errorf("expected the %d indices to be kept, got %d", len(mesh.Indices), len(model.indices))
+	}
+
+	// the model keeps its own copies, the caller may reuse the slices
+	vertices[0].Y = 2
+	mesh.Indices[0] = 3
+	if model.vertices[0].Y != 1 || model.positions[0].Y != 1 || model.indices[0] == 3 {
+		t.Error("expected the model not to share the slices it was updated with")
+	}
+	if len(d.data) != len(vertices)*DefaultVertexLayout().Stride() {
+		t.Errorf("expected %d bytes of vertex data, got %d", len(vertices)*DefaultVertexLayout().Stride(), len(d.data))
+	}
+
+	if err := model.UpdateGeometry(vertexBytes(vertices), []uint32{0, 1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if d.vertexVersion != 3 || d.indexVersion != 2 {
+		t.Errorf("expected the vertex version 3 and the index version 2, got %d and %d", d.vertexVersion, d.indexVersion)
+	}
+	if model.vertices != nil || len(model.indices) != 3 {
+		t.Errorf("expected only the raw geometry to be kept, got %d vertices and %d indices", len(model.vertices), len(model.indices))
+	}
+
+	// a rejected update changes nothing
+	if err := model.UpdateVertexData(vertexBytes(vertices[:2])); err == nil {
+		t.Fatal("expected the indices to be out of range of 2 vertices")
+	}
+	if d.vertexVersion != 3 || d.indexVersion != 2 || len(model.positions) != len(vertices) {
+		t.Errorf("expected the rejected update to be ignored, got versions %d and %d", d.vertexVersion, d.indexVersion)
+	}
+}
+
+func TestUpdateGeometryIndices(t *testing.T) {
+	stride := DefaultVertexLayout().Stride()
+	data := vertexBytes(NewPlaneMesh(1, 1, 1, 1).Vertices)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		indices []uint32
+		error   string
+	}{
+		{"in range", data, []uint32{0, 1, 2, 1, 3, 2}, ""},
+		{"no indices", data, nil, ""},
+		{"out of range", data, []uint32{0, 1, 4}, "index 4 out of range of 4 vertices"},
+		{"fewer vertices", data[:3*stride], []uint32{0, 1, 3}, "index 3 out of range of 3 vertices"},
+		{"partial vertex", data[:stride+1], nil, "not a multiple"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := testDynamicModel().UpdateGeometry(test.data, test.indices)
+			if test.error == "" {
+				if err != nil {
+					t.Errorf("unexpected error %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.error) {
+				t.Errorf("expected error containing %q, got %v", test.error, err)
+			}
+		})
+	}
+
+	// static models check the indices before anything is uploaded
+	t.Run("static", func(t *testing.T) {
+		model := &Model{Transform: NewTransform(), layout: DefaultVertexLayout(), vertices: make([]Vertex, 4), indices: []uint32{0, 1, 3}}
+
+		if err := model.UpdateGeometry(data, []uint32{0, 1, 4}); err == nil || !strings.Contains(err.Error(), "out of range") {
+			t.Errorf("UpdateGeometry: expected an index out of range, got %v", err)
+		}
+		if err := model.UpdateVertexData(data[:3*stride]); err == nil || !strings.Contains(err.Error(), "out of range") {
+			t.Errorf("UpdateVertexData: expected an index out of range, got %v", err)
+		}
+		if err := model.UpdateVertices(make([]Vertex, 3)); err == nil || !strings.Contains(err.Error(), "out of range") {
+			t.Errorf("UpdateVertices: expected an index out of range, got %v", err)
+		}
+		if err := model.UpdateMesh(&Mesh{Vertices: make([]Vertex, 3), Indices: []uint32{0, 1, 3}}); err == nil || !strings.Contains(err.Error(), "out of range") {
+			t.Errorf("UpdateMesh: expected an index out of range, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,257 @@
+package opengl_exercise
+
+import (
+	"errors"
+	"math"
+)
+
+// tangentEpsilon is the smallest texture area of a triangle that still gives it a tangent direction
+const tangentEpsilon = 1e-12
+
+// Normal returns the normal of the vertex
+func (v Vertex) Normal() Vector {
+	return Vector{X: v.NX, Y: v.NY, Z: v.NZ}
+}
+
+// Tangent returns the tangent of the vertex, pointing along increasing U
+func (v Vertex) Tangent() Vector {
+	return Vector{X: v.TX, Y: v.TY, Z: v.TZ}
+}
+
+// Bitangent returns the direction of increasing V, rebuilt from the normal and tangent like MikkTSpace shaders do
+func (v Vertex) Bitangent() Vector {
+	return v.Normal().Cross(v.Tangent()).MultiplyScalar(v.TW)
+}
+
+func (v Vertex) position() Vector {
+	return Vector{X: v.X, Y: v.Y, Z: v.Z}
+}
+
+// GenerateFlatNormals gives every triangle its own vertices, which all get the face normal
+func (m *Mesh) GenerateFlatNormals() {
+	vertices := make([]Vertex, 0, len(m.Indices))
+	indices := make([]uint32, 0, len(m.Indices))
+	for i := 0; i+2 < len(m.Indices); i += 3 {
+		a, b, c := m.Vertices[m.Indices[i]], m.Vertices[m.Indices[i+1]], m.Vertices[m.Indices[i+2]]
+		normal := triangleNormal(a.position(), b.position(), c.position())
+
+		for _, vertex := range []Vertex{a, b, c} {
+			vertex.NX, vertex.NY, vertex.NZ = normal.X, normal.Y, normal.Z
+			indices = append(indices, uint32(len(vertices)))
+			vertices = append(vertices, vertex)
+		}
+	}
+
+	// the triangles keep their order, so the groups stay valid
+	m.Vertices, m.Indices = vertices, indices
+}
+
+// GenerateSmoothNormals averages the normals of the triangles around every vertex position, weighted by the corner angles.
+// Triangles whose normals differ by more than angle (in radians) do not share their normals, so vertices on such hard edges are split.
+// An angle of Pi or more smooths everything.
+func (m *Mesh) GenerateSmoothNormals(angle float32) {
+	faceNormals := make([]Vector, len(m.Indices)/3)
+	for i := range faceNormals {
+		a, b, c := m.Vertices[m.Indices[i*3]], m.Vertices[m.Indices[i*3+1]], m.Vertices[m.Indices[i*3+2]]
+		faceNormals[i] = triangleNormal(a.position(), b.position(), c.position())
+	}
+
+	// corners meet at the same position even if uv seams split their vertices
+	cornersAt := map[Vector][]int{}
+	for corner := range m.Indices[:len(faceNormals)*3] {
+		position := m.Vertices[m.Indices[corner]].position()
+		cornersAt[position] = append(cornersAt[position], corner)
+	}
+
+	threshold := float32(math.Cos(float64(angle)))
+	smooth := angle >= math.Pi
+
+	type split struct {
+		index  uint32
+		normal Vector
+	}
+	splits := map[split]uint32{}
+	vertices := make([]Vertex, 0, len(m.Vertices))
+	indices := make([]uint32, len(faceNormals)*3)
+
+	for corner := range indices {
+		index := m.Indices[corner]
+		face := faceNormals[corner/3]
+
+		// corners that see the same set of faces add them up in the same order, so their normals match exactly
+		var sum Vector
+		for _, other := range cornersAt[m.Vertices[index].position()] {
+			otherFace := faceNormals[other/3]
+			if smooth || otherFace.Dot(face) >= threshold {
+				sum = sum.AddVector(otherFace.MultiplyScalar(m.cornerAngle(other)))
+			}
+		}
+		normal := direction(sum)
+
+		key := split{index, normal}
+		vertexIndex, ok := splits[key]
+		if !ok {
+			vertex := m.Vertices[index]
+			vertex.NX, vertex.NY, vertex.NZ = normal.X, normal.Y, normal.Z
+			vertexIndex = uint32(len(vertices))
+			vertices = append(vertices, vertex)
+			splits[key] = vertexIndex
+		}
+		indices[corner] = vertexIndex
+	}
+
+	m.Vertices, m.Indices = vertices, indices
+}
+
+// cornerAngle returns the angle of a triangle at one of its corners
+func (m *Mesh) cornerAngle(corner int) float32 {
+	triangle := corner - corner%3
+	p := m.Vertices[m.Indices[corner]].position()
+	next := m.Vertices[m.Indices[triangle+(corner+1)%3]].position()
+	previous := m.Vertices[m.Indices[triangle+(corner+2)%3]].position()
+
+	e1 := direction(next.AddVector(p.Negative()))
+	e2 := direction(previous.AddVector(p.Negative()))
+	cos := e1.Dot(e2)
+	if cos > 1 {
+		cos = 1
+	} else if cos < -1 {
+		cos = -1
+	}
+
+	return float32(math.Acos(float64(cos)))
+}
+
+// GenerateTangents calculates tangents from the texture coordinates like MikkTSpace: the directions of increasing U of the triangles
+// around a vertex are projected onto the plane of its normal and averaged by the corner angles. The sign in TW tells whether
+// the direction of increasing V is the normal crossed with the tangent or the opposite.
+// Vertices shared by triangles with mirrored texture coordinates are split, since they need both signs.
+// The mesh needs normals, see GenerateSmoothNormals.
+func (m *Mesh) GenerateTangents() {
+	type split struct {
+		index uint32
+		sign  float32
+	}
+
+	// first work out the sign and the weighted tangent every corner contributes
+	corners := len(m.Indices) / 3 * 3
+	keys := make([]split, corners)
+	tangents := map[split]Vector{}
+	var order []split
+	for corner := 0; corner < corners; corner++ {
+		index := m.Indices[corner]
+		vertex := m.Vertices[index]
+		tangent, bitangent, ok := m.triangleTangent(corner / 3)
+
+		normal := vertex.Normal()
+		sign := float32(1)
+		if ok && normal.Cross(tangent).Dot(bitangent) < 0 {
+			sign = -1
+		}
+
+		key := split{index, sign}
+		keys[corner] = key
+		if _, found := tangents[key]; !found {
+			tangents[key] = Vector{}
+			order = append(order, key)
+		}
+		if ok {
+			weight := m.cornerAngle(corner)
+			tangents[key] = tangents[key].AddVector(direction(projectOnPlane(tangent, normal)).MultiplyScalar(weight))
+		}
+	}
+
+	// then build one vertex per index and sign
+	vertices := make([]Vertex, 0, len(order))
+	indices := map[split]uint32{}
+	for _, key := range order {
+		vertex := m.Vertices[key.index]
+		normal := vertex.Normal()
+
+		tangent := direction(projectOnPlane(tangents[key], normal))
+		if tangent == (Vector{}) {
+			// without usable texture coordinates any direction on the surface will do
+			tangent = anyPerpendicular(normal)
+		}
+
+		vertex.TX, vertex.TY, vertex.TZ, vertex.TW = tangent.X, tangent.Y, tangent.Z, key.sign
+		indices[key] = uint32(len(vertices))
+		vertices = append(vertices, vertex)
+	}
+
+	for corner, key := range keys {
+		m.Indices[corner] = indices[key]
+	}
+	m.Vertices = vertices
+}
+
+// triangleTangent returns the directions of increasing U and V across a triangle
+func (m *Mesh) triangleTangent(triangle int) (tangent, bitangent Vector, ok bool) {
+	a, b, c := m.Vertices[m.Indices[triangle*3]], m.Vertices[m.Indices[triangle*3+1]], m.Vertices[m.Indices[triangle*3+2]]
+
+	e1, e2 := b.position().AddVector(a.position().Negative()), c.position().AddVector(a.position().Negative())
+	du1, dv1 := b.U-a.U, b.V-a.V
+	du2, dv2 := c.U-a.U, c.V-a.V
+
+	area := du1*dv2 - du2*dv1
+	if area*area < tangentEpsilon {
+		return Vector{}, Vector{}, false
+	}
+
+	tangent = e1.MultiplyScalar(dv2).AddVector(e2.MultiplyScalar(-dv1)).MultiplyScalar(1 / area)
+	bitangent = e2.MultiplyScalar(du1).AddVector(e1.MultiplyScalar(-du2)).MultiplyScalar(1 / area)
+	return tangent, bitangent, true
+}
+
+// projectOnPlane removes the part of v along the unit normal
+func projectOnPlane(v, normal Vector) Vector {
+	return v.AddVector(normal.MultiplyScalar(-v.Dot(normal)))
+}
+
+// anyPerpendicular returns a unit vector perpendicular to the unit normal
+func anyPerpendicular(normal Vector) Vector {
+	axis := Vector{X: 1}
+	if normal.X*normal.X > 0.5 {
+		axis = Vector{Y: 1}
+	}
+
+	return direction(projectOnPlane(axis, normal))
+}
+
+// direction scales v to unit length. Unlike Normalize it keeps the short vectors of small triangles, only zero stays zero.
+func direction(v Vector) Vector {
+	length := math.Sqrt(float64(v.X)*float64(v.X) + float64(v.Y)*float64(v.Y) + float64(v.Z)*float64(v.Z))
+	if length == 0 {
+		return Vector{}
+	}
+
+	return Vector{X: float32(float64(v.X) / length), Y: float32(float64(v.Y) / length), Z: float32(float64(v.Z) / length)}
+}
+
+// GenerateFlatNormals replaces the normals of the model with face normals, see Mesh.GenerateFlatNormals
+func (m *Model) GenerateFlatNormals() error {
+	return m.editMesh((*Mesh).GenerateFlatNormals)
+}
+
+// GenerateSmoothNormals replaces the normals of the model with smooth ones, see Mesh.GenerateSmoothNormals
+func (m *Model) GenerateSmoothNormals(angle float32) error {
+	return m.editMesh(func(mesh *Mesh) {
+		mesh.GenerateSmoothNormals(angle)
+	})
+}
+
+// GenerateTangents adds tangents to the vertices of the model, see Mesh.GenerateTangents
+func (m *Model) GenerateTangents() error {
+	return m.editMesh((*Mesh).GenerateTangents)
+}
+
+// editMesh changes the geometry of the model and uploads the result
+func (m *Model) editMesh(edit func(mesh *Mesh)) error {
+	mesh := m.Mesh()
+	if mesh == nil {
+		return errors.New("model was not created from Vertex structs")
+	}
+
+	edit(mesh)
+	return m.UpdateMesh(mesh)
+}
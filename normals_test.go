@@ -0,0 +1,193 @@
+package opengl_exercise
+
+import (
+	"math"
+	"testing"
+)
+
+// withoutNormals clears the normals and tangents of a mesh
+func withoutNormals(mesh *Mesh) *Mesh {
+	for i := range mesh.Vertices {
+		vertex := &mesh.Vertices[i]
+		vertex.NX, vertex.NY, vertex.NZ = 0, 0, 0
+		vertex.TX, vertex.TY, vertex.TZ, vertex.TW = 0, 0, 0, 0
+	}
+	return mesh
+}
+
+func TestGenerateFlatNormals(t *testing.T) {
+	mesh := withoutNormals(NewIcosphereMesh(1, 1))
+	triangles := len(mesh.Indices) / 3
+	mesh.GenerateFlatNormals()
+
+	if len(mesh.Vertices) != triangles*3 || len(mesh.Indices) != triangles*3 {
+		t.Fatalf("expected %d unshared vertices, got %d vertices and %d indices", triangles*3, len(mesh.Vertices), len(mesh.Indices))
+	}
+
+	for i := 0; i < len(mesh.Indices); i += 3 {
+		a, b, c := mesh.Vertices[mesh.Indices[i]], mesh.Vertices[mesh.Indices[i+1]], mesh.Vertices[mesh.Indices[i+2]]
+		normal := a.Normal()
+		if normal != b.Normal() || normal != c.Normal() {
+			t.Fatalf("triangle %d has different normals", i/3)
+		}
+
+		// clockwise around the normal and pointing out of the sphere
+		face := b.position().AddVector(a.position().Negative()).Cross(c.position().AddVector(a.position().Negative()))
+		center := a.position().AddVector(b.position()).AddVector(c.position())
+		if face.Dot(normal) <= 0 || center.Dot(normal) <= 0 {
+			t.Fatalf("triangle %d normal %+v does not point outwards", i/3, normal)
+		}
+	}
+}
+
+func TestGenerateSmoothNormals(t *testing.T) {
+	t.Run("sphere", func(t *testing.T) {
+		mesh := withoutNormals(NewUVSphereMesh(2, 32, 16))
+		vertexCount := len(mesh.Vertices)
+		mesh.GenerateSmoothNormals(math.Pi / 3)
+
+		// the seam shares positions but keeps its vertices for the texture coordinates
+		if len(mesh.Vertices) > vertexCount {
+			t.Errorf("expected no more than %d vertices, got %d", vertexCount, len(mesh.Vertices))
+		}
+		for i, vertex := range mesh.Vertices {
+			// the averaged face normals lean a little off the exact normal, less than a degree
+			if expected := direction(vertex.position()); vertex.Normal().Dot(expected) < 0.9999 {
+				t.Fatalf("vertex %d: expected normal %+v, got %+v", i, expected, vertex.Normal())
+			}
+		}
+	})
+
+	cornerNormal := float32(1 / math.Sqrt(3))
+	sign := func(v float32) float32 {
+		if v < 0 {
+			return -cornerNormal
+		}
+		return cornerNormal
+	}
+
+	tests := []struct {
+		name     string
+		angle    float32
+		expected func(original, vertex Vertex) Vector
+	}{
+		// faces meet at right angles, so a smaller threshold keeps the cube faces flat
+		{"hard edges", math.Pi / 3, func(original, vertex Vertex) Vector {
+			return original.Normal()
+		}},
+		{"smooth corners", math.Pi, func(original, vertex Vertex) Vector {
+			return Vector{sign(vertex.X), sign(vertex.Y), sign(vertex.Z)}
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			original := NewCubeMesh(2, 1)
+			mesh := withoutNormals(NewCubeMesh(2, 1))
+			mesh.GenerateSmoothNormals(test.angle)
+
+			if len(mesh.Vertices) != len(original.Vertices) || len(mesh.Indices) != len(original.Indices) {
+				t.Fatalf("expected %d vertices, got %d", len(original.Vertices), len(mesh.Vertices))
+			}
+			for i, index := range mesh.Indices {
+				vertex := mesh.Vertices[index]
+				expected := test.expected(original.Vertices[original.Indices[i]], vertex)
+				if !vectorNearlyEqual(vertex.Normal(), expected) {
+					t.Fatalf("corner %d: expected normal %+v, got %+v", i, expected, vertex.Normal())
+				}
+			}
+		})
+	}
+
+	t.Run("split at hard edge", func(t *testing.T) {
+		// two triangles folded by 90 degrees along a shared edge
+		mesh := &Mesh{
+			Vertices: []Vertex{{X: 0, Y: 0, Z: 0}, {X: 0, Y: 1, Z: 0}, {X: 1, Y: 0, Z: 0}, {X: 0, Y: 0, Z: -1}},
+			Indices:  []uint32{0, 1, 2, 0, 3, 1},
+		}
+
+		smooth := &Mesh{Vertices: append([]Vertex(nil), mesh.Vertices...), Indices: append([]uint32(nil), mesh.Indices...)}
+		smooth.GenerateSmoothNormals(math.Pi / 2 * 1.1)
+		if len(smooth.Vertices) != 4 {
+			t.Errorf("expected the shared edge to stay shared below the threshold, got %d vertices", len(smooth.Vertices))
+		}
+
+		mesh.GenerateSmoothNormals(math.Pi / 4)
+		if len(mesh.Vertices) != 6 {
+			t.Fatalf("expected the two vertices on the edge to be split, got %d vertices", len(mesh.Vertices))
+		}
+		if first, second := mesh.Vertices[mesh.Indices[0]].Normal(), mesh.Vertices[mesh.Indices[3]].Normal(); first != (Vector{0, 0, -1}) || second != (Vector{1, 0, 0}) {
+			t.Errorf("expected the face normals (0, 0, -1) and (1, 0, 0), got %+v and %+v", first, second)
+		}
+	})
+}
+
+func TestGenerateTangents(t *testing.T) {
+	t.Run("plane", func(t *testing.T) {
+		// u runs along +x and v along +z on a plane facing up
+		mesh := NewPlaneMesh(2, 2, 2, 2)
+		vertexCount := len(mesh.Vertices)
+		mesh.GenerateTangents()
+
+		if len(mesh.Vertices) != vertexCount {
+			t.Fatalf("expected %d vertices, got %d", vertexCount, len(mesh.Vertices))
+		}
+		for i, vertex := range mesh.Vertices {
+			if !vectorNearlyEqual(vertex.Tangent(), Vector{X: 1}) || !vectorNearlyEqual(vertex.Bitangent(), Vector{Z: 1}) {
+				t.Fatalf("vertex %d: expected tangent (1, 0, 0) and bitangent (0, 0, 1), got %+v and %+v", i, vertex.Tangent(), vertex.Bitangent())
+			}
+		}
+	})
+
+	t.Run("mirrored", func(t *testing.T) {
+		// the right half repeats the texture mirrored, the vertices on the middle edge need both signs
+		mesh := &Mesh{
+			Vertices: []Vertex{
+				{X: -1, Z: 1, NY: 1, U: 0, V: 1}, {X: 0, Z: 1, NY: 1, U: 1, V: 1}, {X: 1, Z: 1, NY: 1, U: 0, V: 1},
+				{X: -1, Z: 0, NY: 1, U: 0, V: 0}, {X: 0, Z: 0, NY: 1, U: 1, V: 0}, {X: 1, Z: 0, NY: 1, U: 0, V: 0},
+			},
+			Indices: []uint32{0, 1, 3, 1, 4, 3, 1, 2, 4, 2, 5, 4},
+		}
+		mesh.GenerateTangents()
+
+		if len(mesh.Vertices) != 8 {
+			t.Fatalf("expected the two middle vertices to be split, got %d vertices", len(mesh.Vertices))
+		}
+		for i, index := range mesh.Indices {
+			vertex := mesh.Vertices[index]
+			expected := Vector{X: 1}
+			if i >= 6 {
+				expected = Vector{X: -1}
+			}
+			if !vectorNearlyEqual(vertex.Tangent(), expected) || !vectorNearlyEqual(vertex.Bitangent(), Vector{Z: 1}) {
+				t.Fatalf("corner %d: expected tangent %+v and bitangent (0, 0, 1), got %+v and %+v", i, expected, vertex.Tangent(), vertex.Bitangent())
+			}
+		}
+	})
+
+	t.Run("sphere", func(t *testing.T) {
+		mesh := NewUVSphereMesh(1, 32, 16)
+		mesh.GenerateTangents()
+
+		for i, vertex := range mesh.Vertices {
+			tangent := vertex.Tangent()
+			if length := tangent.Length(); length < 0.999 || length > 1.001 {
+				t.Fatalf("vertex %d: tangent %+v is not unit length", i, tangent)
+			}
+			if dot := tangent.Dot(vertex.Normal()); dot > 1e-4 || dot < -1e-4 {
+				t.Fatalf("vertex %d: tangent %+v is not perpendicular to the normal %+v", i, tangent, vertex.Normal())
+			}
+			if vertex.TW != 1 && vertex.TW != -1 {
+				t.Fatalf("vertex %d: invalid sign %v", i, vertex.TW)
+			}
+
+			// away from the poles u runs around the y axis
+			if vertex.Y > -0.9 && vertex.Y < 0.9 {
+				around := direction(Vector{X: -vertex.Z, Z: vertex.X})
+				if tangent.Dot(around) < 0.995 {
+					t.Fatalf("vertex %d: expected tangent %+v, got %+v", i, around, tangent)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,453 @@
+package opengl_exercise
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LoadOBJ reads a Wavefront OBJ file together with the MTL material libraries it refers to.
+// Material libraries are looked up next to the OBJ file.
+func LoadOBJ(path string) (*Mesh, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	dir := filepath.Dir(path)
+	mesh, err := ReadOBJ(file, func(name string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join(dir, filepath.FromSlash(name)))
+	})
+
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) && parseErr.File == "" {
+		parseErr.File = path
+	}
+
+	return mesh, err
+}
+
+// ReadOBJ reads a Wavefront OBJ file. openLibrary opens the material libraries named by mtllib statements,
+// when it is nil the materials are skipped.
+//
+// OBJ files are right-handed, so z is negated to bring them into the left-handed space used here.
+// Front faces are counter clockwise in OBJ, the corners are swapped to match the clockwise front faces set up in InitializeOpenGL.
+// Polygons with more than three corners are split into a fan of triangles, which assumes they are convex.
+func ReadOBJ(r io.Reader, openLibrary func(name string) (io.ReadCloser, error)) (*Mesh, error) {
+	reader := &objReader{
+		mesh:        &Mesh{},
+		materials:   map[string]*Material{},
+		vertexIndex: map[objVertexKey]uint32{},
+		openLibrary: openLibrary,
+	}
+
+	if err := scanStatements(r, reader.statement); err != nil {
+		return nil, err
+	}
+	reader.endGroup()
+
+	return reader.mesh, nil
+}
+
+// objVertexKey identifies a unique combination of position, texture coordinate and normal
+type objVertexKey struct {
+	position, texcoord, normal int
+	material                   *Material
+}
+
+type objReader struct {
+	positions []Vector
+	colors    []*Vector
+	texcoords [][2]float32
+	normals   []Vector
+
+	mesh        *Mesh
+	materials   map[string]*Material
+	vertexIndex map[objVertexKey]uint32
+	openLibrary func(name string) (io.ReadCloser, error)
+
+	// the group currently being filled
+	groupName  string
+	material   *Material
+	groupStart int
+}
+
+func (o *objReader) statement(keyword string, args []string) error {
+	switch keyword {
+	case "v":
+		// x y z with an optional w, or x y z r g b for files with vertex colors
+		if len(args) != 3 && len(args) != 4 && len(args) != 6 {
+			return fmt.Errorf("vertex needs 3, 4 or 6 values, got %d", len(args))
+		}
+		values, err := parseFloats(args)
+		if err != nil {
+			return err
+		}
+
+		o.positions = append(o.positions, Vector{X: values[0], Y: values[1], Z: -values[2]})
+		if len(values) == 6 {
+			o.colors = append(o.colors, &Vector{X: values[3], Y: values[4], Z: values[5]})
+		} else {
+			o.colors = append(o.colors, nil)
+		}
+
+	case "vt":
+		if len(args) < 1 || len(args) > 3 {
+			return fmt.Errorf("texture coordinate needs 1 to 3 values, got %d", len(args))
+		}
+		values, err := parseFloats(args)
+		if err != nil {
+			return err
+		}
+
+		var texcoord [2]float32
+		copy(texcoord[:], values)
+		o.texcoords = append(o.texcoords, texcoord)
+
+	case "vn":
+		if len(args) != 3 {
+			return fmt.Errorf("normal needs 3 values, got %d", len(args))
+		}
+		values, err := parseFloats(args)
+		if err != nil {
+			return err
+		}
+
+		o.normals = append(o.normals, Vector{X: values[0], Y: values[1], Z: -values[2]}.Normalize())
+
+	case "f":
+		return o.face(args)
+
+	case "g", "o":
+		o.endGroup()
+		o.groupName = strings.Join(args, " ")
+
+	case "usemtl":
+		if len(args) != 1 {
+			return errors.New("usemtl needs a material name")
+		}
+		material, ok := o.materials[args[0]]
+		if !ok {
+			// keep going with a default material when no library defines it
+			material = NewMaterial(args[0])
+			o.materials[args[0]] = material
+		}
+
+		if material != o.material {
+			o.endGroup()
+			o.material = material
+		}
+
+	case "mtllib":
+		if len(args) == 0 {
+			return errors.New("mtllib needs a file name")
+		}
+		return o.loadLibraries(args)
+
+	case "s", "l", "p", "vp", "cstype", "deg", "curv", "surf", "parm", "end":
+		// smoothing groups, lines, points and free-form geometry are not used
+	}
+
+	return nil
+}
+
+func (o *objReader) face(args []string) error {
+	if len(args) < 3 {
+		return fmt.Errorf("face needs at least 3 vertices, got %d", len(args))
+	}
+
+	corners := make([]uint32, len(args))
+	for i, arg := range args {
+		index, err := o.vertex(arg)
+		if err != nil {
+			return err
+		}
+		corners[i] = index
+	}
+
+	// split the polygon into a fan of clockwise triangles around the first corner
+	for i := 1; i+1 < len(corners); i++ {
+		o.mesh.Indices = append(o.mesh.Indices, corners[0], corners[i+1], corners[i])
+	}
+
+	return nil
+}
+
+// vertex returns the index of a face corner given as v, v/vt, v//vn or v/vt/vn, adding the vertex when it is new
+func (o *objReader) vertex(corner string) (uint32, error) {
+	parts := strings.Split(corner, "/")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid face vertex '%s'", corner)
+	}
+
+	key := objVertexKey{position: -1, texcoord: -1, normal: -1, material: o.material}
+
+	var err error
+	if key.position, err = objIndex(parts[0], len(o.positions)); err != nil {
+		return 0, err
+	}
+	if len(parts) > 1 && parts[1] != "" {
+		if key.texcoord, err = objIndex(parts[1], len(o.texcoords)); err != nil {
+			return 0, err
+		}
+	}
+	if len(parts) > 2 && parts[2] != "" {
+		if key.normal, err = objIndex(parts[2], len(o.normals)); err != nil {
+			return 0, err
+		}
+	}
+
+	if index, ok := o.vertexIndex[key]; ok {
+		return index, nil
+	}
+
+	position := o.positions[key.position]
+	vertex := Vertex{X: position.X, Y: position.Y, Z: position.Z, R: 1, G: 1, B: 1}
+
+	// vertex colors win over the material color
+	if color := o.colors[key.position]; color != nil {
+		vertex.R, vertex.G, vertex.B = color.X, color.Y, color.Z
+	} else if o.material != nil {
+		vertex.R, vertex.G, vertex.B = o.material.Diffuse.X, o.material.Diffuse.Y, o.material.Diffuse.Z
+	}
+
+	if key.texcoord >= 0 {
+		vertex.U, vertex.V = o.texcoords[key.texcoord][0], o.texcoords[key.texcoord][1]
+	}
+	if key.normal >= 0 {
+		normal := o.normals[key.normal]
+		vertex.NX, vertex.NY, vertex.NZ = normal.X, normal.Y, normal.Z
+	}
+
+	index := uint32(len(o.mesh.Vertices))
+	o.mesh.Vertices = append(o.mesh.Vertices, vertex)
+	o.vertexIndex[key] = index
+
+	return index, nil
+}
+
+// objIndex turns a one based or negative relative OBJ index into a zero based one
+func objIndex(text string, count int) (int, error) {
+	index, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid index '%s'", text)
+	}
+
+	switch {
+	case index > 0 && index <= count:
+		return index - 1, nil
+	case index < 0 && -index <= count:
+		return count + index, nil
+	default:
+		return 0, fmt.Errorf("index %d out of range, %d defined so far", index, count)
+	}
+}
+
+// endGroup closes the group of the indices added since the last group change
+func (o *objReader) endGroup() {
+	count := len(o.mesh.Indices) - o.groupStart
+	if count > 0 {
+		o.mesh.Groups = append(o.mesh.Groups, MeshGroup{
+			Name:     o.groupName,
+			Material: o.material,
+			Start:    o.groupStart,
+			Count:    count,
+		})
+	}
+
+	o.groupStart = len(o.mesh.Indices)
+}
+
+func (o *objReader) loadLibraries(names []string) error {
+	if o.openLibrary == nil {
+		return nil
+	}
+
+	for _, name := range names {
+		file, err := o.openLibrary(name)
+		if err != nil {
+			return err
+		}
+
+		materials, err := ReadMTL(file)
+		file.Close()
+		if err != nil {
+			var parseErr *ParseError
+			if errors.As(err, &parseErr) {
+				parseErr.File = name
+			}
+			return err
+		}
+
+		for name, material := range materials {
+			o.materials[name] = material
+		}
+	}
+
+	return nil
+}
+
+// ReadMTL reads a Wavefront material library and returns the materials by name
+func ReadMTL(r io.Reader) (map[string]*Material, error) {
+	materials := map[string]*Material{}
+
+	var current *Material
+	err := scanStatements(r, func(keyword string, args []string) error {
+		if keyword == "newmtl" {
+			if len(args) != 1 {
+				return errors.New("newmtl needs a material name")
+			}
+			current = NewMaterial(args[0])
+			materials[args[0]] = current
+			return nil
+		}
+
+		if current == nil {
+			return fmt.Errorf("'%s' before the first newmtl", keyword)
+		}
+
+		switch keyword {
+		case "Ka":
+			return parseColor(args, &current.Ambient)
+		case "Kd":
+			return parseColor(args, &current.Diffuse)
+		case "Ks":
+			return parseColor(args, &current.Specular)
+		case "Ke":
+			return parseColor(args, &current.Emissive)
+		case "Ns":
+			return parseFloat(args, &current.Shininess)
+		case "d":
+			return parseFloat(args, &current.Opacity)
+		case "Tr":
+			var transparency float32
+			if err := parseFloat(args, &transparency); err != nil {
+				return err
+			}
+			current.Opacity = 1 - transparency
+		case "map_Ka":
+			return parseTexture(args, &current.AmbientTexture)
+		case "map_Kd":
+			return parseTexture(args, &current.DiffuseTexture)
+		case "map_Ks":
+			return parseTexture(args, &current.SpecularTexture)
+		case "map_Bump", "map_bump", "bump", "norm":
+			return parseTexture(args, &current.NormalTexture)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return materials, nil
+}
+
+// parseColor reads an RGB color, a single value is used for all three channels
+func parseColor(args []string, color *Vector) error {
+	if len(args) != 1 && len(args) != 3 {
+		return fmt.Errorf("color needs 1 or 3 values, got %d", len(args))
+	}
+
+	values, err := parseFloats(args)
+	if err != nil {
+		return err
+	}
+
+	if len(values) == 1 {
+		*color = Vector{X: values[0], Y: values[0], Z: values[0]}
+	} else {
+		*color = Vector{X: values[0], Y: values[1], Z: values[2]}
+	}
+
+	return nil
+}
+
+func parseFloat(args []string, value *float32) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected 1 value, got %d", len(args))
+	}
+
+	values, err := parseFloats(args)
+	if err != nil {
+		return err
+	}
+
+	*value = values[0]
+	return nil
+}
+
+// parseTexture reads the file name of a texture map, which comes after the options
+func parseTexture(args []string, path *string) error {
+	if len(args) == 0 {
+		return errors.New("texture map needs a file name")
+	}
+
+	*path = args[len(args)-1]
+	return nil
+}
+
+func parseFloats(args []string) ([]float32, error) {
+	values := make([]float32, len(args))
+	for i, arg := range args {
+		value, err := strconv.ParseFloat(arg, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s'", arg)
+		}
+		values[i] = float32(value)
+	}
+
+	return values, nil
+}
+
+// scanStatements splits a line based text format like OBJ or MTL into a keyword and its arguments.
+// Comments and empty lines are skipped and lines ending in a backslash continue on the next line.
+// Errors returned by fn are wrapped in a ParseError with the line number, unless they are one already.
+func scanStatements(r io.Reader, fn func(keyword string, args []string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		start := lineNumber
+
+		// join continued lines
+		for strings.HasSuffix(line, "\\") && scanner.Scan() {
+			lineNumber++
+			line = line[:len(line)-1] + " " + scanner.Text()
+		}
+
+		if comment := strings.IndexByte(line, '#'); comment >= 0 {
+			line = line[:comment]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if err := fn(fields[0], fields[1:]); err != nil {
+			// errors in included files like material libraries already point to their own line
+			var parseErr *ParseError
+			if errors.As(err, &parseErr) {
+				return err
+			}
+			return &ParseError{Line: start, Err: err}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return &ParseError{Line: lineNumber + 1, Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,275 @@
+package opengl_exercise
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReadOBJ(t *testing.T) {
+	// a counter clockwise square facing +z in the right-handed space of the file
+	const square = `v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+vn 0 0 1
+vt 0 0
+vt 1 0
+vt 1 1
+vt 0 1
+`
+
+	tests := []struct {
+		name      string
+		data      string
+		vertices  int
+		triangles int
+	}{
+		{"triangle", square + "f 1 2 3\n", 3, 1},
+		{"quad as fan", square + "f 1/1/1 2/2/1 3/3/1 4/4/1\n", 4, 2},
+		{"negative indices", square + "f -4/-4/-1 -3/-3/-1 -2/-2/-1 -1/-1/-1\n", 4, 2},
+		{"shared corners", square + "f 1//1 2//1 3//1\nf 1//1 3//1 4//1\n", 4, 2},
+		{"continued line", square + "f 1 2 \\\n3 4\n", 4, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mesh, err := ReadOBJ(strings.NewReader(test.data), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(mesh.Vertices) != test.vertices || len(mesh.Indices) != test.triangles*3 {
+				t.Fatalf("expected %d vertices and %d triangles, got %d and %d", test.vertices, test.triangles, len(mesh.Vertices), len(mesh.Indices)/3)
+			}
+
+			// mirroring z turns the square to face -z, the triangles have to be clockwise around that
+			for i := 0; i < len(mesh.Indices); i += 3 {
+				a, b, c := mesh.Vertices[mesh.Indices[i]].position(), mesh.Vertices[mesh.Indices[i+1]].position(), mesh.Vertices[mesh.Indices[i+2]].position()
+				if normal := triangleNormal(a, b, c); !vectorNearlyEqual(normal, Vector{Z: -1}) {
+					t.Errorf("triangle %d: expected the face normal (0, 0, -1), got %+v", i/3, normal)
+				}
+			}
+			for i, vertex := range mesh.Vertices {
+				if vertex.NZ != 0 && vertex.Normal() != (Vector{Z: -1}) {
+					t.Errorf("vertex %d: expected the normal (0, 0, -1), got %+v", i, vertex.Normal())
+				}
+			}
+		})
+	}
+}
+
+func TestReadOBJVertices(t *testing.T) {
+	const data = `v 0 0 0 1 0 0
+v 1 0 -2
+v 0 1 0
+vt 0.25 0.75
+vn 0 1 0
+vn 1 0 0
+f 1/1/1 2/1/1 3/1/1
+f 1/1/2 3/1/2 2/1/2
+`
+	mesh, err := ReadOBJ(strings.NewReader(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the corners share positions, but the second triangle has another normal so they are new vertices
+	if len(mesh.Vertices) != 6 {
+		t.Fatalf("expected 6 vertices, got %d", len(mesh.Vertices))
+	}
+
+	first := mesh.Vertices[mesh.Indices[0]]
+	if expected := (Vertex{R: 1, NY: 1, U: 0.25, V: 0.75}); first != expected {
+		t.Errorf("expected %+v, got %+v", expected, first)
+	}
+	if second := mesh.Vertices[1]; second.Z != 2 || second.R != 1 || second.G != 1 || second.B != 1 {
+		t.Errorf("expected z mirrored to 2 and a white vertex, got %+v", second)
+	}
+}
+
+func TestReadOBJGroups(t *testing.T) {
+	const library = `newmtl red
+Kd 1 0 0
+newmtl green
+Kd 0 1 0
+`
+	const data = `mtllib colors.mtl
+v 0 0 0
+v 1 0 0
+v 0 1 0
+g first
+usemtl red
+f 1 2 3
+f 1 2 3
+usemtl green
+f 1 2 3
+g second
+f 1 2 3
+usemtl missing
+f 1 2 3
+`
+	var opened []string
+	mesh, err := ReadOBJ(strings.NewReader(data), func(name string) (io.ReadCloser, error) {
+		opened = append(opened, name)
+		return ioutil.NopCloser(strings.NewReader(library)), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opened) != 1 || opened[0] != "colors.mtl" {
+		t.Errorf("expected colors.mtl to be opened, got %v", opened)
+	}
+
+	expected := []struct {
+		name, material string
+		start, count   int
+	}{
+		{"first", "red", 0, 6},
+		{"first", "green", 6, 3},
+		{"second", "green", 9, 3},
+		{"second", "missing", 12, 3},
+	}
+	if len(mesh.Groups) != len(expected) {
+		t.Fatalf("expected %d groups, got %+v", len(expected), mesh.Groups)
+	}
+	for i, group := range mesh.Groups {
+		want := expected[i]
+		if group.Name != want.name || group.Material == nil || group.Material.Name != want.material || group.Start != want.start || group.Count != want.count {
+			t.Errorf("group %d: expected %+v, got %+v", i, want, group)
+		}
+	}
+
+	// the vertices take the diffuse color of their material, the same position is a new vertex in every material
+	if len(mesh.Vertices) != 9 {
+		t.Errorf("expected 9 vertices, got %d", len(mesh.Vertices))
+	}
+	for i, group := range mesh.Groups {
+		vertex := mesh.Vertices[mesh.Indices[group.Start]]
+		if color := (Vector{vertex.R, vertex.G, vertex.B}); color != group.Material.Diffuse {
+			t.Errorf("group %d: expected color %+v, got %+v", i, group.Material.Diffuse, color)
+		}
+	}
+}
+
+func TestReadOBJInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		library string
+		line    int
+		error   string
+	}{
+		{"short vertex", "v 1 2\n", "", 1, "needs 3, 4 or 6 values"},
+		{"invalid number", "v 0 0 0\nv 1 x 0\n", "", 2, "invalid number"},
+		{"short normal", "vn 0 1\n", "", 1, "needs 3 values"},
+		{"two corners", "v 0 0 0\nv 1 0 0\nf 1 2\n", "", 3, "at least 3 vertices"},
+		{"index out of range", "v 0 0 0\nv 1 0 0\nv 0 1 0\n\n# comment\nf 1 2 4\n", "", 6, "out of range"},
+		{"zero index", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 0 1 2\n", "", 4, "out of range"},
+		{"texcoord out of range", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1/1 2/1 3/1\n", "", 4, "out of range"},
+		{"invalid index", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 a 3\n", "", 4, "invalid index"},
+		{"too many slashes", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1/1/1/1 2 3\n", "", 4, "invalid face vertex"},
+		{"continued line", "v 0 0 0\nv 1 0 \\\n0\nv 0 1\n", "", 4, "needs 3, 4 or 6 values"},
+		{"bad library", "mtllib bad.mtl\n", "newmtl a\nKd 1 x 0\n", 2, "bad.mtl:2: invalid number"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ReadOBJ(strings.NewReader(test.data), func(name string) (io.ReadCloser, error) {
+				return ioutil.NopCloser(strings.NewReader(test.library)), nil
+			})
+			if err == nil || !strings.Contains(err.Error(), test.error) {
+				t.Fatalf("expected error containing %q, got %v", test.error, err)
+			}
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) || parseErr.Line != test.line {
+				t.Errorf("expected the error on line %d, got %v", test.line, err)
+			}
+		})
+	}
+
+	t.Run("missing library", func(t *testing.T) {
+		_, err := ReadOBJ(strings.NewReader("mtllib missing.mtl\n"), func(name string) (io.ReadCloser, error) {
+			return nil, os.ErrNotExist
+		})
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("expected the open error, got %v", err)
+		}
+	})
+}
+
+func TestReadMTL(t *testing.T) {
+	const data = `# two materials
+newmtl plain
+Kd 0.5
+
+newmtl shiny
+Ka 0.1 0.2 0.3
+Kd 1 0 0
+Ks 1 1 1
+Ke 0 0 0.5
+Ns 32
+Tr 0.25
+map_Kd -s 1 1 1 textures/diffuse.png
+map_Bump normal.png
+`
+	materials, err := ReadMTL(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(materials) != 2 {
+		t.Fatalf("expected 2 materials, got %d", len(materials))
+	}
+
+	plain := materials["plain"]
+	if plain == nil || plain.Diffuse != (Vector{0.5, 0.5, 0.5}) || plain.Opacity != 1 {
+		t.Errorf("unexpected plain material %+v", plain)
+	}
+
+	shiny := materials["shiny"]
+	if shiny == nil {
+		t.Fatal("material shiny is missing")
+	}
+	if shiny.Ambient != (Vector{0.1, 0.2, 0.3}) || shiny.Diffuse != (Vector{1, 0, 0}) || shiny.Specular != (Vector{1, 1, 1}) || shiny.Emissive != (Vector{0, 0, 0.5}) {
+		t.Errorf("unexpected colors %+v", shiny)
+	}
+	if shiny.Shininess != 32 || shiny.Opacity != 0.75 {
+		t.Errorf("expected shininess 32 and opacity 0.75, got %v and %v", shiny.Shininess, shiny.Opacity)
+	}
+	if shiny.DiffuseTexture != "textures/diffuse.png" || shiny.NormalTexture != "normal.png" {
+		t.Errorf("unexpected textures %q and %q", shiny.DiffuseTexture, shiny.NormalTexture)
+	}
+}
+
+func TestReadMTLInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		line  int
+		error string
+	}{
+		{"before newmtl", "# comment\nKd 1 1 1\n", 2, "before the first newmtl"},
+		{"unnamed", "newmtl\n", 1, "needs a material name"},
+		{"two values", "newmtl a\nKd 1 1\n", 2, "1 or 3 values"},
+		{"invalid number", "newmtl a\nNs high\n", 2, "invalid number"},
+		{"no texture", "newmtl a\nmap_Kd\n", 2, "needs a file name"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ReadMTL(strings.NewReader(test.data))
+			if err == nil || !strings.Contains(err.Error(), test.error) {
+				t.Fatalf("expected error containing %q, got %v", test.error, err)
+			}
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) || parseErr.Line != test.line {
+				t.Errorf("expected the error on line %d, got %v", test.line, err)
+			}
+		})
+	}
+}
@@ -39,6 +39,21 @@ func (m1 *Matrix) Multiply(m2 *Matrix) Matrix {
 	}
 }
 
+// TransformHomogeneous transforms a homogeneous coordinate by the matrix, keeping the resulting w
+func (m *Matrix) TransformHomogeneous(v Vector4) Vector4 {
+	return v.MultiplyMatrix(m)
+}
+
+// TransformPoint transforms a position with w=1 and applies the perspective divide to the result
+func (m *Matrix) TransformPoint(v Vector) Vector {
+	return v.Vector4(1).MultiplyMatrix(m).PerspectiveDivide()
+}
+
+// TransformDirection transforms a direction with w=0, so it is not affected by translation
+func (m *Matrix) TransformDirection(v Vector) Vector {
+	return v.Vector4(0).MultiplyMatrix(m).Vector()
+}
+
 func (m *Matrix) Print(f io.Writer) {
 	fmt.Fprintf(f, "%.2f %.2f %.2f %.2f\n", m[0], m[1], m[2], m[3])
 	fmt.Fprintf(f, "%.2f %.2f %.2f %.2f\n", m[4], m[5], m[6], m[7])
@@ -50,11 +65,147 @@ func (m *Matrix) Ptr() *float32 {
 	return &(*m)[0]
 }
 
+// ErrSingularMatrix is returned when a matrix has no inverse
+var ErrSingularMatrix = errors.New("matrix is singular")
+
+func (m *Matrix) Transpose() Matrix {
+	return Matrix{
+		m[0], m[4], m[8], m[12],
+		m[1], m[5], m[9], m[13],
+		m[2], m[6], m[10], m[14],
+		m[3], m[7], m[11], m[15],
+	}
+}
+
+func (m *Matrix) Determinant() float32 {
+	// expand along the first row using the 2x2 minors of the bottom two rows
+	s0 := m[8]*m[13] - m[9]*m[12]
+	s1 := m[8]*m[14] - m[10]*m[12]
+	s2 := m[8]*m[15] - m[11]*m[12]
+	s3 := m[9]*m[14] - m[10]*m[13]
+	s4 := m[9]*m[15] - m[11]*m[13]
+	s5 := m[10]*m[15] - m[11]*m[14]
+
+	return m[0]*(m[5]*s5-m[6]*s4+m[7]*s3) -
+		m[1]*(m[4]*s5-m[6]*s2+m[7]*s1) +
+		m[2]*(m[4]*s4-m[5]*s2+m[7]*s0) -
+		m[3]*(m[4]*s3-m[5]*s1+m[6]*s0)
+}
+
+// Inverse returns the inverse of the matrix, or ErrSingularMatrix if the matrix cannot be inverted
+func (m *Matrix) Inverse() (Matrix, error) {
+	// work in double precision, the cofactors of a projection matrix lose a lot of bits in float32
+	var a [16]float64
+	for i := range m {
+		a[i] = float64(m[i])
+	}
+
+	// 2x2 minors of the top and bottom two rows
+	s0 := a[0]*a[5] - a[4]*a[1]
+	s1 := a[0]*a[6] - a[4]*a[2]
+	s2 := a[0]*a[7] - a[4]*a[3]
+	s3 := a[1]*a[6] - a[5]*a[2]
+	s4 := a[1]*a[7] - a[5]*a[3]
+	s5 := a[2]*a[7] - a[6]*a[3]
+
+	c5 := a[10]*a[15] - a[14]*a[11]
+	c4 := a[9]*a[15] - a[13]*a[11]
+	c3 := a[9]*a[14] - a[13]*a[10]
+	c2 := a[8]*a[15] - a[12]*a[11]
+	c1 := a[8]*a[14] - a[12]*a[10]
+	c0 := a[8]*a[13] - a[12]*a[9]
+
+	det := s0*c5 - s1*c4 + s2*c3 + s3*c2 - s4*c1 + s5*c0
+
+	// compare against the largest determinant rows of this length can have, so small but well shaped matrices
+	// such as a uniform scale of 1e-4 are still inverted while nearly dependent rows are rejected at any scale
+	bound := 1.0
+	for row := 0; row < 16; row += 4 {
+		bound *= math.Sqrt(a[row]*a[row] + a[row+1]*a[row+1] + a[row+2]*a[row+2] + a[row+3]*a[row+3])
+	}
+	if bound == 0 || math.Abs(det) < 1e-10*bound {
+		return Matrix{}, ErrSingularMatrix
+	}
+
+	invDet := 1 / det
+	inverse := [16]float64{
+		(a[5]*c5 - a[6]*c4 + a[7]*c3) * invDet,
+		(-a[1]*c5 + a[2]*c4 - a[3]*c3) * invDet,
+		(a[13]*s5 - a[14]*s4 + a[15]*s3) * invDet,
+		(-a[9]*s5 + a[10]*s4 - a[11]*s3) * invDet,
+
+		(-a[4]*c5 + a[6]*c2 - a[7]*c1) * invDet,
+		(a[0]*c5 - a[2]*c2 + a[3]*c1) * invDet,
+		(-a[12]*s5 + a[14]*s2 - a[15]*s1) * invDet,
+		(a[8]*s5 - a[10]*s2 + a[11]*s1) * invDet,
+
+		(a[4]*c4 - a[5]*c2 + a[7]*c0) * invDet,
+		(-a[0]*c4 + a[1]*c2 - a[3]*c0) * invDet,
+		(a[12]*s4 - a[13]*s2 + a[15]*s0) * invDet,
+		(-a[8]*s4 + a[9]*s2 - a[11]*s0) * invDet,
+
+		(-a[4]*c3 + a[5]*c1 - a[6]*c0) * invDet,
+		(a[0]*c3 - a[1]*c1 + a[2]*c0) * invDet,
+		(-a[12]*s3 + a[13]*s1 - a[14]*s0) * invDet,
+		(a[8]*s3 - a[9]*s1 + a[10]*s0) * invDet,
+	}
+
+	var result Matrix
+	for i := range inverse {
+		result[i] = float32(inverse[i])
+	}
+
+	return result, nil
+}
+
+// Decompose splits an affine matrix into the scale, rotation and translation that rebuild it in that order.
+// It returns ErrSingularMatrix if one of the axes has been scaled down to zero.
+func (m *Matrix) Decompose() (translation Vector, rotation Quaternion, scale Vector, err error) {
+	translation = Vector{X: m[12], Y: m[13], Z: m[14]}
+
+	// each of the first three rows is a transformed basis axis, its length is the scale along that axis
+	xAxis := Vector{X: m[0], Y: m[1], Z: m[2]}
+	yAxis := Vector{X: m[4], Y: m[5], Z: m[6]}
+	zAxis := Vector{X: m[8], Y: m[9], Z: m[10]}
+
+	scale = Vector{
+		X: float32(math.Sqrt(float64(xAxis.Dot(xAxis)))),
+		Y: float32(math.Sqrt(float64(yAxis.Dot(yAxis)))),
+		Z: float32(math.Sqrt(float64(zAxis.Dot(zAxis)))),
+	}
+
+	if scale.X < 1e-6 || scale.Y < 1e-6 || scale.Z < 1e-6 {
+		return translation, IdentityQuaternion(), scale, ErrSingularMatrix
+	}
+
+	// a mirrored matrix can't be represented by a rotation, so move the reflection into the scale
+	if xAxis.Cross(yAxis).Dot(zAxis) < 0 {
+		scale.X = -scale.X
+	}
+
+	// remove the scale to leave a pure rotation matrix
+	rotationMatrix := Matrix{
+		m[0] / scale.X, m[1] / scale.X, m[2] / scale.X, 0,
+		m[4] / scale.Y, m[5] / scale.Y, m[6] / scale.Y, 0,
+		m[8] / scale.Z, m[9] / scale.Z, m[10] / scale.Z, 0,
+		0, 0, 0, 1,
+	}
+
+	return translation, QuaternionFromMatrix(&rotationMatrix), scale, nil
+}
+
 type OpenGL struct {
 	renderingContext w32.HGLRC
 	deviceContext    w32.HDC
-	worldMatrix      Matrix
-	projectionMatrix Matrix
+	reversedZ        bool
+	screenWidth      int
+	screenHeight     int
+	screenNear       float32
+	screenDepth      float32
+	fov              float32
+
+	// the window rectangle currently rendered to, as x, y, width and height in pixels from the bottom left corner
+	viewport [4]int32
 
 	videoCardDesc string
 }
@@ -104,7 +255,7 @@ func (o *OpenGL) InitializeExtensions(hwnd w32.HWND) error {
 	return nil
 }
 
-func (o *OpenGL) InitializeOpenGL(hwnd w32.HWND, screenWidth, screenHeight int, screenDepth, screenNear float32, vsync bool) error {
+func (o *OpenGL) InitializeOpenGL(hwnd w32.HWND, screenWidth, screenHeight int, fov, screenDepth, screenNear float32, vsync bool) error {
 
 	// get device context for this window
 	o.deviceContext = w32.GetDC(hwnd)
@@ -179,15 +330,17 @@ func (o *OpenGL) InitializeOpenGL(hwnd w32.HWND, screenWidth, screenHeight int,
 	gl.Enable(gl.CULL_FACE)
 	gl.CullFace(gl.BACK)
 
-	// initialize the world/model matrix to the identity matrix
-	o.worldMatrix = o.identityMatrix()
+	// remember the screen size for mapping to window coordinates
+	o.screenWidth, o.screenHeight = screenWidth, screenHeight
+	o.viewport = [4]int32{0, 0, int32(screenWidth), int32(screenHeight)}
 
 	// set the field of view and screen aspect ratio
-	fov := float32(math.Pi / 4.0)
-	screenAspect := float32(screenWidth) / float32(screenHeight)
+	if fov <= 0 {
+		fov = math.Pi / 4.0
+	}
 
-	// build the perspective projection matrix
-	o.projectionMatrix = o.perspectiveFovLHMatrix(fov, screenAspect, screenNear, screenDepth)
+	// remember the projection settings so cameras can start with the same lens
+	o.fov, o.screenNear, o.screenDepth = fov, screenNear, screenDepth
 
 	// get the name of the video card
 	vendorString := gl.GoStr(gl.GetString(gl.VENDOR))
@@ -212,15 +365,167 @@ func (o *OpenGL) InitializeOpenGL(hwnd w32.HWND, screenWidth, screenHeight int,
 	return nil
 }
 
-func (o *OpenGL) WorldMatrix() Matrix {
-	return o.worldMatrix
+// SetReversedZ switches the depth buffer setup between the standard one and the one the reversed-Z projection matrices need.
+// Reversed-Z maps the near plane to 1 and the far plane to 0, which spreads the float depth precision evenly across large scenes.
+// It needs glClipControl from OpenGL 4.5 or ARB_clip_control.
+func (o *OpenGL) SetReversedZ(enabled bool) error {
+	if enabled == o.reversedZ {
+		return nil
+	}
+
+	if !o.supportsClipControl() {
+		return errors.New("reversed-Z depth needs OpenGL 4.5 or ARB_clip_control")
+	}
+
+	if enabled {
+		// keep the projected depth in the 0 to 1 range so it is not squashed by the -1 to 1 mapping
+		gl.ClipControl(gl.LOWER_LEFT, gl.ZERO_TO_ONE)
+
+		// nearer fragments now have greater depth values, so clear to the far value and flip the depth test
+		gl.ClearDepth(0.0)
+		gl.DepthFunc(gl.GREATER)
+	} else {
+		gl.ClipControl(gl.LOWER_LEFT, gl.NEGATIVE_ONE_TO_ONE)
+		gl.ClearDepth(1.0)
+		gl.DepthFunc(gl.LESS)
+	}
+
+	o.reversedZ = enabled
+	return nil
+}
+
+// ReversedZ returns whether the depth buffer is set up for reversed-Z projection matrices
+func (o *OpenGL) ReversedZ() bool {
+	return o.reversedZ
+}
+
+func (o *OpenGL) ScreenSize() (width, height int) {
+	return o.screenWidth, o.screenHeight
+}
+
+// FieldOfView returns the vertical field of view in radians cameras start with
+func (o *OpenGL) FieldOfView() float32 {
+	return o.fov
+}
+
+// ClipPlanes returns the near and far plane distances cameras start with
+func (o *OpenGL) ClipPlanes() (near, far float32) {
+	return o.screenNear, o.screenDepth
+}
+
+// SetViewport selects the window rectangle rendering goes to, in pixels from the bottom left corner.
+// Clearing is limited to the same rectangle so viewports can be cleared one by one.
+func (o *OpenGL) SetViewport(x, y, width, height int32) {
+	gl.Viewport(x, y, width, height)
+	gl.Scissor(x, y, width, height)
+	gl.Enable(gl.SCISSOR_TEST)
+
+	o.viewport = [4]int32{x, y, width, height}
+}
+
+// ResetViewport renders to the whole window again
+func (o *OpenGL) ResetViewport() {
+	gl.Viewport(0, 0, int32(o.screenWidth), int32(o.screenHeight))
+	gl.Disable(gl.SCISSOR_TEST)
+
+	o.viewport = [4]int32{0, 0, int32(o.screenWidth), int32(o.screenHeight)}
 }
 
-func (o *OpenGL) ProjectionMatrix() Matrix {
-	return o.projectionMatrix
+// Viewport returns the window rectangle currently rendered to
+func (o *OpenGL) Viewport() (x, y, width, height int32) {
+	return o.viewport[0], o.viewport[1], o.viewport[2], o.viewport[3]
+}
+
+// WindowCoordinates maps normalized device coordinates to window pixels the same way the viewport transform does.
+// The origin is the bottom left corner of the window and z is the value written to the depth buffer.
+func (o *OpenGL) WindowCoordinates(ndc Vector) Vector {
+	depth := ndc.Z
+	if !o.reversedZ {
+		// the default clip control maps the -1 to 1 range onto the depth range
+		depth = (ndc.Z + 1) * 0.5
+	}
+
+	return Vector{
+		X: float32(o.viewport[0]) + (ndc.X+1)*0.5*float32(o.viewport[2]),
+		Y: float32(o.viewport[1]) + (ndc.Y+1)*0.5*float32(o.viewport[3]),
+		Z: depth,
+	}
+}
+
+// Unproject maps window coordinates, as returned by WindowCoordinates, back into world space
+func (o *OpenGL) Unproject(window Vector, viewMatrix, projectionMatrix Matrix) (Vector, error) {
+	viewProjection := viewMatrix.Multiply(&projectionMatrix)
+	inverse, err := viewProjection.Inverse()
+	if err != nil {
+		return Vector{}, err
+	}
+
+	return inverse.TransformPoint(o.normalizedDeviceCoordinates(window, o.viewport)), nil
+}
+
+// PickRay builds the world space ray that goes through a window pixel of the current viewport.
+// x and y are measured from the top left corner of the window like the mouse position.
+func (o *OpenGL) PickRay(x, y int, viewMatrix, projectionMatrix Matrix) (Ray, error) {
+	return o.pickRay(x, y, o.viewport, viewMatrix, projectionMatrix)
+}
+
+func (o *OpenGL) pickRay(x, y int, viewport [4]int32, viewMatrix, projectionMatrix Matrix) (Ray, error) {
+	viewProjection := viewMatrix.Multiply(&projectionMatrix)
+	inverse, err := viewProjection.Inverse()
+	if err != nil {
+		return Ray{}, err
+	}
+
+	// aim at the center of the pixel and flip y to the bottom left origin of window coordinates
+	window := Vector{
+		X: float32(x) + 0.5,
+		Y: float32(o.screenHeight-y) - 0.5,
+	}
+	ndc := o.normalizedDeviceCoordinates(window, viewport)
+
+	// unproject a point on the near plane and one halfway into the depth range, which stays finite
+	// even for projections with the far plane at infinity
+	nearDepth := float32(0)
+	if o.reversedZ {
+		nearDepth = 1
+	}
+
+	ndc.Z = nearDepth
+	origin := inverse.TransformPoint(ndc)
+
+	ndc.Z = 0.5
+	through := inverse.TransformPoint(ndc)
+
+	return Ray{
+		Origin:    origin,
+		Direction: through.AddVector(origin.Negative()).Normalize(),
+	}, nil
+}
+
+// normalizedDeviceCoordinates is the inverse of WindowCoordinates for the given viewport
+func (o *OpenGL) normalizedDeviceCoordinates(window Vector, viewport [4]int32) Vector {
+	depth := window.Z
+	if !o.reversedZ {
+		depth = window.Z*2 - 1
+	}
+
+	return Vector{
+		X: (window.X-float32(viewport[0]))/float32(viewport[2])*2 - 1,
+		Y: (window.Y-float32(viewport[1]))/float32(viewport[3])*2 - 1,
+		Z: depth,
+	}
 }
 
 func (o *OpenGL) BeginScene(r, g, b, a float32) {
+	// render to the whole window
+	o.ResetViewport()
+
+	// clear the screen and depth buffer
+	o.Clear(r, g, b, a)
+}
+
+// Clear clears the color and depth buffer of the current viewport
+func (o *OpenGL) Clear(r, g, b, a float32) {
 	// set the color to clear the scene to
 	gl.ClearColor(r, g, b, a)
 	check()
@@ -263,6 +568,25 @@ func (o *OpenGL) loadExtensionList() error {
 	return nil
 }
 
+func (o *OpenGL) supportsClipControl() bool {
+	var major, minor int32
+	gl.GetIntegerv(gl.MAJOR_VERSION, &major)
+	gl.GetIntegerv(gl.MINOR_VERSION, &minor)
+	if major > 4 || (major == 4 && minor >= 5) {
+		return true
+	}
+
+	var extensionCount int32
+	gl.GetIntegerv(gl.NUM_EXTENSIONS, &extensionCount)
+	for i := uint32(0); i < uint32(extensionCount); i++ {
+		if gl.GoStr(gl.GetStringi(gl.EXTENSIONS, i)) == "GL_ARB_clip_control" {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (o *OpenGL) identityMatrix() Matrix {
 	return Matrix{
 		1.0, 0, 0, 0,
@@ -272,15 +596,6 @@ func (o *OpenGL) identityMatrix() Matrix {
 	}
 }
 
-func (o *OpenGL) perspectiveFovLHMatrix(fov, screenAspect, screenNear, screenDepth float32) Matrix {
-	return Matrix{
-		1.0 / (screenAspect * float32(math.Tan(float64(fov)*0.5))), 0, 0, 0,
-		0, 1 / float32(math.Tan(float64(fov)*0.5)), 0, 0,
-		0, 0, screenDepth / (screenDepth - screenNear), 1,
-		0, 0, (-screenNear * screenDepth) / (screenDepth - screenNear), 0,
-	}
-}
-
 func (o *OpenGL) rotationMatrix(angle float32) Matrix {
 	return Matrix{
 		float32(math.Cos(float64(angle))), 0, float32(-math.Sin(float64(angle))), 0,
@@ -291,6 +606,10 @@ func (o *OpenGL) rotationMatrix(angle float32) Matrix {
 }
 
 func (o *OpenGL) translationMatrix(x, y, z float32) Matrix {
+	return TranslationMatrix(x, y, z)
+}
+
+func TranslationMatrix(x, y, z float32) Matrix {
 	return Matrix{
 		1, 0, 0, 0,
 		0, 1, 0, 0,
@@ -298,3 +617,153 @@ func (o *OpenGL) translationMatrix(x, y, z float32) Matrix {
 		x, y, z, 1,
 	}
 }
+
+func ScalingMatrix(x, y, z float32) Matrix {
+	return Matrix{
+		x, 0, 0, 0,
+		0, y, 0, 0,
+		0, 0, z, 0,
+		0, 0, 0, 1,
+	}
+}
+
+// The projection builders below follow the Direct3D conventions the rest of the code uses,
+// mapping the view depth into the 0 to 1 range for row vectors.
+
+// PerspectiveFovLHMatrix builds a left-handed perspective projection, fov is the vertical field of view in radians
+func PerspectiveFovLHMatrix(fov, aspect, near, far float32) Matrix {
+	yScale := 1 / float32(math.Tan(float64(fov)*0.5))
+	xScale := yScale / aspect
+
+	return Matrix{
+		xScale, 0, 0, 0,
+		0, yScale, 0, 0,
+		0, 0, far / (far - near), 1,
+		0, 0, (-near * far) / (far - near), 0,
+	}
+}
+
+// PerspectiveFovRHMatrix builds a right-handed perspective projection, fov is the vertical field of view in radians
+func PerspectiveFovRHMatrix(fov, aspect, near, far float32) Matrix {
+	yScale := 1 / float32(math.Tan(float64(fov)*0.5))
+	xScale := yScale / aspect
+
+	return Matrix{
+		xScale, 0, 0, 0,
+		0, yScale, 0, 0,
+		0, 0, far / (near - far), -1,
+		0, 0, (near * far) / (near - far), 0,
+	}
+}
+
+// PerspectiveFovInfiniteLHMatrix builds a left-handed perspective projection with the far plane at infinity
+func PerspectiveFovInfiniteLHMatrix(fov, aspect, near float32) Matrix {
+	yScale := 1 / float32(math.Tan(float64(fov)*0.5))
+	xScale := yScale / aspect
+
+	return Matrix{
+		xScale, 0, 0, 0,
+		0, yScale, 0, 0,
+		0, 0, 1, 1,
+		0, 0, -near, 0,
+	}
+}
+
+// PerspectiveFovInfiniteRHMatrix builds a right-handed perspective projection with the far plane at infinity
+func PerspectiveFovInfiniteRHMatrix(fov, aspect, near float32) Matrix {
+	yScale := 1 / float32(math.Tan(float64(fov)*0.5))
+	xScale := yScale / aspect
+
+	return Matrix{
+		xScale, 0, 0, 0,
+		0, yScale, 0, 0,
+		0, 0, -1, -1,
+		0, 0, -near, 0,
+	}
+}
+
+// PerspectiveFovReversedZLHMatrix builds a left-handed perspective projection that maps the near plane to 1 and the far plane to 0.
+// Use it together with OpenGL.SetReversedZ.
+func PerspectiveFovReversedZLHMatrix(fov, aspect, near, far float32) Matrix {
+	yScale := 1 / float32(math.Tan(float64(fov)*0.5))
+	xScale := yScale / aspect
+
+	return Matrix{
+		xScale, 0, 0, 0,
+		0, yScale, 0, 0,
+		0, 0, near / (near - far), 1,
+		0, 0, (near * far) / (far - near), 0,
+	}
+}
+
+// PerspectiveFovReversedZRHMatrix builds a right-handed perspective projection that maps the near plane to 1 and the far plane to 0.
+// Use it together with OpenGL.SetReversedZ.
+func PerspectiveFovReversedZRHMatrix(fov, aspect, near, far float32) Matrix {
+	yScale := 1 / float32(math.Tan(float64(fov)*0.5))
+	xScale := yScale / aspect
+
+	return Matrix{
+		xScale, 0, 0, 0,
+		0, yScale, 0, 0,
+		0, 0, near / (far - near), -1,
+		0, 0, (near * far) / (far - near), 0,
+	}
+}
+
+// PerspectiveFovInfiniteReversedZLHMatrix builds a left-handed reversed-Z perspective projection with the far plane at infinity.
+// Use it together with OpenGL.SetReversedZ.
+func PerspectiveFovInfiniteReversedZLHMatrix(fov, aspect, near float32) Matrix {
+	yScale := 1 / float32(math.Tan(float64(fov)*0.5))
+	xScale := yScale / aspect
+
+	return Matrix{
+		xScale, 0, 0, 0,
+		0, yScale, 0, 0,
+		0, 0, 0, 1,
+		0, 0, near, 0,
+	}
+}
+
+// PerspectiveFovInfiniteReversedZRHMatrix builds a right-handed reversed-Z perspective projection with the far plane at infinity.
+// Use it together with OpenGL.SetReversedZ.
+func PerspectiveFovInfiniteReversedZRHMatrix(fov, aspect, near float32) Matrix {
+	yScale := 1 / float32(math.Tan(float64(fov)*0.5))
+	xScale := yScale / aspect
+
+	return Matrix{
+		xScale, 0, 0, 0,
+		0, yScale, 0, 0,
+		0, 0, 0, -1,
+		0, 0, near, 0,
+	}
+}
+
+// OrthoLHMatrix builds a left-handed orthographic projection of a width by height volume centered on the view axis
+func OrthoLHMatrix(width, height, near, far float32) Matrix {
+	return OrthoOffCenterLHMatrix(-width/2, width/2, -height/2, height/2, near, far)
+}
+
+// OrthoRHMatrix builds a right-handed orthographic projection of a width by height volume centered on the view axis
+func OrthoRHMatrix(width, height, near, far float32) Matrix {
+	return OrthoOffCenterRHMatrix(-width/2, width/2, -height/2, height/2, near, far)
+}
+
+// OrthoOffCenterLHMatrix builds a left-handed orthographic projection of an arbitrary view volume
+func OrthoOffCenterLHMatrix(left, right, bottom, top, near, far float32) Matrix {
+	return Matrix{
+		2 / (right - left), 0, 0, 0,
+		0, 2 / (top - bottom), 0, 0,
+		0, 0, 1 / (far - near), 0,
+		(left + right) / (left - right), (top + bottom) / (bottom - top), near / (near - far), 1,
+	}
+}
+
+// OrthoOffCenterRHMatrix builds a right-handed orthographic projection of an arbitrary view volume
+func OrthoOffCenterRHMatrix(left, right, bottom, top, near, far float32) Matrix {
+	return Matrix{
+		2 / (right - left), 0, 0, 0,
+		0, 2 / (top - bottom), 0, 0,
+		0, 0, 1 / (near - far), 0,
+		(left + right) / (left - right), (top + bottom) / (bottom - top), near / (near - far), 1,
+	}
+}
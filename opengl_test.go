@@ -0,0 +1,336 @@
+package opengl_exercise
+
+import (
+	"math"
+	"testing"
+)
+
+const matrixEpsilon = 1e-4
+
+func matrixNearlyEqual(a, b Matrix) bool {
+	for i := range a {
+		if math.Abs(float64(a[i]-b[i])) > matrixEpsilon {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestMatrixInverse(t *testing.T) {
+	o := NewOpenGL()
+	identity := o.identityMatrix()
+	rotation := o.rotationMatrix(0.7)
+	translation := o.translationMatrix(1, -2, 3)
+	scale := ScalingMatrix(2, 3, 0.5)
+
+	tests := []struct {
+		name   string
+		matrix Matrix
+	}{
+		{"identity", identity},
+		{"translation", translation},
+		{"rotation", rotation},
+		{"rotation then translation", rotation.Multiply(&translation)},
+		{"scale rotation translation", func() Matrix {
+			m := scale.Multiply(&rotation)
+			return m.Multiply(&translation)
+		}()},
+		{"perspective", PerspectiveFovLHMatrix(math.Pi/4, 4.0/3.0, 0.1, 1000)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			inverse, err := test.matrix.Inverse()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if product := test.matrix.Multiply(&inverse); !matrixNearlyEqual(product, identity) {
+				t.Errorf("m * inverse is not identity: %v", product)
+			}
+
+			if product := inverse.Multiply(&test.matrix); !matrixNearlyEqual(product, identity) {
+				t.Errorf("inverse * m is not identity: %v", product)
+			}
+		})
+	}
+}
+
+func TestMatrixInverseSingular(t *testing.T) {
+	o := NewOpenGL()
+	translation := o.translationMatrix(4, 5, 6)
+	flat := ScalingMatrix(1, 0, 1)
+
+	tests := []struct {
+		name   string
+		matrix Matrix
+	}{
+		{"zero", Matrix{}},
+		{"flattened", flat.Multiply(&translation)},
+		{"duplicate rows", Matrix{
+			1, 2, 3, 0,
+			1, 2, 3, 0,
+			0, 0, 1, 0,
+			0, 0, 0, 1,
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.matrix.Inverse(); err != ErrSingularMatrix {
+				t.Errorf("expected ErrSingularMatrix, got %v", err)
+			}
+		})
+	}
+
+	// a small determinant alone doesn't make a matrix singular
+	tiny := ScalingMatrix(1e-4, 1e-4, 1e-4)
+	huge := ScalingMatrix(1e4, 1e4, 1e4)
+	uneven := ScalingMatrix(1e-4, 1, 1e3)
+	invertible := []struct {
+		name   string
+		matrix Matrix
+	}{
+		{"uniform scale 1e-4", tiny},
+		{"uniform scale 1e-4 and translation", tiny.Multiply(&translation)},
+		{"uniform scale 1e4", huge},
+		{"uneven scale", uneven.Multiply(&translation)},
+	}
+
+	for _, test := range invertible {
+		t.Run(test.name, func(t *testing.T) {
+			inverse, err := test.matrix.Inverse()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if product := test.matrix.Multiply(&inverse); !matrixNearlyEqual(product, o.identityMatrix()) {
+				t.Errorf("expected the identity, got %v", product)
+			}
+		})
+	}
+}
+
+func TestMatrixTranspose(t *testing.T) {
+	o := NewOpenGL()
+	rotation := o.rotationMatrix(1.2)
+	translation := o.translationMatrix(7, 8, 9)
+
+	tests := []struct {
+		name   string
+		matrix Matrix
+	}{
+		{"identity", o.identityMatrix()},
+		{"rotation", rotation},
+		{"translation", translation},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			transposed := test.matrix.Transpose()
+			for row := 0; row < 4; row++ {
+				for col := 0; col < 4; col++ {
+					if transposed[row*4+col] != test.matrix[col*4+row] {
+						t.Fatalf("element (%d, %d) was not transposed", row, col)
+					}
+				}
+			}
+
+			if back := transposed.Transpose(); back != test.matrix {
+				t.Errorf("transposing twice changed the matrix: %v", back)
+			}
+		})
+	}
+
+	// the inverse of a pure rotation is its transpose
+	inverse, err := rotation.Inverse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if transposed := rotation.Transpose(); !matrixNearlyEqual(inverse, transposed) {
+		t.Errorf("rotation inverse %v differs from transpose %v", inverse, transposed)
+	}
+}
+
+func TestMatrixDeterminant(t *testing.T) {
+	o := NewOpenGL()
+	rotation := o.rotationMatrix(0.3)
+	translation := o.translationMatrix(1, 2, 3)
+	scale := ScalingMatrix(2, 3, 4)
+	mirror := ScalingMatrix(-1, 1, 1)
+
+	tests := []struct {
+		name     string
+		matrix   Matrix
+		expected float32
+	}{
+		{"identity", o.identityMatrix(), 1},
+		{"rotation", rotation, 1},
+		{"translation", translation, 1},
+		{"scale", scale, 24},
+		{"scale rotation translation", func() Matrix {
+			m := scale.Multiply(&rotation)
+			return m.Multiply(&translation)
+		}(), 24},
+		{"mirror", mirror, -1},
+		{"zero", Matrix{}, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if det := test.matrix.Determinant(); math.Abs(float64(det-test.expected)) > matrixEpsilon {
+				t.Errorf("expected determinant %f, got %f", test.expected, det)
+			}
+		})
+	}
+}
+
+func TestMatrixDecompose(t *testing.T) {
+	o := NewOpenGL()
+
+	tests := []struct {
+		name        string
+		translation Vector
+		angle       float32
+		scale       Vector
+	}{
+		{"identity", Vector{}, 0, Vector{1, 1, 1}},
+		{"translation", Vector{1, -2, 3}, 0, Vector{1, 1, 1}},
+		{"rotation", Vector{}, 0.9, Vector{1, 1, 1}},
+		{"all", Vector{-5, 0.5, 10}, -2.1, Vector{2, 0.5, 3}},
+		{"mirrored", Vector{1, 1, 1}, 0.4, Vector{-2, 1, 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scale := ScalingMatrix(test.scale.X, test.scale.Y, test.scale.Z)
+			rotation := o.rotationMatrix(test.angle)
+			translation := o.translationMatrix(test.translation.X, test.translation.Y, test.translation.Z)
+
+			matrix := scale.Multiply(&rotation)
+			matrix = matrix.Multiply(&translation)
+
+			gotTranslation, gotRotation, gotScale, err := matrix.Decompose()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if gotTranslation != test.translation {
+				t.Errorf("expected translation %v, got %v", test.translation, gotTranslation)
+			}
+
+			if !matrixNearlyEqual(gotRotation.Matrix(), rotation) {
+				t.Errorf("expected rotation %v, got %v", rotation, gotRotation.Matrix())
+			}
+
+			if gotScale.AddVector(test.scale.Negative()).Dot(gotScale.AddVector(test.scale.Negative())) > matrixEpsilon {
+				t.Errorf("expected scale %v, got %v", test.scale, gotScale)
+			}
+
+			// rebuild the matrix from the decomposed parts
+			rotationMatrix := gotRotation.Matrix()
+			rebuilt := ScalingMatrix(gotScale.X, gotScale.Y, gotScale.Z)
+			rebuilt = rebuilt.Multiply(&rotationMatrix)
+			rebuilt = rebuilt.Multiply(&translation)
+			if !matrixNearlyEqual(rebuilt, matrix) {
+				t.Errorf("rebuilt matrix %v differs from %v", rebuilt, matrix)
+			}
+		})
+	}
+
+	flat := ScalingMatrix(1, 1, 0)
+	if _, _, _, err := flat.Decompose(); err != ErrSingularMatrix {
+		t.Errorf("expected ErrSingularMatrix for flattened matrix, got %v", err)
+	}
+}
+
+func TestProjectionDepthRange(t *testing.T) {
+	const fov, aspect, near, far = math.Pi / 3, 16.0 / 9.0, 0.5, 500
+
+	// project a point on the view axis and return its depth after the perspective divide
+	depth := func(m Matrix, z float32) float32 {
+		clipZ := z*m[10] + m[14]
+		clipW := z*m[11] + m[15]
+		return clipZ / clipW
+	}
+
+	tests := []struct {
+		name                string
+		matrix              Matrix
+		nearZ, farZ         float32
+		nearDepth, farDepth float32
+	}{
+		{"perspective lh", PerspectiveFovLHMatrix(fov, aspect, near, far), near, far, 0, 1},
+		{"perspective rh", PerspectiveFovRHMatrix(fov, aspect, near, far), -near, -far, 0, 1},
+		{"infinite lh", PerspectiveFovInfiniteLHMatrix(fov, aspect, near), near, 1e7, 0, 1},
+		{"infinite rh", PerspectiveFovInfiniteRHMatrix(fov, aspect, near), -near, -1e7, 0, 1},
+		{"reversed lh", PerspectiveFovReversedZLHMatrix(fov, aspect, near, far), near, far, 1, 0},
+		{"reversed rh", PerspectiveFovReversedZRHMatrix(fov, aspect, near, far), -near, -far, 1, 0},
+		{"infinite reversed lh", PerspectiveFovInfiniteReversedZLHMatrix(fov, aspect, near), near, 1e7, 1, 0},
+		{"infinite reversed rh", PerspectiveFovInfiniteReversedZRHMatrix(fov, aspect, near), -near, -1e7, 1, 0},
+		{"ortho lh", OrthoLHMatrix(8, 6, near, far), near, far, 0, 1},
+		{"ortho rh", OrthoRHMatrix(8, 6, near, far), -near, -far, 0, 1},
+		{"off center lh", OrthoOffCenterLHMatrix(-1, 3, -2, 5, near, far), near, far, 0, 1},
+		{"off center rh", OrthoOffCenterRHMatrix(-1, 3, -2, 5, near, far), -near, -far, 0, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if d := depth(test.matrix, test.nearZ); math.Abs(float64(d-test.nearDepth)) > matrixEpsilon {
+				t.Errorf("near plane depth: expected %f, got %f", test.nearDepth, d)
+			}
+
+			if d := depth(test.matrix, test.farZ); math.Abs(float64(d-test.farDepth)) > matrixEpsilon {
+				t.Errorf("far plane depth: expected %f, got %f", test.farDepth, d)
+			}
+		})
+	}
+
+	// the off center volume edges end up on the clip space edges
+	offCenter := OrthoOffCenterLHMatrix(-1, 3, -2, 5, near, far)
+	for _, corner := range []struct{ x, y, ndcX, ndcY float32 }{{-1, -2, -1, -1}, {3, 5, 1, 1}} {
+		x := corner.x*offCenter[0] + corner.y*offCenter[4] + offCenter[12]
+		y := corner.x*offCenter[1] + corner.y*offCenter[5] + offCenter[13]
+		if math.Abs(float64(x-corner.ndcX)) > matrixEpsilon || math.Abs(float64(y-corner.ndcY)) > matrixEpsilon {
+			t.Errorf("corner (%f, %f) projected to (%f, %f)", corner.x, corner.y, x, y)
+		}
+	}
+}
+
+func TestMatrixTransform(t *testing.T) {
+	o := NewOpenGL()
+	translation := o.translationMatrix(1, 2, 3)
+	projection := PerspectiveFovLHMatrix(math.Pi/2, 1, 1, 100)
+
+	tests := []struct {
+		name     string
+		matrix   Matrix
+		point    Vector
+		expected Vector
+	}{
+		{"translated point", translation, Vector{1, 1, 1}, Vector{2, 3, 4}},
+		{"projected near corner", projection, Vector{1, 1, 1}, Vector{1, 1, 0}},
+		{"projected far center", projection, Vector{0, 0, 100}, Vector{0, 0, 1}},
+		{"projected point is divided by w", projection, Vector{5, -5, 10}, Vector{0.5, -0.5, 100.0 / 99.0 * 0.9}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.matrix.TransformPoint(test.point)
+			if diff := got.AddVector(test.expected.Negative()); diff.Dot(diff) > matrixEpsilon {
+				t.Errorf("expected %+v, got %+v", test.expected, got)
+			}
+		})
+	}
+
+	// directions ignore the translation part
+	if got := translation.TransformDirection(Vector{0, 0, 1}); got != (Vector{0, 0, 1}) {
+		t.Errorf("direction was translated: %+v", got)
+	}
+
+	// clip space keeps w, which is the view depth for a perspective projection
+	if clip := projection.TransformHomogeneous(Vector{0, 0, 10}.Vector4(1)); clip.W != 10 {
+		t.Errorf("expected clip w 10, got %+v", clip)
+	}
+}
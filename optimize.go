@@ -0,0 +1,503 @@
+package opengl_exercise
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// OptimizeOptions configures Mesh.Optimize
+type OptimizeOptions struct {
+	// WeldTolerance is how far apart vertices may be to be merged, in position and in every other attribute.
+	// Vertices are not welded when it is negative.
+	WeldTolerance float32
+
+	// CacheSize is the number of vertices the post-transform cache is assumed to hold
+	CacheSize int
+
+	// OverdrawThreshold is how much worse than the cache order the ACMR may get to draw outward facing parts first,
+	// 1.05 allows 5 percent. Overdraw is not optimized when it is below 1.
+	OverdrawThreshold float32
+}
+
+// DefaultOptimizeOptions welds exact duplicates, optimizes for a 32 vertex cache and allows 5 percent for overdraw
+func DefaultOptimizeOptions() OptimizeOptions {
+	return OptimizeOptions{
+		WeldTolerance:     0,
+		CacheSize:         32,
+		OverdrawThreshold: 1.05,
+	}
+}
+
+// OptimizeStats tells what Mesh.Optimize did
+type OptimizeStats struct {
+	VerticesBefore, VerticesAfter   int
+	TrianglesBefore, TrianglesAfter int
+
+	// ACMR is the average number of vertices transformed per triangle with a FIFO cache of the optimized size,
+	// 3 is the worst and 0.5 about the best a large mesh can get
+	ACMRBefore, ACMRAfter float32
+}
+
+func (s OptimizeStats) String() string {
+	return fmt.Sprintf("vertices %d -> %d, triangles %d -> %d, ACMR %.3f -> %.3f",
+		s.VerticesBefore, s.VerticesAfter, s.TrianglesBefore, s.TrianglesAfter, s.ACMRBefore, s.ACMRAfter)
+}
+
+// Optimize welds duplicate vertices, orders the triangles for the vertex cache and against overdraw and then the vertices
+// in the order they are used. Triangles stay in their groups.
+func (m *Mesh) Optimize(options OptimizeOptions) OptimizeStats {
+	cacheSize := atLeast(options.CacheSize, 3)
+	stats := OptimizeStats{
+		VerticesBefore:  len(m.Vertices),
+		TrianglesBefore: len(m.Indices) / 3,
+		ACMRBefore:      m.ACMR(cacheSize),
+	}
+
+	if options.WeldTolerance >= 0 {
+		m.Weld(options.WeldTolerance)
+	}
+	m.OptimizeVertexCache(cacheSize)
+	if options.OverdrawThreshold >= 1 {
+		m.OptimizeOverdraw(cacheSize, options.OverdrawThreshold)
+	}
+	m.OptimizeVertexFetch()
+
+	stats.VerticesAfter = len(m.Vertices)
+	stats.TrianglesAfter = len(m.Indices) / 3
+	stats.ACMRAfter = m.ACMR(cacheSize)
+	return stats
+}
+
+// Optimize runs Mesh.Optimize on the model geometry and uploads the result
+func (m *Model) Optimize(options OptimizeOptions) (OptimizeStats, error) {
+	var stats OptimizeStats
+	err := m.editMesh(func(mesh *Mesh) {
+		stats = mesh.Optimize(options)
+	})
+
+	return stats, err
+}
+
+// ACMR simulates a FIFO post-transform cache of the given size and returns the average number of cache misses per triangle
+func (m *Mesh) ACMR(cacheSize int) float32 {
+	triangles := len(m.Indices) / 3
+	if triangles == 0 {
+		return 0
+	}
+
+	cache := make([]uint32, 0, cacheSize)
+	misses := 0
+	for _, index := range m.Indices[:triangles*3] {
+		if fifoContains(cache, index) {
+			continue
+		}
+
+		misses++
+		if len(cache) == cacheSize {
+			cache = cache[1:]
+		}
+		cache = append(cache, index)
+	}
+
+	return float32(misses) / float32(triangles)
+}
+
+func fifoContains(cache []uint32, index uint32) bool {
+	for _, cached := range cache {
+		if cached == index {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ranges returns the index ranges that triangles must not leave, the groups or the whole mesh
+func (m *Mesh) ranges() []MeshGroup {
+	if len(m.Groups) > 0 {
+		return m.Groups
+	}
+
+	return []MeshGroup{{Start: 0, Count: len(m.Indices) / 3 * 3}}
+}
+
+// Weld merges vertices whose positions and other attributes are no more than tolerance apart and drops the triangles that collapse.
+// It returns the number of vertices merged away, unused vertices are left for OptimizeVertexFetch.
+func (m *Mesh) Weld(tolerance float32) int {
+	remap := make([]uint32, len(m.Vertices))
+	kept := make([]Vertex, 0, len(m.Vertices))
+
+	if tolerance <= 0 {
+		// exact duplicates can be looked up directly
+		seen := map[Vertex]uint32{}
+		for i, vertex := range m.Vertices {
+			match, found := seen[vertex]
+			if !found {
+				match = uint32(len(kept))
+				kept = append(kept, vertex)
+				seen[vertex] = match
+			}
+			remap[i] = match
+		}
+		return m.remapVertices(remap, kept)
+	}
+
+	// vertices are hashed by position cells of the tolerance size, similar ones can only be in the neighbouring cells
+	cellSize := float64(tolerance)
+	cellOf := func(v Vertex) [3]int64 {
+		return [3]int64{
+			int64(math.Floor(float64(v.X) / cellSize)),
+			int64(math.Floor(float64(v.Y) / cellSize)),
+			int64(math.Floor(float64(v.Z) / cellSize)),
+		}
+	}
+
+	cells := map[[3]int64][]uint32{}
+	for i, vertex := range m.Vertices {
+		cell := cellOf(vertex)
+		match, found := uint32(0), false
+
+	search:
+		for x := int64(-1); x <= 1; x++ {
+			for y := int64(-1); y <= 1; y++ {
+				for z := int64(-1); z <= 1; z++ {
+					for _, candidate := range cells[[3]int64{cell[0] + x, cell[1] + y, cell[2] + z}] {
+						if verticesClose(kept[candidate], vertex, tolerance) {
+							match, found = candidate, true
+							break search
+						}
+					}
+				}
+			}
+		}
+
+		if !found {
+			match = uint32(len(kept))
+			kept = append(kept, vertex)
+			cells[cell] = append(cells[cell], match)
+		}
+		remap[i] = match
+	}
+
+	return m.remapVertices(remap, kept)
+}
+
+// remapVertices replaces the vertices by the kept ones remap points to and drops the triangles that collapse
+func (m *Mesh) remapVertices(remap []uint32, kept []Vertex) int {
+	// rebuild the groups without the collapsed triangles
+	indices := make([]uint32, 0, len(m.Indices))
+	groups := make([]MeshGroup, 0, len(m.Groups))
+	for _, group := range m.ranges() {
+		start := len(indices)
+		for i := group.Start; i+2 < group.Start+group.Count; i += 3 {
+			a, b, c := remap[m.Indices[i]], remap[m.Indices[i+1]], remap[m.Indices[i+2]]
+			if a != b && b != c && c != a {
+				indices = append(indices, a, b, c)
+			}
+		}
+
+		group.Start, group.Count = start, len(indices)-start
+		groups = append(groups, group)
+	}
+
+	merged := len(m.Vertices) - len(kept)
+	m.Vertices, m.Indices = kept, indices
+	if len(m.Groups) > 0 {
+		m.Groups = groups
+	}
+
+	return merged
+}
+
+// verticesClose compares every attribute of two vertices
+func verticesClose(a, b Vertex, tolerance float32) bool {
+	pa := [...]float32{a.X, a.Y, a.Z, a.R, a.G, a.B, a.NX, a.NY, a.NZ, a.U, a.V, a.TX, a.TY, a.TZ, a.TW}
+	pb := [...]float32{b.X, b.Y, b.Z, b.R, b.G, b.B, b.NX, b.NY, b.NZ, b.U, b.V, b.TX, b.TY, b.TZ, b.TW}
+	for i := range pa {
+		if d := pa[i] - pb[i]; d > tolerance || d < -tolerance {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Forsyth's vertex scoring, see "Linear-Speed Vertex Cache Optimisation"
+const (
+	forsythCacheDecayPower   = 1.5
+	forsythLastTriangleScore = 0.75
+	forsythValenceBoostScale = 2.0
+	forsythValencePower      = 0.5
+)
+
+// OptimizeVertexCache orders the triangles of every group with Tom Forsyth's algorithm, so that the vertices of the next
+// triangles are likely still in the post-transform cache of the given size
+func (m *Mesh) OptimizeVertexCache(cacheSize int) {
+	cacheSize = atLeast(cacheSize, 4)
+	for _, group := range m.ranges() {
+		forsythOrder(m.Indices[group.Start:group.Start+group.Count], len(m.Vertices), cacheSize)
+	}
+}
+
+func forsythVertexScore(cachePosition, remaining, cacheSize int) float32 {
+	if remaining == 0 {
+		return -1
+	}
+
+	score := float32(0)
+	if cachePosition >= 0 {
+		if cachePosition < 3 {
+			// the vertices of the last triangle get a fixed score, so it does not matter which one is used next
+			score = forsythLastTriangleScore
+		} else {
+			scale := 1 / float32(cacheSize-3)
+			score = float32(math.Pow(float64(1-float32(cachePosition-3)*scale), forsythCacheDecayPower))
+		}
+	}
+
+	// vertices with few triangles left get a boost, so they are finished off instead of leaving lone triangles behind
+	return score + forsythValenceBoostScale*float32(math.Pow(float64(remaining), -forsythValencePower))
+}
+
+// forsythOrder reorders the triangles of indices in place
+func forsythOrder(indices []uint32, vertexCount, cacheSize int) {
+	triangleCount := len(indices) / 3
+	if triangleCount < 2 {
+		return
+	}
+
+	// triangles of every vertex, the first remaining[v] entries of each list are the triangles not drawn yet
+	offsets := make([]int, vertexCount+1)
+	for _, index := range indices {
+		offsets[index+1]++
+	}
+	for v := 0; v < vertexCount; v++ {
+		offsets[v+1] += offsets[v]
+	}
+	remaining := make([]int, vertexCount)
+	adjacency := make([]int, len(indices))
+	for corner, index := range indices {
+		adjacency[offsets[index]+remaining[index]] = corner / 3
+		remaining[index]++
+	}
+
+	cachePosition := make([]int, vertexCount)
+	vertexScore := make([]float32, vertexCount)
+	for v := range cachePosition {
+		cachePosition[v] = -1
+		vertexScore[v] = forsythVertexScore(-1, remaining[v], cacheSize)
+	}
+
+	triangleScore := make([]float32, triangleCount)
+	drawn := make([]bool, triangleCount)
+	best := 0
+	for t := range triangleScore {
+		triangleScore[t] = vertexScore[indices[t*3]] + vertexScore[indices[t*3+1]] + vertexScore[indices[t*3+2]]
+		if triangleScore[t] > triangleScore[best] {
+			best = t
+		}
+	}
+
+	output := make([]uint32, 0, len(indices))
+	cache := make([]uint32, 0, cacheSize+3)
+	next := 0
+	for len(output) < len(indices) {
+		if best < 0 {
+			// nothing in the cache leads on, continue with the next triangle not drawn yet
+			for drawn[next] {
+				next++
+			}
+			best = next
+		}
+
+		corners := indices[best*3 : best*3+3]
+		output = append(output, corners...)
+		drawn[best] = true
+
+		// the triangle is no longer waiting on its vertices
+		for _, v := range corners {
+			list := adjacency[offsets[v] : offsets[v]+remaining[v]]
+			for i, t := range list {
+				if t == best {
+					list[i] = list[len(list)-1]
+					break
+				}
+			}
+			remaining[v]--
+		}
+
+		// move the triangle vertices to the front of the LRU cache, the ones pushed past its end drop out
+		updated := append([]uint32(nil), corners...)
+		for _, v := range cache {
+			if v != corners[0] && v != corners[1] && v != corners[2] {
+				updated = append(updated, v)
+			}
+		}
+		for i, v := range updated {
+			if i < cacheSize {
+				cachePosition[v] = i
+			} else {
+				cachePosition[v] = -1
+			}
+		}
+
+		// rescore the vertices that moved and the triangles using them, the best of those is drawn next
+		best = -1
+		var bestScore float32
+		for _, v := range updated {
+			score := forsythVertexScore(cachePosition[v], remaining[v], cacheSize)
+			delta := score - vertexScore[v]
+			vertexScore[v] = score
+
+			for _, t := range adjacency[offsets[v] : offsets[v]+remaining[v]] {
+				triangleScore[t] += delta
+				if cachePosition[v] >= 0 && (best < 0 || triangleScore[t] > bestScore) {
+					best, bestScore = t, triangleScore[t]
+				}
+			}
+		}
+
+		if len(updated) > cacheSize {
+			updated = updated[:cacheSize]
+		}
+		cache = append(cache[:0], updated...)
+	}
+
+	copy(indices, output)
+}
+
+// OptimizeOverdraw splits the cache ordered triangles of every group into clusters and draws the clusters facing outwards first,
+// so they hide what is behind them. Clusters are only cut where the ACMR of the group stays within threshold times the one before.
+// Run it after OptimizeVertexCache.
+func (m *Mesh) OptimizeOverdraw(cacheSize int, threshold float32) {
+	var center Vector
+	for _, vertex := range m.Vertices {
+		center = center.AddVector(vertex.position())
+	}
+	if len(m.Vertices) > 0 {
+		center = center.MultiplyScalar(1 / float32(len(m.Vertices)))
+	}
+
+	for _, group := range m.ranges() {
+		indices := m.Indices[group.Start : group.Start+group.Count]
+		clusters := m.overdrawClusters(indices, cacheSize, threshold)
+
+		type cluster struct {
+			start, end int
+			key        float32
+		}
+		sorted := make([]cluster, len(clusters))
+		for i, start := range clusters {
+			end := len(indices) / 3
+			if i+1 < len(clusters) {
+				end = clusters[i+1]
+			}
+
+			// area weighted centroid and normal of the cluster
+			var centroid, normal Vector
+			var area float32
+			for t := start; t < end; t++ {
+				a, b, c := m.Vertices[indices[t*3]].position(), m.Vertices[indices[t*3+1]].position(), m.Vertices[indices[t*3+2]].position()
+				face := b.AddVector(a.Negative()).Cross(c.AddVector(a.Negative()))
+				weight := face.Length()
+				normal = normal.AddVector(face)
+				centroid = centroid.AddVector(a.AddVector(b).AddVector(c).MultiplyScalar(weight / 3))
+				area += weight
+			}
+			if area > 0 {
+				centroid = centroid.MultiplyScalar(1 / area)
+			}
+
+			sorted[i] = cluster{start, end, centroid.AddVector(center.Negative()).Dot(direction(normal))}
+		}
+
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].key > sorted[j].key
+		})
+
+		ordered := make([]uint32, 0, len(indices))
+		for _, cluster := range sorted {
+			ordered = append(ordered, indices[cluster.start*3:cluster.end*3]...)
+		}
+		copy(indices, ordered)
+	}
+}
+
+// overdrawClusters returns the first triangle of every cluster. Clusters start where the cache runs dry, and are split
+// further as soon as the ACMR of the cluster so far, starting with an empty cache, is within threshold of the unsplit one.
+func (m *Mesh) overdrawClusters(indices []uint32, cacheSize int, threshold float32) []int {
+	triangles := len(indices) / 3
+	cache := make([]uint32, 0, cacheSize)
+	misses := func(t int) int {
+		count := 0
+		for _, index := range indices[t*3 : t*3+3] {
+			if fifoContains(cache, index) {
+				continue
+			}
+			count++
+			if len(cache) == cacheSize {
+				cache = cache[1:]
+			}
+			cache = append(cache, index)
+		}
+		return count
+	}
+
+	// hard boundaries where a triangle shares nothing with the cache
+	var hard []int
+	hardMisses := map[int]int{}
+	for t := 0; t < triangles; t++ {
+		count := misses(t)
+		if t == 0 || count == 3 {
+			hard = append(hard, t)
+		}
+		hardMisses[hard[len(hard)-1]] += count
+	}
+
+	var clusters []int
+	for i, start := range hard {
+		end := triangles
+		if i+1 < len(hard) {
+			end = hard[i+1]
+		}
+		limit := float32(hardMisses[start]) / float32(end-start) * threshold
+
+		// a new cluster starts cold, since the clusters are drawn in a different order later
+		clusters = append(clusters, start)
+		cache = cache[:0]
+		clusterStart, clusterMisses := start, 0
+		for t := start; t < end; t++ {
+			clusterMisses += misses(t)
+			if t+1 < end && float32(clusterMisses)/float32(t-clusterStart+1) <= limit {
+				clusters = append(clusters, t+1)
+				cache = cache[:0]
+				clusterStart, clusterMisses = t+1, 0
+			}
+		}
+	}
+
+	return clusters
+}
+
+// OptimizeVertexFetch renumbers the vertices in the order the triangles first use them, so the vertex buffer is read front to back.
+// Vertices no triangle uses are dropped.
+func (m *Mesh) OptimizeVertexFetch() {
+	const unused = math.MaxUint32
+
+	remap := make([]uint32, len(m.Vertices))
+	for i := range remap {
+		remap[i] = unused
+	}
+
+	vertices := make([]Vertex, 0, len(m.Vertices))
+	for i, index := range m.Indices {
+		if remap[index] == unused {
+			remap[index] = uint32(len(vertices))
+			vertices = append(vertices, m.Vertices[index])
+		}
+		m.Indices[i] = remap[index]
+	}
+
+	m.Vertices = vertices
+}
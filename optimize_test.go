@@ -0,0 +1,212 @@
+package opengl_exercise
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// shuffledSphere returns a sphere whose triangles are in random order, the worst case for the vertex cache
+func shuffledSphere() *Mesh {
+	mesh := NewUVSphereMesh(1, 32, 16)
+
+	random := rand.New(rand.NewSource(1))
+	indices := make([]uint32, 0, len(mesh.Indices))
+	for _, triangle := range random.Perm(len(mesh.Indices) / 3) {
+		indices = append(indices, mesh.Indices[triangle*3:triangle*3+3]...)
+	}
+	mesh.Indices = indices
+
+	return mesh
+}
+
+// triangleSet counts the triangles of an index range by their corners, starting from the smallest corner so that
+// triangles compare equal whatever corner they start with but not when their winding changed
+func triangleSet(mesh *Mesh, start, count int) map[[3]Vertex]int {
+	less := func(a, b Vertex) bool {
+		pa := [...]float32{a.X, a.Y, a.Z, a.R, a.G, a.B, a.NX, a.NY, a.NZ, a.U, a.V, a.TX, a.TY, a.TZ, a.TW}
+		pb := [...]float32{b.X, b.Y, b.Z, b.R, b.G, b.B, b.NX, b.NY, b.NZ, b.U, b.V, b.TX, b.TY, b.TZ, b.TW}
+		for i := range pa {
+			if pa[i] != pb[i] {
+				return pa[i] < pb[i]
+			}
+		}
+		return false
+	}
+
+	triangles := map[[3]Vertex]int{}
+	for i := start; i+2 < start+count; i += 3 {
+		corners := [3]Vertex{mesh.Vertices[mesh.Indices[i]], mesh.Vertices[mesh.Indices[i+1]], mesh.Vertices[mesh.Indices[i+2]]}
+		for less(corners[1], corners[0]) || less(corners[2], corners[0]) {
+			corners = [3]Vertex{corners[1], corners[2], corners[0]}
+		}
+		triangles[corners]++
+	}
+
+	return triangles
+}
+
+func sameTriangles(t *testing.T, expected, got map[[3]Vertex]int) {
+	t.Helper()
+
+	if len(expected) != len(got) {
+		t.Fatalf("expected %d different triangles, got %d", len(expected), len(got))
+	}
+	for triangle, count := range expected {
+		if got[triangle] != count {
+			t.Fatalf("triangle %+v: expected %d, got %d", triangle, count, got[triangle])
+		}
+	}
+}
+
+func TestACMR(t *testing.T) {
+	tests := []struct {
+		name      string
+		indices   []uint32
+		cacheSize int
+		expected  float32
+	}{
+		{"one triangle", []uint32{0, 1, 2}, 32, 3},
+		{"shared edge", []uint32{0, 1, 2, 2, 1, 3}, 32, 2},
+		{"repeated", []uint32{0, 1, 2, 0, 1, 2}, 32, 1.5},
+		// the fourth vertex pushes the first one out of a cache of three, bringing it back pushes out the next ones
+		{"evicted", []uint32{0, 1, 2, 1, 3, 2, 0, 1, 2}, 3, 7.0 / 3},
+		{"empty", nil, 32, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mesh := &Mesh{Indices: test.indices}
+			if acmr := mesh.ACMR(test.cacheSize); acmr != test.expected {
+				t.Errorf("expected ACMR %v, got %v", test.expected, acmr)
+			}
+		})
+	}
+}
+
+func TestWeld(t *testing.T) {
+	t.Run("exact", func(t *testing.T) {
+		mesh := NewPlaneMesh(2, 2, 4, 4)
+		expected := triangleSet(mesh, 0, len(mesh.Indices))
+		vertexCount := len(mesh.Vertices)
+		mesh.GenerateFlatNormals()
+
+		if merged := mesh.Weld(0); merged != len(mesh.Indices)-vertexCount {
+			t.Errorf("expected %d vertices merged, got %d", len(mesh.Indices)-vertexCount, merged)
+		}
+		if len(mesh.Vertices) != vertexCount {
+			t.Errorf("expected %d vertices, got %d", vertexCount, len(mesh.Vertices))
+		}
+		sameTriangles(t, expected, triangleSet(mesh, 0, len(mesh.Indices)))
+	})
+
+	t.Run("tolerance", func(t *testing.T) {
+		mesh := &Mesh{
+			Vertices: []Vertex{
+				{X: 0, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 0},
+				{X: 1.0005, Y: 0}, {X: 0, Y: 1.0005}, {X: 1, Y: 1},
+				// close to the first vertex in position, but not in color
+				{X: 0.0005, Y: 0, R: 1},
+			},
+			Indices: []uint32{0, 1, 2, 3, 4, 5, 6, 4, 3},
+		}
+
+		if merged := (&Mesh{Vertices: mesh.Vertices, Indices: append([]uint32(nil), mesh.Indices...)}).Weld(0); merged != 0 {
+			t.Errorf("expected no exact duplicates, got %d merged", merged)
+		}
+
+		if merged := mesh.Weld(0.001); merged != 2 {
+			t.Fatalf("expected 2 vertices merged, got %d", merged)
+		}
+		if len(mesh.Indices) != 9 || mesh.Indices[3] != 2 || mesh.Indices[4] != 1 || mesh.Indices[7] != 1 || mesh.Indices[8] != 2 {
+			t.Errorf("expected the second triangle to use the first corners, got %v", mesh.Indices)
+		}
+	})
+
+	t.Run("collapsed triangles", func(t *testing.T) {
+		mesh := &Mesh{
+			Vertices: []Vertex{{X: 0}, {X: 1}, {Y: 1}, {X: 1.0001}},
+			Indices:  []uint32{0, 2, 1, 0, 1, 3, 0, 2, 3},
+			Groups:   []MeshGroup{{Name: "a", Start: 0, Count: 6}, {Name: "b", Start: 6, Count: 3}},
+		}
+
+		mesh.Weld(0.001)
+		if len(mesh.Indices) != 6 {
+			t.Fatalf("expected the sliver between the close vertices to be dropped, got %v", mesh.Indices)
+		}
+		if mesh.Groups[0].Start != 0 || mesh.Groups[0].Count != 3 || mesh.Groups[1].Start != 3 || mesh.Groups[1].Count != 3 {
+			t.Errorf("expected the groups to shrink with their triangles, got %+v", mesh.Groups)
+		}
+	})
+}
+
+func TestOptimizeVertexCache(t *testing.T) {
+	mesh := shuffledSphere()
+	expected := triangleSet(mesh, 0, len(mesh.Indices))
+	before := mesh.ACMR(32)
+
+	mesh.OptimizeVertexCache(32)
+
+	sameTriangles(t, expected, triangleSet(mesh, 0, len(mesh.Indices)))
+	if after := mesh.ACMR(32); after >= before || after > 1 {
+		t.Errorf("expected the ACMR to drop from %v to below 1, got %v", before, after)
+	}
+}
+
+func TestOptimizeOverdraw(t *testing.T) {
+	mesh := shuffledSphere()
+	expected := triangleSet(mesh, 0, len(mesh.Indices))
+
+	mesh.OptimizeVertexCache(32)
+	cacheOrdered := mesh.ACMR(32)
+	mesh.OptimizeOverdraw(32, 1.05)
+
+	sameTriangles(t, expected, triangleSet(mesh, 0, len(mesh.Indices)))
+	if after := mesh.ACMR(32); after > cacheOrdered*1.05 {
+		t.Errorf("expected the ACMR to stay within 5 percent of %v, got %v", cacheOrdered, after)
+	}
+}
+
+func TestOptimizeVertexFetch(t *testing.T) {
+	mesh := shuffledSphere()
+	// a vertex no triangle uses is dropped
+	mesh.Vertices = append(mesh.Vertices, Vertex{X: 5})
+	expected := triangleSet(mesh, 0, len(mesh.Indices))
+
+	mesh.OptimizeVertexFetch()
+
+	sameTriangles(t, expected, triangleSet(mesh, 0, len(mesh.Indices)))
+
+	next := uint32(0)
+	for i, index := range mesh.Indices {
+		if index > next {
+			t.Fatalf("index %d: vertex %d is used before vertex %d", i, index, next)
+		}
+		if index == next {
+			next++
+		}
+	}
+	if int(next) != len(mesh.Vertices) {
+		t.Errorf("expected all %d vertices to be used, got %d", len(mesh.Vertices), next)
+	}
+}
+
+func TestOptimize(t *testing.T) {
+	mesh := shuffledSphere()
+	half := len(mesh.Indices) / 6 * 3
+	mesh.Groups = []MeshGroup{{Name: "top", Start: 0, Count: half}, {Name: "bottom", Start: half, Count: len(mesh.Indices) - half}}
+	expected := []map[[3]Vertex]int{triangleSet(mesh, 0, half), triangleSet(mesh, half, len(mesh.Indices)-half)}
+
+	stats := mesh.Optimize(DefaultOptimizeOptions())
+
+	if stats.ACMRAfter > stats.ACMRBefore || stats.ACMRAfter != mesh.ACMR(32) {
+		t.Errorf("expected the ACMR not to increase, got %v", stats)
+	}
+	if stats.TrianglesBefore != stats.TrianglesAfter || stats.VerticesAfter != len(mesh.Vertices) {
+		t.Errorf("unexpected stats %v", stats)
+	}
+
+	// the triangles stay in their groups
+	for i, group := range mesh.Groups {
+		sameTriangles(t, expected[i], triangleSet(mesh, group.Start, group.Count))
+	}
+}
@@ -0,0 +1,614 @@
+package opengl_exercise
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// plyScalar is a property type of a PLY file
+type plyScalar struct {
+	size   int
+	signed bool
+	float  bool
+}
+
+var plyScalars = map[string]plyScalar{
+	"char": {1, true, false}, "int8": {1, true, false},
+	"uchar": {1, false, false}, "uint8": {1, false, false},
+	"short": {2, true, false}, "int16": {2, true, false},
+	"ushort": {2, false, false}, "uint16": {2, false, false},
+	"int": {4, true, false}, "int32": {4, true, false},
+	"uint": {4, false, false}, "uint32": {4, false, false},
+	"float": {4, true, true}, "float32": {4, true, true},
+	"double": {8, true, true}, "float64": {8, true, true},
+}
+
+// normalize maps integer colors to 0..1 by the largest value of the type, floats are kept
+func (s plyScalar) normalize(value float64) float32 {
+	if s.float {
+		return float32(value)
+	}
+
+	bits := uint(s.size * 8)
+	if s.signed {
+		bits--
+	}
+	return float32(value / float64(uint64(1)<<bits-1))
+}
+
+// plyPreallocation limits the room reserved for counts read from a file, a damaged count must not reserve more memory
+// than the data behind it could fill, larger counts grow as the data is read
+const plyPreallocation = 4096
+
+type plyProperty struct {
+	name string
+	list bool
+
+	// count is the type of the list length, value the type of the property or list items
+	count plyScalar
+	value plyScalar
+}
+
+type plyElement struct {
+	name       string
+	count      int
+	properties []plyProperty
+}
+
+// LoadPLY reads an ASCII or binary PLY file
+func LoadPLY(path string) (*Mesh, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	mesh, err := ReadPLY(file)
+
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) && parseErr.File == "" {
+		parseErr.File = path
+	}
+
+	return mesh, err
+}
+
+// ReadPLY reads a PLY file in any of its encodings. Vertices use the x, y, z, nx, ny, nz, red, green, blue and s, t or u, v properties,
+// faces the vertex_indices list, everything else is skipped. Without normals in the file every triangle gets its own vertices
+// with the face normal, colors default to white.
+//
+// PLY files are converted to the left-handed space like OBJ files, z is negated and the triangle corners are swapped.
+func ReadPLY(r io.Reader) (*Mesh, error) {
+	p := &plyReader{reader: bufio.NewReader(r)}
+
+	elements, err := p.readHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	vertexCount := -1
+	for _, element := range elements {
+		if element.name == "vertex" {
+			vertexCount = element.count
+		}
+	}
+	if vertexCount < 0 {
+		return nil, errors.New("ply: file has no vertex element")
+	}
+
+	capacity := vertexCount
+	if capacity > plyPreallocation {
+		capacity = plyPreallocation
+	}
+	vertices := make([]Vertex, 0, capacity)
+	var triangles []uint32
+	hasNormals := false
+
+	for _, element := range elements {
+		for i := 0; i < element.count; i++ {
+			err := p.beginRow()
+			if err == nil {
+				switch element.name {
+				case "vertex":
+					var vertex Vertex
+					vertex, err = p.readVertex(element, &hasNormals)
+					vertices = append(vertices, vertex)
+				case "face":
+					triangles, err = p.readFace(element, vertexCount, triangles)
+				default:
+					err = p.skipRow(element)
+				}
+			}
+			if err == nil {
+				err = p.endRow()
+			}
+
+			if err != nil {
+				if p.encoding == EncodingASCII {
+					return nil, &ParseError{Line: p.line, Err: err}
+				}
+				return nil, fmt.Errorf("ply: %s %d: %v", element.name, i, err)
+			}
+		}
+	}
+
+	if hasNormals {
+		return &Mesh{Vertices: vertices, Indices: triangles}, nil
+	}
+
+	mesh := &Mesh{}
+	for i := 0; i+2 < len(triangles); i += 3 {
+		mesh.appendFlatTriangle(vertices[triangles[i]], vertices[triangles[i+1]], vertices[triangles[i+2]])
+	}
+
+	return mesh, nil
+}
+
+type plyReader struct {
+	reader   *bufio.Reader
+	encoding MeshEncoding
+	order    binary.ByteOrder
+
+	// line counts the lines read so far, fields holds the values left in the current ASCII line
+	line    int
+	fields  []string
+	scratch [8]byte
+}
+
+func (p *plyReader) readLine() (string, error) {
+	line, err := p.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return "", err
+	}
+
+	p.line++
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (p *plyReader) readHeader() ([]*plyElement, error) {
+	headerError := func(format string, args ...interface{}) error {
+		return &ParseError{Line: p.line, Err: fmt.Errorf(format, args...)}
+	}
+
+	line, err := p.readLine()
+	if err != nil || strings.TrimSpace(line) != "ply" {
+		return nil, errors.New("ply: file does not start with 'ply'")
+	}
+
+	var elements []*plyElement
+	hasFormat := false
+	for {
+		line, err := p.readLine()
+		if err != nil {
+			return nil, &ParseError{Line: p.line + 1, Err: errors.New("header has no 'end_header'")}
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "comment", "obj_info":
+
+		case "format":
+			if len(fields) != 3 {
+				return nil, headerError("format needs an encoding and a version")
+			}
+			switch fields[1] {
+			case "ascii":
+				p.encoding = EncodingASCII
+			case "binary_little_endian":
+				p.encoding, p.order = EncodingBinaryLittleEndian, binary.LittleEndian
+			case "binary_big_endian":
+				p.encoding, p.order = EncodingBinaryBigEndian, binary.BigEndian
+			default:
+				return nil, headerError("unknown format '%s'", fields[1])
+			}
+			if fields[2] != "1.0" {
+				return nil, headerError("unsupported version '%s'", fields[2])
+			}
+			hasFormat = true
+
+		case "element":
+			if len(fields) != 3 {
+				return nil, headerError("element needs a name and a count")
+			}
+			count, err := strconv.Atoi(fields[2])
+			if err != nil || count < 0 {
+				return nil, headerError("invalid element count '%s'", fields[2])
+			}
+			elements = append(elements, &plyElement{name: fields[1], count: count})
+
+		case "property":
+			if len(elements) == 0 {
+				return nil, headerError("property before the first element")
+			}
+			property, err := parsePLYProperty(fields[1:])
+			if err != nil {
+				return nil, &ParseError{Line: p.line, Err: err}
+			}
+			element := elements[len(elements)-1]
+			element.properties = append(element.properties, property)
+
+		case "end_header":
+			if !hasFormat {
+				return nil, headerError("header has no format")
+			}
+			return elements, nil
+
+		default:
+			return nil, headerError("unknown keyword '%s'", fields[0])
+		}
+	}
+}
+
+func parsePLYProperty(args []string) (plyProperty, error) {
+	scalar := func(name string) (plyScalar, error) {
+		s, ok := plyScalars[name]
+		if !ok {
+			return s, fmt.Errorf("unknown property type '%s'", name)
+		}
+		return s, nil
+	}
+
+	if len(args) > 0 && args[0] == "list" {
+		if len(args) != 4 {
+			return plyProperty{}, errors.New("list property needs a count type, an item type and a name")
+		}
+		count, err := scalar(args[1])
+		if err != nil {
+			return plyProperty{}, err
+		}
+		if count.float {
+			return plyProperty{}, fmt.Errorf("list count type '%s' is not an integer", args[1])
+		}
+		value, err := scalar(args[2])
+		if err != nil {
+			return plyProperty{}, err
+		}
+		return plyProperty{name: args[3], list: true, count: count, value: value}, nil
+	}
+
+	if len(args) != 2 {
+		return plyProperty{}, errors.New("property needs a type and a name")
+	}
+	value, err := scalar(args[0])
+	if err != nil {
+		return plyProperty{}, err
+	}
+	return plyProperty{name: args[1], value: value}, nil
+}
+
+// beginRow reads the line of the next ASCII element, binary elements are read as they go
+func (p *plyReader) beginRow() error {
+	if p.encoding != EncodingASCII {
+		return nil
+	}
+
+	for {
+		line, err := p.readLine()
+		if err != nil {
+			p.line++
+			return errors.New("file ends before all elements were read")
+		}
+		if p.fields = strings.Fields(line); len(p.fields) > 0 {
+			return nil
+		}
+	}
+}
+
+func (p *plyReader) endRow() error {
+	if len(p.fields) > 0 {
+		return fmt.Errorf("%d values too many", len(p.fields))
+	}
+	return nil
+}
+
+func (p *plyReader) value(s plyScalar) (float64, error) {
+	if p.encoding == EncodingASCII {
+		if len(p.fields) == 0 {
+			return 0, errors.New("too few values")
+		}
+		field := p.fields[0]
+		p.fields = p.fields[1:]
+
+		var value float64
+		var err error
+		switch {
+		case s.float:
+			value, err = strconv.ParseFloat(field, 64)
+		case s.signed:
+			var i int64
+			i, err = strconv.ParseInt(field, 10, s.size*8)
+			value = float64(i)
+		default:
+			var u uint64
+			u, err = strconv.ParseUint(field, 10, s.size*8)
+			value = float64(u)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("invalid number '%s'", field)
+		}
+		return value, nil
+	}
+
+	buffer := p.scratch[:s.size]
+	if _, err := io.ReadFull(p.reader, buffer); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+
+	switch {
+	case s.float && s.size == 4:
+		return float64(math.Float32frombits(p.order.Uint32(buffer))), nil
+	case s.float:
+		return math.Float64frombits(p.order.Uint64(buffer)), nil
+	case s.size == 1 && s.signed:
+		return float64(int8(buffer[0])), nil
+	case s.size == 1:
+		return float64(buffer[0]), nil
+	case s.size == 2 && s.signed:
+		return float64(int16(p.order.Uint16(buffer))), nil
+	case s.size == 2:
+		return float64(p.order.Uint16(buffer)), nil
+	case s.signed:
+		return float64(int32(p.order.Uint32(buffer))), nil
+	default:
+		return float64(p.order.Uint32(buffer)), nil
+	}
+}
+
+// list reads the length of a list property followed by its items
+func (p *plyReader) list(property plyProperty) ([]float64, error) {
+	count, err := p.value(property.count)
+	if err != nil {
+		return nil, err
+	}
+	if count < 0 {
+		return nil, fmt.Errorf("negative list length %v", count)
+	}
+	if p.encoding == EncodingASCII && count > float64(len(p.fields)) {
+		return nil, fmt.Errorf("list length %v is more than the %d values left", count, len(p.fields))
+	}
+
+	// binary lists end at the end of the file at the latest, so only the items read are allocated
+	items := make([]float64, 0, int(math.Min(count, plyPreallocation)))
+	for len(items) < int(count) {
+		item, err := p.value(property.value)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (p *plyReader) skipRow(element *plyElement) error {
+	for _, property := range element.properties {
+		var err error
+		if property.list {
+			_, err = p.list(property)
+		} else {
+			_, err = p.value(property.value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *plyReader) readVertex(element *plyElement, hasNormals *bool) (Vertex, error) {
+	vertex := Vertex{R: 1, G: 1, B: 1}
+	for _, property := range element.properties {
+		if property.list {
+			if _, err := p.list(property); err != nil {
+				return vertex, err
+			}
+			continue
+		}
+
+		value, err := p.value(property.value)
+		if err != nil {
+			return vertex, err
+		}
+
+		switch property.name {
+		case "x":
+			vertex.X = float32(value)
+		case "y":
+			vertex.Y = float32(value)
+		case "z":
+			vertex.Z = -float32(value)
+		case "nx":
+			vertex.NX = float32(value)
+			*hasNormals = true
+		case "ny":
+			vertex.NY = float32(value)
+		case "nz":
+			vertex.NZ = -float32(value)
+		case "red", "diffuse_red":
+			vertex.R = property.value.normalize(value)
+		case "green", "diffuse_green":
+			vertex.G = property.value.normalize(value)
+		case "blue", "diffuse_blue":
+			vertex.B = property.value.normalize(value)
+		case "s", "u", "texture_u", "texture_s":
+			vertex.U = float32(value)
+		case "t", "v", "texture_v", "texture_t":
+			vertex.V = float32(value)
+		}
+	}
+
+	return vertex, nil
+}
+
+// readFace appends the face as a fan of triangles with swapped corners
+func (p *plyReader) readFace(element *plyElement, vertexCount int, triangles []uint32) ([]uint32, error) {
+	for _, property := range element.properties {
+		if !property.list {
+			if _, err := p.value(property.value); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		items, err := p.list(property)
+		if err != nil {
+			return nil, err
+		}
+		if property.name != "vertex_indices" && property.name != "vertex_index" {
+			continue
+		}
+
+		if len(items) < 3 {
+			return nil, fmt.Errorf("face needs at least 3 vertices, got %d", len(items))
+		}
+		corners := make([]uint32, len(items))
+		for i, item := range items {
+			if item < 0 || item >= float64(vertexCount) || item != math.Trunc(item) {
+				return nil, fmt.Errorf("vertex index %v out of range", item)
+			}
+			corners[i] = uint32(item)
+		}
+
+		for i := 1; i+1 < len(corners); i++ {
+			triangles = append(triangles, corners[0], corners[i+1], corners[i])
+		}
+	}
+
+	return triangles, nil
+}
+
+// WritePLY writes a mesh as PLY with positions, colors, and normals and texture coordinates when the mesh has them
+func WritePLY(w io.Writer, mesh *Mesh, encoding MeshEncoding) error {
+	writer := bufio.NewWriter(w)
+	p := &plyWriter{writer: writer}
+
+	format := "ascii"
+	switch encoding {
+	case EncodingASCII:
+	case EncodingBinaryLittleEndian:
+		format, p.order = "binary_little_endian", binary.LittleEndian
+	case EncodingBinaryBigEndian:
+		format, p.order = "binary_big_endian", binary.BigEndian
+	default:
+		return fmt.Errorf("ply: unknown encoding %d", encoding)
+	}
+
+	hasNormals, hasTexcoords := false, false
+	for _, v := range mesh.Vertices {
+		hasNormals = hasNormals || v.NX != 0 || v.NY != 0 || v.NZ != 0
+		hasTexcoords = hasTexcoords || v.U != 0 || v.V != 0
+	}
+
+	// header
+	fmt.Fprintln(writer, "ply")
+	fmt.Fprintf(writer, "format %s 1.0\n", format)
+	fmt.Fprintf(writer, "element vertex %d\n", len(mesh.Vertices))
+	properties := []string{"float x", "float y", "float z"}
+	if hasNormals {
+		properties = append(properties, "float nx", "float ny", "float nz")
+	}
+	properties = append(properties, "uchar red", "uchar green", "uchar blue")
+	if hasTexcoords {
+		properties = append(properties, "float s", "float t")
+	}
+	for _, property := range properties {
+		fmt.Fprintf(writer, "property %s\n", property)
+	}
+	fmt.Fprintf(writer, "element face %d\n", len(mesh.Indices)/3)
+	fmt.Fprintln(writer, "property list uchar int vertex_indices")
+	fmt.Fprintln(writer, "end_header")
+
+	channel := func(value float32) uint8 {
+		return uint8(math.Round(math.Max(0, math.Min(1, float64(value))) * 255))
+	}
+
+	// the inverse of the conversion done by ReadPLY
+	for _, v := range mesh.Vertices {
+		p.float(v.X)
+		p.float(v.Y)
+		p.float(-v.Z)
+		if hasNormals {
+			p.float(v.NX)
+			p.float(v.NY)
+			p.float(-v.NZ)
+		}
+		p.uchar(channel(v.R))
+		p.uchar(channel(v.G))
+		p.uchar(channel(v.B))
+		if hasTexcoords {
+			p.float(v.U)
+			p.float(v.V)
+		}
+		p.endRow()
+	}
+
+	for i := 0; i+2 < len(mesh.Indices); i += 3 {
+		p.uchar(3)
+		p.int(int32(mesh.Indices[i]))
+		p.int(int32(mesh.Indices[i+2]))
+		p.int(int32(mesh.Indices[i+1]))
+		p.endRow()
+	}
+
+	return writer.Flush()
+}
+
+// plyWriter writes element rows, ASCII rows are collected in fields when order is nil
+type plyWriter struct {
+	writer  *bufio.Writer
+	order   binary.ByteOrder
+	fields  []string
+	scratch [4]byte
+}
+
+func (p *plyWriter) float(value float32) {
+	if p.order == nil {
+		p.fields = append(p.fields, strconv.FormatFloat(float64(value), 'g', -1, 32))
+		return
+	}
+	p.order.PutUint32(p.scratch[:], math.Float32bits(value))
+	p.writer.Write(p.scratch[:])
+}
+
+func (p *plyWriter) uchar(value uint8) {
+	if p.order == nil {
+		p.fields = append(p.fields, strconv.Itoa(int(value)))
+		return
+	}
+	p.writer.WriteByte(value)
+}
+
+func (p *plyWriter) int(value int32) {
+	if p.order == nil {
+		p.fields = append(p.fields, strconv.Itoa(int(value)))
+		return
+	}
+	p.order.PutUint32(p.scratch[:], uint32(value))
+	p.writer.Write(p.scratch[:])
+}
+
+func (p *plyWriter) endRow() {
+	if p.order == nil {
+		p.writer.WriteString(strings.Join(p.fields, " "))
+		p.writer.WriteByte('\n')
+		p.fields = p.fields[:0]
+	}
+}
@@ -0,0 +1,159 @@
+package opengl_exercise
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPLYRoundTrip(t *testing.T) {
+	// shared vertices with normals, colors and texture coordinates
+	mesh := &Mesh{
+		Vertices: []Vertex{
+			{X: 0, Y: 0, Z: 1, R: 1, G: 0, B: 0, NX: 0, NY: 0, NZ: -1, U: 0, V: 0},
+			{X: 0, Y: 1, Z: 1, R: 0, G: 1, B: 0, NX: 0, NY: 0, NZ: -1, U: 0, V: 1},
+			{X: 1, Y: 0, Z: 1, R: 0, G: 0, B: 1, NX: 0, NY: 0, NZ: -1, U: 1, V: 0},
+			{X: 1, Y: 1, Z: 1.5, R: 1, G: 1, B: 1, NX: 0.6, NY: 0, NZ: -0.8, U: 1, V: 1},
+		},
+		Indices: []uint32{0, 1, 2, 2, 1, 3},
+	}
+
+	encodings := map[string]MeshEncoding{
+		"ascii":                EncodingASCII,
+		"binary little endian": EncodingBinaryLittleEndian,
+		"binary big endian":    EncodingBinaryBigEndian,
+	}
+
+	for name, encoding := range encodings {
+		t.Run(name, func(t *testing.T) {
+			var buffer bytes.Buffer
+			if err := WritePLY(&buffer, mesh, encoding); err != nil {
+				t.Fatal(err)
+			}
+
+			read, err := ReadPLY(&buffer)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(read.Vertices) != len(mesh.Vertices) {
+				t.Fatalf("expected %d vertices, got %d", len(mesh.Vertices), len(read.Vertices))
+			}
+			for i, vertex := range mesh.Vertices {
+				if read.Vertices[i] != vertex {
+					t.Errorf("vertex %d: expected %+v, got %+v", i, vertex, read.Vertices[i])
+				}
+			}
+
+			if len(read.Indices) != len(mesh.Indices) {
+				t.Fatalf("expected indices %v, got %v", mesh.Indices, read.Indices)
+			}
+			for i, index := range mesh.Indices {
+				if read.Indices[i] != index {
+					t.Fatalf("expected indices %v, got %v", mesh.Indices, read.Indices)
+				}
+			}
+		})
+	}
+}
+
+func TestReadPLY(t *testing.T) {
+	// a counter clockwise quad facing +z, with properties and elements that are skipped
+	const data = `ply
+format ascii 1.0
+comment made by hand
+element vertex 4
+property float x
+property float y
+property float z
+property uchar red
+property uchar green
+property uchar blue
+property uchar alpha
+property list uchar int neighbours
+element face 1
+property uchar flags
+property list uchar uint vertex_indices
+element edge 1
+property int vertex1
+property int vertex2
+end_header
+0 0 0 255 0 0 255 0
+1 0 0 255 0 0 255 1 0
+1 1 0 255 0 0 255 0
+
+0 1 0 255 0 0 255 0
+7 4 0 1 2 3
+0 1
+`
+
+	mesh, err := ReadPLY(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without normals in the file the two triangles get their own vertices
+	if len(mesh.Vertices) != 6 || len(mesh.Indices) != 6 {
+		t.Fatalf("expected 6 vertices and indices, got %d and %d", len(mesh.Vertices), len(mesh.Indices))
+	}
+
+	for i := 0; i < len(mesh.Indices); i += 3 {
+		v0, v1, v2 := mesh.Vertices[mesh.Indices[i]], mesh.Vertices[mesh.Indices[i+1]], mesh.Vertices[mesh.Indices[i+2]]
+		if normal := (Vector{v0.NX, v0.NY, v0.NZ}); normal != (Vector{0, 0, -1}) {
+			t.Errorf("triangle %d: expected normal (0, 0, -1), got %+v", i/3, normal)
+		}
+		edge1 := Vector{v1.X - v0.X, v1.Y - v0.Y, v1.Z - v0.Z}
+		edge2 := Vector{v2.X - v0.X, v2.Y - v0.Y, v2.Z - v0.Z}
+		if face := edge1.Cross(edge2); face.Z >= 0 {
+			t.Errorf("triangle %d is not clockwise around its normal: %+v", i/3, face)
+		}
+		if color := (Vector{v0.R, v0.G, v0.B}); color != (Vector{1, 0, 0}) {
+			t.Errorf("triangle %d: expected red, got %+v", i/3, color)
+		}
+	}
+}
+
+func TestReadPLYInvalid(t *testing.T) {
+	const header = "ply\nformat ascii 1.0\nelement vertex 3\nproperty float x\nproperty float y\nproperty float z\nelement face 1\nproperty list uchar int vertex_indices\nend_header\n"
+
+	tests := []struct {
+		name  string
+		data  string
+		line  int
+		error string
+	}{
+		{"not ply", "solid\n", 0, "does not start with 'ply'"},
+		{"unknown format", "ply\nformat binary 1.0\n", 2, "unknown format"},
+		{"unknown type", "ply\nformat ascii 1.0\nelement vertex 1\nproperty float128 x\n", 4, "unknown property type"},
+		{"float list count", "ply\nformat ascii 1.0\nelement face 1\nproperty list float int vertex_indices\n", 4, "not an integer"},
+		{"property before element", "ply\nformat ascii 1.0\nproperty float x\n", 3, "before the first element"},
+		{"no end", "ply\nformat ascii 1.0\nelement vertex 0\n", 4, "no 'end_header'"},
+		{"no vertices", "ply\nformat ascii 1.0\nend_header\n", 0, "no vertex element"},
+		{"too few values", header + "0 0 0\n1 0\n", 11, "too few values"},
+		{"too many values", header + "0 0 0\n1 0 0 1\n", 11, "too many"},
+		{"invalid number", header + "0 0 0\n1 0 0\n0 one 0\n", 12, "invalid number"},
+		{"index out of range", header + "0 0 0\n1 0 0\n0 1 0\n3 0 1 3\n", 13, "out of range"},
+		{"two corners", header + "0 0 0\n1 0 0\n0 1 0\n2 0 1\n", 13, "at least 3 vertices"},
+		{"missing face", header + "0 0 0\n1 0 0\n0 1 0\n", 13, "file ends"},
+		{"short binary", "ply\nformat binary_little_endian 1.0\nelement vertex 1\nproperty float x\nend_header\n\x00\x00", 0, "vertex 0: unexpected EOF"},
+		{"long list", header + "0 0 0\n1 0 0\n0 1 0\n4 0 1 2\n", 13, "list length 4 is more than the 3 values left"},
+		// counts from the header are not trusted to reserve memory
+		{"huge vertex count", "ply\nformat binary_little_endian 1.0\nelement vertex 2000000000\nproperty float x\nend_header\n\x00\x00\x00\x00", 0, "vertex 1: unexpected EOF"},
+		{"huge binary list", "ply\nformat binary_big_endian 1.0\nelement vertex 0\nelement face 1\nproperty list uint int vertex_indices\nend_header\n\xff\xff\xff\xff\x00\x00\x00\x00", 0, "face 0: unexpected EOF"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ReadPLY(strings.NewReader(test.data))
+			if err == nil || !strings.Contains(err.Error(), test.error) {
+				t.Fatalf("expected error containing %q, got %v", test.error, err)
+			}
+
+			var parseErr *ParseError
+			if test.line > 0 && (!errors.As(err, &parseErr) || parseErr.Line != test.line) {
+				t.Errorf("expected the error on line %d, got %v", test.line, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,402 @@
+package opengl_exercise
+
+import (
+	"math"
+)
+
+// The primitive generators return white meshes centered on the origin, except for the arrow which starts there.
+// Their triangles are clockwise seen from outside and segment counts below the minimum are raised to it.
+
+// NewCubeMesh returns a cube with the given edge length, every face split into segments by segments quads
+func NewCubeMesh(size float32, segments int) *Mesh {
+	segments = atLeast(segments, 1)
+	half := size / 2
+
+	// the u and v axes of every face are chosen so that u cross v points out of the cube
+	faces := []struct{ normal, u, v Vector }{
+		{Vector{1, 0, 0}, Vector{0, 0, 1}, Vector{0, -1, 0}},
+		{Vector{-1, 0, 0}, Vector{0, 0, -1}, Vector{0, -1, 0}},
+		{Vector{0, 1, 0}, Vector{1, 0, 0}, Vector{0, 0, -1}},
+		{Vector{0, -1, 0}, Vector{1, 0, 0}, Vector{0, 0, 1}},
+		{Vector{0, 0, 1}, Vector{-1, 0, 0}, Vector{0, -1, 0}},
+		{Vector{0, 0, -1}, Vector{1, 0, 0}, Vector{0, -1, 0}},
+	}
+
+	mesh := &Mesh{}
+	for _, face := range faces {
+		face := face
+		mesh.appendGrid(segments, segments, func(column, row int) Vertex {
+			u, v := float32(column)/float32(segments), float32(row)/float32(segments)
+			position := face.normal.MultiplyScalar(half).
+				AddVector(face.u.MultiplyScalar((u - 0.5) * size)).
+				AddVector(face.v.MultiplyScalar((v - 0.5) * size))
+			return surfaceVertex(position, face.normal, u, 1-v)
+		})
+	}
+
+	return mesh
+}
+
+// NewPlaneMesh returns a grid on the xz plane facing up, with the given number of quads along x and z
+func NewPlaneMesh(width, depth float32, xSegments, zSegments int) *Mesh {
+	xSegments, zSegments = atLeast(xSegments, 1), atLeast(zSegments, 1)
+
+	// rows run from the far edge towards -z, so that x cross -z points up
+	mesh := &Mesh{}
+	mesh.appendGrid(xSegments, zSegments, func(column, row int) Vertex {
+		u, v := float32(column)/float32(xSegments), float32(row)/float32(zSegments)
+		position := Vector{X: (u - 0.5) * width, Z: (0.5 - v) * depth}
+		return surfaceVertex(position, Vector{Y: 1}, u, 1-v)
+	})
+
+	return mesh
+}
+
+// NewUVSphereMesh returns a sphere made of segments slices around the y axis and rings stacked from pole to pole
+func NewUVSphereMesh(radius float32, segments, rings int) *Mesh {
+	segments, rings = atLeast(segments, 3), atLeast(rings, 2)
+
+	profile := make([]latheProfile, rings+1)
+	for i := range profile {
+		v := float64(i) / float64(rings)
+		sin, cos := math.Sincos(v * math.Pi)
+		if i == 0 || i == rings {
+			// keep the poles on the axis, so the triangles there collapse and are dropped
+			sin = 0
+		}
+		profile[i] = latheProfile{
+			radius: radius * float32(sin), y: radius * float32(cos),
+			normalRadius: float32(sin), normalY: float32(cos),
+			v: 1 - float32(v),
+		}
+	}
+
+	mesh := &Mesh{}
+	mesh.appendLathe(segments, profile)
+	return mesh
+}
+
+// NewIcosphereMesh returns a sphere made by splitting the faces of an icosahedron subdivisions times, which spreads the vertices evenly
+func NewIcosphereMesh(radius float32, subdivisions int) *Mesh {
+	subdivisions = atLeast(subdivisions, 0)
+
+	t := float32((1 + math.Sqrt(5)) / 2)
+	positions := []Vector{
+		{-1, t, 0}, {1, t, 0}, {-1, -t, 0}, {1, -t, 0},
+		{0, -1, t}, {0, 1, t}, {0, -1, -t}, {0, 1, -t},
+		{t, 0, -1}, {t, 0, 1}, {-t, 0, -1}, {-t, 0, 1},
+	}
+	for i := range positions {
+		positions[i] = positions[i].Normalize()
+	}
+
+	// the corners are ordered so that (b-a) cross (c-a) points outwards
+	triangles := []uint32{
+		0, 11, 5, 0, 5, 1, 0, 1, 7, 0, 7, 10, 0, 10, 11,
+		1, 5, 9, 5, 11, 4, 11, 10, 2, 10, 7, 6, 7, 1, 8,
+		3, 9, 4, 3, 4, 2, 3, 2, 6, 3, 6, 8, 3, 8, 9,
+		4, 9, 5, 2, 4, 11, 6, 2, 10, 8, 6, 7, 9, 8, 1,
+	}
+
+	for i := 0; i < subdivisions; i++ {
+		midpoints := map[[2]uint32]uint32{}
+		midpoint := func(a, b uint32) uint32 {
+			key := [2]uint32{a, b}
+			if a > b {
+				key = [2]uint32{b, a}
+			}
+			if index, ok := midpoints[key]; ok {
+				return index
+			}
+
+			index := uint32(len(positions))
+			positions = append(positions, positions[a].AddVector(positions[b]).Normalize())
+			midpoints[key] = index
+			return index
+		}
+
+		subdivided := make([]uint32, 0, len(triangles)*4)
+		for j := 0; j < len(triangles); j += 3 {
+			a, b, c := triangles[j], triangles[j+1], triangles[j+2]
+			ab, bc, ca := midpoint(a, b), midpoint(b, c), midpoint(c, a)
+			subdivided = append(subdivided, a, ab, ca, b, bc, ab, c, ca, bc, ab, bc, ca)
+		}
+		triangles = subdivided
+	}
+
+	mesh := &Mesh{Indices: triangles}
+	for _, normal := range positions {
+		u := 0.5 + float32(math.Atan2(float64(normal.Z), float64(normal.X))/(2*math.Pi))
+		v := 0.5 + float32(math.Asin(float64(normal.Y))/math.Pi)
+		mesh.Vertices = append(mesh.Vertices, surfaceVertex(normal.MultiplyScalar(radius), normal, u, v))
+	}
+
+	// triangles crossing the seam at u = 0 get copies of their low u corners moved past 1,
+	// corners on a pole get a copy with the u of the rest of their triangle
+	pole := func(index uint32) bool {
+		vertex := mesh.Vertices[index]
+		return vertex.NX == 0 && vertex.NZ == 0
+	}
+	seam := map[uint32]uint32{}
+	for i := 0; i < len(mesh.Indices); i += 3 {
+		corners := mesh.Indices[i : i+3]
+		low, high := float32(1), float32(0)
+		for _, index := range corners {
+			if pole(index) {
+				continue
+			}
+			u := mesh.Vertices[index].U
+			if u < low {
+				low = u
+			}
+			if u > high {
+				high = u
+			}
+		}
+
+		var sum float32
+		for j, index := range corners {
+			if high-low > 0.5 && !pole(index) && mesh.Vertices[index].U < 0.5 {
+				copied, ok := seam[index]
+				if !ok {
+					vertex := mesh.Vertices[index]
+					vertex.U++
+					copied = uint32(len(mesh.Vertices))
+					mesh.Vertices = append(mesh.Vertices, vertex)
+					seam[index] = copied
+				}
+				corners[j] = copied
+			}
+			if !pole(index) {
+				sum += mesh.Vertices[corners[j]].U
+			}
+		}
+
+		for j, index := range corners {
+			if pole(index) {
+				vertex := mesh.Vertices[index]
+				vertex.U = sum / 2
+				corners[j] = uint32(len(mesh.Vertices))
+				mesh.Vertices = append(mesh.Vertices, vertex)
+			}
+		}
+	}
+
+	return mesh
+}
+
+// NewCylinderMesh returns a capped cylinder along the y axis
+func NewCylinderMesh(radius, height float32, segments int) *Mesh {
+	segments = atLeast(segments, 3)
+	top, bottom := height/2, -height/2
+
+	mesh := &Mesh{}
+	mesh.appendCap(segments, radius, top, true)
+	mesh.appendLathe(segments, []latheProfile{
+		{radius: radius, y: top, normalRadius: 1, v: 1},
+		{radius: radius, y: bottom, normalRadius: 1, v: 0},
+	})
+	mesh.appendCap(segments, radius, bottom, false)
+	return mesh
+}
+
+// NewConeMesh returns a cone along the y axis with its tip at the top and a capped base
+func NewConeMesh(radius, height float32, segments int) *Mesh {
+	segments = atLeast(segments, 3)
+
+	mesh := &Mesh{}
+	mesh.appendCone(segments, radius, height/2, -height/2)
+	mesh.appendCap(segments, radius, -height/2, false)
+	return mesh
+}
+
+// NewCapsuleMesh returns a cylinder along the y axis closed by two half spheres, height is the distance between their centers.
+// Every half sphere is made of rings rings.
+func NewCapsuleMesh(radius, height float32, segments, rings int) *Mesh {
+	segments, rings = atLeast(segments, 3), atLeast(rings, 1)
+
+	// one profile from the top pole to the bottom pole, the texture is spread by the length along it
+	total := height + radius*math.Pi
+	var profile []latheProfile
+	for i := 0; i <= 2*rings+1; i++ {
+		// the two rings at the equators of the half spheres share an angle and bound the cylinder part
+		ring := i
+		if i > rings {
+			ring--
+		}
+		angle := float64(ring) / float64(2*rings) * math.Pi
+		sin, cos := math.Sincos(angle)
+		if ring == 0 || ring == 2*rings {
+			sin = 0
+		}
+
+		center, arc := height/2, float32(angle)*radius
+		if i > rings {
+			center, arc = -height/2, arc+height
+		}
+
+		profile = append(profile, latheProfile{
+			radius: radius * float32(sin), y: center + radius*float32(cos),
+			normalRadius: float32(sin), normalY: float32(cos),
+			v: 1 - arc/total,
+		})
+	}
+
+	mesh := &Mesh{}
+	mesh.appendLathe(segments, profile)
+	return mesh
+}
+
+// NewTorusMesh returns a ring around the y axis, majorRadius is the distance from the center to the middle of the tube
+func NewTorusMesh(majorRadius, minorRadius float32, majorSegments, minorSegments int) *Mesh {
+	majorSegments, minorSegments = atLeast(majorSegments, 3), atLeast(minorSegments, 3)
+
+	// rows walk around the tube downwards on the outside, like the profile of a lathe
+	mesh := &Mesh{}
+	mesh.appendGrid(majorSegments, minorSegments, func(column, row int) Vertex {
+		u, v := float64(column)/float64(majorSegments), float64(row)/float64(minorSegments)
+		sinTheta, cosTheta := math.Sincos(u * 2 * math.Pi)
+		sinPhi, cosPhi := math.Sincos(-v * 2 * math.Pi)
+
+		normal := Vector{float32(cosPhi * cosTheta), float32(sinPhi), float32(cosPhi * sinTheta)}
+		ring := float64(majorRadius) + float64(minorRadius)*cosPhi
+		position := Vector{float32(ring * cosTheta), minorRadius * float32(sinPhi), float32(ring * sinTheta)}
+		return surfaceVertex(position, normal, float32(u), 1-float32(v))
+	})
+
+	return mesh
+}
+
+// NewArrowMesh returns an arrow pointing up the y axis from the origin, made of a shaft and a cone shaped head
+func NewArrowMesh(length, shaftRadius, headLength, headRadius float32, segments int) *Mesh {
+	segments = atLeast(segments, 3)
+	if headLength > length {
+		headLength = length
+	}
+	shaftTop := length - headLength
+
+	mesh := &Mesh{}
+	mesh.appendCone(segments, headRadius, length, shaftTop)
+
+	// the underside of the head is a ring around the shaft
+	mesh.appendLathe(segments, []latheProfile{
+		{radius: headRadius, y: shaftTop, normalY: -1, v: 1},
+		{radius: shaftRadius, y: shaftTop, normalY: -1, v: 0},
+	})
+	mesh.appendLathe(segments, []latheProfile{
+		{radius: shaftRadius, y: shaftTop, normalRadius: 1, v: 1},
+		{radius: shaftRadius, y: 0, normalRadius: 1, v: 0},
+	})
+	mesh.appendCap(segments, shaftRadius, 0, false)
+	return mesh
+}
+
+func atLeast(value, minimum int) int {
+	if value < minimum {
+		return minimum
+	}
+	return value
+}
+
+func surfaceVertex(position, normal Vector, u, v float32) Vertex {
+	return Vertex{
+		X: position.X, Y: position.Y, Z: position.Z,
+		R: 1, G: 1, B: 1,
+		NX: normal.X, NY: normal.Y, NZ: normal.Z,
+		U: u, V: v,
+	}
+}
+
+// appendGrid adds a grid of columns by rows quads. The surface has to be laid out so that the column direction crossed
+// with the row direction points to its front, then the triangles are clockwise seen from there.
+// Triangles that collapse to a line, like the ones at the poles of a sphere, are left out.
+func (m *Mesh) appendGrid(columns, rows int, vertex func(column, row int) Vertex) {
+	base := uint32(len(m.Vertices))
+	for row := 0; row <= rows; row++ {
+		for column := 0; column <= columns; column++ {
+			m.Vertices = append(m.Vertices, vertex(column, row))
+		}
+	}
+
+	index := func(column, row int) uint32 {
+		return base + uint32(row*(columns+1)+column)
+	}
+	triangle := func(a, b, c uint32) {
+		position := func(i uint32) Vector {
+			return Vector{m.Vertices[i].X, m.Vertices[i].Y, m.Vertices[i].Z}
+		}
+		if position(a) == position(b) || position(b) == position(c) || position(c) == position(a) {
+			return
+		}
+		m.Indices = append(m.Indices, a, b, c)
+	}
+
+	for row := 0; row < rows; row++ {
+		for column := 0; column < columns; column++ {
+			topLeft, topRight := index(column, row), index(column+1, row)
+			bottomLeft, bottomRight := index(column, row+1), index(column+1, row+1)
+			triangle(topLeft, topRight, bottomLeft)
+			triangle(topRight, bottomRight, bottomLeft)
+		}
+	}
+}
+
+// latheProfile is a point of a profile rotated around the y axis, with the normal it gets at angle zero
+type latheProfile struct {
+	radius, y             float32
+	normalRadius, normalY float32
+
+	// v is the texture coordinate along the profile
+	v float32
+}
+
+// appendLathe rotates a profile around the y axis. The profile has to run downwards along the front of the surface,
+// for example from the top pole of a sphere to the bottom one or from the rim of a bottom cap to its center.
+func (m *Mesh) appendLathe(segments int, profile []latheProfile) {
+	m.appendGrid(segments, len(profile)-1, func(column, row int) Vertex {
+		u := float32(column) / float32(segments)
+		sin, cos := math.Sincos(float64(u) * 2 * math.Pi)
+		if column == segments {
+			// close the seam exactly
+			sin, cos = 0, 1
+		}
+
+		point := profile[row]
+		position := Vector{point.radius * float32(cos), point.y, point.radius * float32(sin)}
+		normal := Vector{point.normalRadius * float32(cos), point.normalY, point.normalRadius * float32(sin)}.Normalize()
+		return surfaceVertex(position, normal, u, point.v)
+	})
+}
+
+// appendCap adds a disc facing up or down, with the texture laid flat across it
+func (m *Mesh) appendCap(segments int, radius, y float32, up bool) {
+	profile := []latheProfile{{y: y, normalY: 1}, {radius: radius, y: y, normalY: 1}}
+	if !up {
+		profile = []latheProfile{{radius: radius, y: y, normalY: -1}, {y: y, normalY: -1}}
+	}
+
+	start := len(m.Vertices)
+	m.appendLathe(segments, profile)
+	if radius == 0 {
+		return
+	}
+
+	for i := start; i < len(m.Vertices); i++ {
+		vertex := &m.Vertices[i]
+		vertex.U = 0.5 + vertex.X/(2*radius)
+		vertex.V = 0.5 + vertex.Z/(2*radius)
+		if !up {
+			vertex.V = 1 - vertex.V
+		}
+	}
+}
+
+// appendCone adds the side of a cone with its tip at top and its base at bottom
+func (m *Mesh) appendCone(segments int, radius, top, bottom float32) {
+	// the side normal leans up by the slope of the side
+	height := top - bottom
+	m.appendLathe(segments, []latheProfile{
+		{y: top, normalRadius: height, normalY: radius, v: 1},
+		{radius: radius, y: bottom, normalRadius: height, normalY: radius, v: 0},
+	})
+}
@@ -0,0 +1,88 @@
+package opengl_exercise
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPrimitiveWinding(t *testing.T) {
+	origin := func(Vector) Vector { return Vector{} }
+
+	tests := []struct {
+		name string
+		mesh *Mesh
+		// center returns the point of the shape a triangle around the given point faces away from
+		center func(Vector) Vector
+	}{
+		{"cube", NewCubeMesh(2, 3), origin},
+		{"plane", NewPlaneMesh(2, 3, 4, 5), func(p Vector) Vector { return Vector{p.X, -1, p.Z} }},
+		{"uv sphere", NewUVSphereMesh(1, 16, 8), origin},
+		{"icosphere", NewIcosphereMesh(1, 0), origin},
+		{"subdivided icosphere", NewIcosphereMesh(1, 2), origin},
+		{"cylinder", NewCylinderMesh(1, 2, 16), origin},
+		{"cone", NewConeMesh(1, 2, 16), origin},
+		{"capsule", NewCapsuleMesh(0.5, 2, 16, 4), func(p Vector) Vector {
+			// the nearest point of the segment between the centers of the half spheres
+			return Vector{Y: float32(math.Max(-1, math.Min(1, float64(p.Y))))}
+		}},
+		{"torus", NewTorusMesh(2, 0.5, 24, 12), func(p Vector) Vector {
+			// the middle of the tube
+			return direction(Vector{X: p.X, Z: p.Z}).MultiplyScalar(2)
+		}},
+		// inside the head, in front of the underside of the head and above the bottom cap
+		{"arrow", NewArrowMesh(2, 0.1, 0.5, 0.3, 16), func(Vector) Vector { return Vector{Y: 1.75} }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mesh := test.mesh
+			if len(mesh.Indices) == 0 || len(mesh.Indices)%3 != 0 {
+				t.Fatalf("expected whole triangles, got %d indices", len(mesh.Indices))
+			}
+
+			for i := 0; i < len(mesh.Indices); i += 3 {
+				corners := [3]Vertex{mesh.Vertices[mesh.Indices[i]], mesh.Vertices[mesh.Indices[i+1]], mesh.Vertices[mesh.Indices[i+2]]}
+				a, b, c := corners[0].position(), corners[1].position(), corners[2].position()
+				normal := triangleNormal(a, b, c)
+				centroid := a.AddVector(b).AddVector(c).MultiplyScalar(1.0 / 3)
+
+				if normal.Dot(centroid.AddVector(test.center(centroid).Negative())) <= 0 {
+					t.Fatalf("triangle %d at %+v faces inwards, its normal is %+v", i/3, centroid, normal)
+				}
+				for j, corner := range corners {
+					if normal.Dot(corner.Normal()) <= 0 {
+						t.Fatalf("triangle %d: corner %d has the normal %+v against the face normal %+v", i/3, j, corner.Normal(), normal)
+					}
+				}
+			}
+		})
+	}
+
+	t.Run("icosphere seam", func(t *testing.T) {
+		mesh := NewIcosphereMesh(1, 2)
+
+		// the copies made for the seam and the poles share the position and normal of their original
+		positions := map[Vector]Vector{}
+		for i, vertex := range mesh.Vertices {
+			if normal, ok := positions[vertex.position()]; ok && normal != vertex.Normal() {
+				t.Errorf("vertex %d: expected the normal %+v of the vertex it copies, got %+v", i, normal, vertex.Normal())
+			}
+			positions[vertex.position()] = vertex.Normal()
+		}
+		if len(positions) == len(mesh.Vertices) {
+			t.Fatal("expected copies of the vertices on the seam")
+		}
+
+		// no triangle wraps around the texture
+		for i := 0; i < len(mesh.Indices); i += 3 {
+			low, high := float32(2), float32(-1)
+			for _, index := range mesh.Indices[i : i+3] {
+				u := mesh.Vertices[index].U
+				low, high = float32(math.Min(float64(low), float64(u))), float32(math.Max(float64(high), float64(u)))
+			}
+			if high-low > 0.5 {
+				t.Errorf("triangle %d spans u from %v to %v", i/3, low, high)
+			}
+		}
+	})
+}
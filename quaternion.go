@@ -0,0 +1,228 @@
+package opengl_exercise
+
+import (
+	"math"
+)
+
+// Quaternion represents an orientation in the same left-handed, row-vector convention used by Vector and Matrix
+type Quaternion struct {
+	X, Y, Z, W float32
+}
+
+// IdentityQuaternion returns the quaternion that represents no rotation
+func IdentityQuaternion() Quaternion {
+	return Quaternion{W: 1}
+}
+
+// QuaternionFromAxisAngle builds a rotation of angle radians around the given axis
+func QuaternionFromAxisAngle(axis Vector, angle float32) Quaternion {
+	axis = axis.Normalize()
+	if axis == (Vector{}) {
+		return IdentityQuaternion()
+	}
+
+	s := float32(math.Sin(float64(angle) * 0.5))
+	c := float32(math.Cos(float64(angle) * 0.5))
+
+	return Quaternion{
+		X: axis.X * s,
+		Y: axis.Y * s,
+		Z: axis.Z * s,
+		W: c,
+	}
+}
+
+// QuaternionFromYawPitchRoll builds the same rotation as Camera.matrixRotationYawPitchRoll, angles are in radians
+func QuaternionFromYawPitchRoll(yaw, pitch, roll float32) Quaternion {
+	cYaw := float32(math.Cos(float64(yaw) * 0.5))
+	cPitch := float32(math.Cos(float64(pitch) * 0.5))
+	cRoll := float32(math.Cos(float64(roll) * 0.5))
+
+	sYaw := float32(math.Sin(float64(yaw) * 0.5))
+	sPitch := float32(math.Sin(float64(pitch) * 0.5))
+	sRoll := float32(math.Sin(float64(roll) * 0.5))
+
+	// roll around z first, then pitch around x, then yaw around y
+	return Quaternion{
+		X: cYaw*sPitch*cRoll + sYaw*cPitch*sRoll,
+		Y: sYaw*cPitch*cRoll - cYaw*sPitch*sRoll,
+		Z: cYaw*cPitch*sRoll - sYaw*sPitch*cRoll,
+		W: cYaw*cPitch*cRoll + sYaw*sPitch*sRoll,
+	}
+}
+
+// QuaternionFromEuler builds a quaternion from a rotation vector in degrees, the same format as Camera.Rotation
+func QuaternionFromEuler(degrees Vector) Quaternion {
+	radians := degrees.MultiplyScalar(math.Pi / 180)
+	return QuaternionFromYawPitchRoll(radians.Y, radians.X, radians.Z)
+}
+
+// QuaternionFromMatrix extracts the rotation from the upper 3x3 part of a matrix that has no scale
+func QuaternionFromMatrix(m *Matrix) Quaternion {
+	trace := m[0] + m[5] + m[10]
+
+	// pick the largest diagonal term to keep the square root away from zero
+	var q Quaternion
+	switch {
+	case trace > 0:
+		s := float32(math.Sqrt(float64(trace+1))) * 2
+		q = Quaternion{
+			X: (m[6] - m[9]) / s,
+			Y: (m[8] - m[2]) / s,
+			Z: (m[1] - m[4]) / s,
+			W: 0.25 * s,
+		}
+	case m[0] > m[5] && m[0] > m[10]:
+		s := float32(math.Sqrt(float64(1+m[0]-m[5]-m[10]))) * 2
+		q = Quaternion{
+			X: 0.25 * s,
+			Y: (m[1] + m[4]) / s,
+			Z: (m[8] + m[2]) / s,
+			W: (m[6] - m[9]) / s,
+		}
+	case m[5] > m[10]:
+		s := float32(math.Sqrt(float64(1+m[5]-m[0]-m[10]))) * 2
+		q = Quaternion{
+			X: (m[1] + m[4]) / s,
+			Y: 0.25 * s,
+			Z: (m[6] + m[9]) / s,
+			W: (m[8] - m[2]) / s,
+		}
+	default:
+		s := float32(math.Sqrt(float64(1+m[10]-m[0]-m[5]))) * 2
+		q = Quaternion{
+			X: (m[8] + m[2]) / s,
+			Y: (m[6] + m[9]) / s,
+			Z: 0.25 * s,
+			W: (m[1] - m[4]) / s,
+		}
+	}
+
+	return q.Normalize()
+}
+
+// Multiply returns the rotation q followed by the rotation r, which is the same order as Matrix.Multiply
+func (q Quaternion) Multiply(r Quaternion) Quaternion {
+	return Quaternion{
+		X: r.W*q.X + r.X*q.W + r.Y*q.Z - r.Z*q.Y,
+		Y: r.W*q.Y - r.X*q.Z + r.Y*q.W + r.Z*q.X,
+		Z: r.W*q.Z + r.X*q.Y - r.Y*q.X + r.Z*q.W,
+		W: r.W*q.W - r.X*q.X - r.Y*q.Y - r.Z*q.Z,
+	}
+}
+
+func (q Quaternion) Dot(r Quaternion) float32 {
+	return q.X*r.X + q.Y*r.Y + q.Z*r.Z + q.W*r.W
+}
+
+func (q Quaternion) Length() float32 {
+	return float32(math.Sqrt(float64(q.Dot(q))))
+}
+
+func (q Quaternion) Normalize() Quaternion {
+	length := q.Length()
+	if length < 0.000001 {
+		return IdentityQuaternion()
+	}
+
+	return Quaternion{
+		X: q.X / length,
+		Y: q.Y / length,
+		Z: q.Z / length,
+		W: q.W / length,
+	}
+}
+
+// Conjugate returns the inverse rotation of a unit quaternion
+func (q Quaternion) Conjugate() Quaternion {
+	return Quaternion{X: -q.X, Y: -q.Y, Z: -q.Z, W: q.W}
+}
+
+// Rotate applies the rotation to a vector
+func (q Quaternion) Rotate(v Vector) Vector {
+	// v' = v + 2w(u x v) + 2u x (u x v), where u is the vector part of q
+	u := Vector{X: q.X, Y: q.Y, Z: q.Z}
+	t := u.Cross(v).MultiplyScalar(2)
+
+	return v.AddVector(t.MultiplyScalar(q.W)).AddVector(u.Cross(t))
+}
+
+// Matrix converts a unit quaternion into a rotation matrix
+func (q Quaternion) Matrix() Matrix {
+	xx, yy, zz := q.X*q.X, q.Y*q.Y, q.Z*q.Z
+	xy, xz, yz := q.X*q.Y, q.X*q.Z, q.Y*q.Z
+	wx, wy, wz := q.W*q.X, q.W*q.Y, q.W*q.Z
+
+	return Matrix{
+		1 - 2*(yy+zz), 2 * (xy + wz), 2 * (xz - wy), 0,
+		2 * (xy - wz), 1 - 2*(xx+zz), 2 * (yz + wx), 0,
+		2 * (xz + wy), 2 * (yz - wx), 1 - 2*(xx+yy), 0,
+		0, 0, 0, 1,
+	}
+}
+
+// AxisAngle returns the axis and the angle in radians of the rotation
+func (q Quaternion) AxisAngle() (axis Vector, angle float32) {
+	q = q.Normalize()
+	if q.W < 0 {
+		q = Quaternion{X: -q.X, Y: -q.Y, Z: -q.Z, W: -q.W}
+	}
+
+	angle = 2 * float32(math.Acos(math.Min(float64(q.W), 1)))
+	s := float32(math.Sqrt(math.Max(0, 1-float64(q.W*q.W))))
+	if s < 0.000001 {
+		// no rotation, any axis will do
+		return Vector{X: 1}, 0
+	}
+
+	return Vector{X: q.X / s, Y: q.Y / s, Z: q.Z / s}, angle
+}
+
+// YawPitchRoll returns the angles in radians that QuaternionFromYawPitchRoll would build this rotation from
+func (q Quaternion) YawPitchRoll() (yaw, pitch, roll float32) {
+	m := q.Normalize().Matrix()
+	return m.YawPitchRoll()
+}
+
+// Nlerp linearly interpolates between two rotations and normalizes the result, which is cheap but not constant speed
+func (q Quaternion) Nlerp(r Quaternion, t float32) Quaternion {
+	// take the shortest path around the sphere
+	if q.Dot(r) < 0 {
+		r = Quaternion{X: -r.X, Y: -r.Y, Z: -r.Z, W: -r.W}
+	}
+
+	return Quaternion{
+		X: q.X + (r.X-q.X)*t,
+		Y: q.Y + (r.Y-q.Y)*t,
+		Z: q.Z + (r.Z-q.Z)*t,
+		W: q.W + (r.W-q.W)*t,
+	}.Normalize()
+}
+
+// Slerp interpolates between two rotations at constant angular speed
+func (q Quaternion) Slerp(r Quaternion, t float32) Quaternion {
+	cosTheta := q.Dot(r)
+
+	// take the shortest path around the sphere
+	if cosTheta < 0 {
+		r = Quaternion{X: -r.X, Y: -r.Y, Z: -r.Z, W: -r.W}
+		cosTheta = -cosTheta
+	}
+
+	// fall back to nlerp when the rotations are nearly identical to avoid dividing by zero
+	if cosTheta > 0.9995 {
+		return q.Nlerp(r, t)
+	}
+
+	theta := math.Acos(float64(cosTheta))
+	sinTheta := math.Sin(theta)
+	s0 := float32(math.Sin((1-float64(t))*theta) / sinTheta)
+	s1 := float32(math.Sin(float64(t)*theta) / sinTheta)
+
+	return Quaternion{
+		X: q.X*s0 + r.X*s1,
+		Y: q.Y*s0 + r.Y*s1,
+		Z: q.Z*s0 + r.Z*s1,
+		W: q.W*s0 + r.W*s1,
+	}
+}
@@ -0,0 +1,75 @@
+package opengl_exercise
+
+import (
+	"math"
+)
+
+// Ray is a half line starting at Origin, points on it are Origin + Direction*t for t >= 0
+type Ray struct {
+	Origin    Vector
+	Direction Vector
+}
+
+// Point returns the point at distance t along the ray
+func (r Ray) Point(t float32) Vector {
+	return r.Origin.AddVector(r.Direction.MultiplyScalar(t))
+}
+
+// Transform moves the ray into another space, e.g. by the inverse world matrix to test it against a model.
+// The direction is not normalized again so distances along the transformed ray match the original one.
+func (r Ray) Transform(m *Matrix) Ray {
+	return Ray{
+		Origin:    m.TransformPoint(r.Origin),
+		Direction: m.TransformDirection(r.Direction),
+	}
+}
+
+// RayHit describes where a ray hit a triangle
+type RayHit struct {
+	// index of the triangle, the corners are indices[3*Triangle:3*Triangle+3]
+	Triangle int
+
+	// weights of the three triangle corners at the hit point, they add up to 1
+	Barycentric Vector
+
+	// distance along the ray to the hit point
+	Distance float32
+}
+
+// intersectTriangle implements the Möller–Trumbore ray/triangle test, both sides of the triangle are hit
+func intersectTriangle(ray Ray, v0, v1, v2 Vector) (t, u, v float32, hit bool) {
+	const epsilon = 1e-7
+
+	edge1 := v1.AddVector(v0.Negative())
+	edge2 := v2.AddVector(v0.Negative())
+
+	// a determinant near zero means the ray is parallel to the triangle plane
+	p := ray.Direction.Cross(edge2)
+	det := edge1.Dot(p)
+	if math.Abs(float64(det)) < epsilon {
+		return 0, 0, 0, false
+	}
+	invDet := 1 / det
+
+	// first barycentric coordinate, must be inside the 0 to 1 range
+	s := ray.Origin.AddVector(v0.Negative())
+	u = s.Dot(p) * invDet
+	if u < 0 || u > 1 {
+		return 0, 0, 0, false
+	}
+
+	// second barycentric coordinate, the two together can't exceed 1
+	q := s.Cross(edge1)
+	v = ray.Direction.Dot(q) * invDet
+	if v < 0 || u+v > 1 {
+		return 0, 0, 0, false
+	}
+
+	// distance along the ray, hits behind the origin don't count
+	t = edge2.Dot(q) * invDet
+	if t < 0 {
+		return 0, 0, 0, false
+	}
+
+	return t, u, v, true
+}
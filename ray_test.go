@@ -0,0 +1,156 @@
+package opengl_exercise
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIntersectTriangle(t *testing.T) {
+	// a triangle in the z=0 plane, u is the weight of v1 and v the weight of v2
+	v0, v1, v2 := Vector{0, 0, 0}, Vector{0, 1, 0}, Vector{1, 0, 0}
+
+	tests := []struct {
+		name    string
+		ray     Ray
+		hit     bool
+		t, u, v float32
+	}{
+		{"front side", Ray{Vector{0.25, 0.25, -5}, Vector{0, 0, 1}}, true, 5, 0.25, 0.25},
+		{"back side", Ray{Vector{0.25, 0.25, 5}, Vector{0, 0, -1}}, true, 5, 0.25, 0.25},
+		{"slanted", Ray{Vector{-0.5, 0.5, -1}, Vector{0.75, -0.25, 1}}, true, 1, 0.25, 0.25},
+		// t is measured in lengths of the direction
+		{"long direction", Ray{Vector{0.25, 0.25, -5}, Vector{0, 0, 2}}, true, 2.5, 0.25, 0.25},
+		{"corner", Ray{Vector{0, 1, -1}, Vector{0, 0, 1}}, true, 1, 1, 0},
+		{"past v1", Ray{Vector{0.25, -0.1, -5}, Vector{0, 0, 1}}, false, 0, 0, 0},
+		{"past v2", Ray{Vector{-0.1, 0.25, -5}, Vector{0, 0, 1}}, false, 0, 0, 0},
+		{"past the long edge", Ray{Vector{0.6, 0.6, -5}, Vector{0, 0, 1}}, false, 0, 0, 0},
+		{"parallel", Ray{Vector{0.25, 0.25, -1}, Vector{1, 0, 0}}, false, 0, 0, 0},
+		{"parallel in the plane", Ray{Vector{-1, 0.25, 0}, Vector{1, 0, 0}}, false, 0, 0, 0},
+		{"behind the origin", Ray{Vector{0.25, 0.25, 5}, Vector{0, 0, 1}}, false, 0, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			distance, u, v, hit := intersectTriangle(test.ray, v0, v1, v2)
+			if hit != test.hit {
+				t.Fatalf("expected hit %v, got %v at %v", test.hit, hit, distance)
+			}
+			if !hit {
+				return
+			}
+			if !vectorNearlyEqual(Vector{distance, u, v}, Vector{test.t, test.u, test.v}) {
+				t.Errorf("expected t %v, u %v and v %v, got %v, %v and %v", test.t, test.u, test.v, distance, u, v)
+			}
+
+			// the barycentric coordinates and the distance describe the same point
+			point := v0.MultiplyScalar(1 - u - v).AddVector(v1.MultiplyScalar(u)).AddVector(v2.MultiplyScalar(v))
+			if !vectorNearlyEqual(point, test.ray.Point(distance)) {
+				t.Errorf("expected the hit at %+v, got %+v", test.ray.Point(distance), point)
+			}
+		})
+	}
+}
+
+// testViewports returns the projections to pick through for a full window and for a viewport away from its corner
+func testViewports() []struct {
+	name       string
+	viewport   [4]int32
+	reversedZ  bool
+	projection Matrix
+} {
+	const fov, near, far = math.Pi / 3, 0.1, 100
+	return []struct {
+		name       string
+		viewport   [4]int32
+		reversedZ  bool
+		projection Matrix
+	}{
+		{"window", [4]int32{0, 0, 800, 600}, false, PerspectiveFovLHMatrix(fov, 800.0/600, near, far)},
+		{"viewport offset", [4]int32{400, 150, 320, 240}, false, PerspectiveFovLHMatrix(fov, 320.0/240, near, far)},
+		{"reversed z", [4]int32{0, 0, 800, 600}, true, PerspectiveFovReversedZLHMatrix(fov, 800.0/600, near, far)},
+		{"reversed z viewport offset", [4]int32{400, 150, 320, 240}, true, PerspectiveFovInfiniteReversedZLHMatrix(fov, 320.0/240, near)},
+	}
+}
+
+func TestUnproject(t *testing.T) {
+	view := LookAtLHMatrix(Vector{2, 3, -10}, Vector{0, 1, 0}, Vector{0, 1, 0})
+	points := []Vector{{0, 1, 0}, {-1.5, 0.5, 2}, {3, 4, -5}}
+
+	for _, test := range testViewports() {
+		t.Run(test.name, func(t *testing.T) {
+			o := NewOpenGL()
+			o.screenWidth, o.screenHeight = 800, 600
+			o.viewport = test.viewport
+			o.reversedZ = test.reversedZ
+
+			viewProjection := view.Multiply(&test.projection)
+			for _, point := range points {
+				window := o.WindowCoordinates(viewProjection.TransformPoint(point))
+				if window.X < float32(test.viewport[0]) || window.X > float32(test.viewport[0]+test.viewport[2]) ||
+					window.Y < float32(test.viewport[1]) || window.Y > float32(test.viewport[1]+test.viewport[3]) {
+					t.Errorf("%+v: expected window coordinates inside the viewport, got %+v", point, window)
+				}
+
+				unprojected, err := o.Unproject(window, view, test.projection)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if distance := unprojected.AddVector(point.Negative()).Length(); distance > 1e-2 {
+					t.Errorf("expected %+v, got %+v", point, unprojected)
+				}
+			}
+		})
+	}
+}
+
+func TestPickRay(t *testing.T) {
+	eye, target := Vector{2, 3, -10}, Vector{0, 1, 0}
+	view := LookAtLHMatrix(eye, target, Vector{0, 1, 0})
+	forward := direction(target.AddVector(eye.Negative()))
+
+	// a triangle around the target and its mirror image behind the camera
+	corners := [3]Vector{{-1, -1, 0}, {0, 1, 0}, {1, -1, 0}}
+	centroid := target.AddVector(Vector{Y: -1.0 / 3})
+	behind := eye.MultiplyScalar(2).AddVector(centroid.Negative())
+
+	for _, test := range testViewports() {
+		t.Run(test.name, func(t *testing.T) {
+			o := NewOpenGL()
+			o.screenWidth, o.screenHeight = 800, 600
+			o.viewport = test.viewport
+			o.reversedZ = test.reversedZ
+
+			// the pixel the centroid of the triangle is drawn to, measured from the top left like the mouse
+			viewProjection := view.Multiply(&test.projection)
+			window := o.WindowCoordinates(viewProjection.TransformPoint(centroid))
+			x, y := int(window.X), o.screenHeight-1-int(window.Y)
+
+			ray, err := o.pickRay(x, y, test.viewport, view, test.projection)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the ray starts on the near plane
+			if depth := ray.Origin.AddVector(eye.Negative()).Dot(forward); math.Abs(float64(depth-0.1)) > 1e-3 {
+				t.Errorf("expected the ray to start at depth 0.1, got %v", depth)
+			}
+			if length := ray.Direction.Length(); math.Abs(float64(length-1)) > matrixEpsilon {
+				t.Errorf("expected a unit direction, got length %v", length)
+			}
+
+			distance, _, _, hit := intersectTriangle(ray, target.AddVector(corners[0]), target.AddVector(corners[1]), target.AddVector(corners[2]))
+			if !hit {
+				t.Fatalf("expected the ray %+v to hit the triangle", ray)
+			}
+			// one pixel is about 5 cm at the distance of the triangle
+			if miss := ray.Point(distance).AddVector(centroid.Negative()).Length(); miss > 0.1 {
+				t.Errorf("expected the hit near %+v, got %+v", centroid, ray.Point(distance))
+			}
+
+			offset := behind.AddVector(centroid.Negative())
+			if _, _, _, hit := intersectTriangle(ray, target.AddVector(corners[0]).AddVector(offset), target.AddVector(corners[1]).AddVector(offset), target.AddVector(corners[2]).AddVector(offset)); hit {
+				t.Error("expected the triangle behind the camera not to be hit")
+			}
+		})
+	}
+}
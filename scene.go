@@ -0,0 +1,270 @@
+package opengl_exercise
+
+import (
+	"sort"
+	"time"
+)
+
+// Scene is a node hierarchy with meshes, cameras and animations, as loaded by LoadGLTF
+type Scene struct {
+	// Nodes holds every node of the file, Roots the top level nodes of the scene to show
+	Nodes []*SceneNode
+	Roots []*SceneNode
+
+	Meshes     []*Mesh
+	Materials  []*Material
+	Cameras    []*SceneCamera
+	Animations []*Animation
+
+	// Images are the texture images, material textures that are not stored in a file are named "image:<index>" into this list
+	Images []SceneImage
+}
+
+// SceneNode places a mesh or camera in the scene, its Transform is parented to the transform of its parent node
+type SceneNode struct {
+	Name      string
+	Transform *Transform
+	Parent    *SceneNode
+	Children  []*SceneNode
+
+	Mesh   *Mesh
+	Camera *SceneCamera
+
+	// Model is set by Scene.CreateModels for nodes with a mesh
+	Model *Model
+}
+
+// SceneCamera is a camera lens stored in a scene, placed by the node that refers to it
+type SceneCamera struct {
+	Name       string
+	Projection ProjectionMode
+
+	// FOV is the vertical field of view in radians, OrthoHeight the height of the orthographic view volume
+	FOV         float32
+	OrthoHeight float32
+
+	// Aspect is zero when the file leaves it to the viewport, Far is zero for an infinite perspective projection
+	Aspect float32
+	Near   float32
+	Far    float32
+
+	Node *SceneNode
+}
+
+// SceneImage is a texture image, either stored in a file given by URI or embedded as Data
+type SceneImage struct {
+	Name     string
+	URI      string
+	MimeType string
+	Data     []byte
+}
+
+// AnimationPath is the node property an animation channel changes
+type AnimationPath string
+
+const (
+	AnimationTranslation AnimationPath = "translation"
+	AnimationRotation    AnimationPath = "rotation"
+	AnimationScale       AnimationPath = "scale"
+	AnimationWeights     AnimationPath = "weights"
+)
+
+// AnimationInterpolation selects how an animation channel gets from one keyframe to the next
+type AnimationInterpolation string
+
+const (
+	AnimationLinear      AnimationInterpolation = "LINEAR"
+	AnimationStep        AnimationInterpolation = "STEP"
+	AnimationCubicSpline AnimationInterpolation = "CUBICSPLINE"
+)
+
+// Animation is a set of channels that play together
+type Animation struct {
+	Name     string
+	Channels []AnimationChannel
+}
+
+// AnimationChannel animates one property of a node
+type AnimationChannel struct {
+	Node          *SceneNode
+	Path          AnimationPath
+	Interpolation AnimationInterpolation
+
+	// Times are the keyframe times in seconds
+	Times []float32
+
+	// Values holds the keyframe values one after the other. Cubic splines store an in-tangent, the value and an out-tangent per keyframe.
+	Values []float32
+}
+
+// CreateModels uploads the meshes of the scene, one model per node with a mesh that moves with the node transform
+func (s *Scene) CreateModels() ([]*Model, error) {
+	var models []*Model
+	for _, node := range s.Nodes {
+		if node.Mesh == nil {
+			continue
+		}
+
+		model, err := NewModelFromMesh(node.Mesh)
+		if err != nil {
+			for _, model := range models {
+				model.Shutdown()
+			}
+			return nil, err
+		}
+
+		model.Transform = node.Transform
+		node.Model = model
+		models = append(models, model)
+	}
+
+	return models, nil
+}
+
+// FindNode returns the first node with the given name, or nil
+func (s *Scene) FindNode(name string) *SceneNode {
+	for _, node := range s.Nodes {
+		if node.Name == name {
+			return node
+		}
+	}
+
+	return nil
+}
+
+// Apply sets the camera lens and places the camera where the scene camera node is
+func (c *SceneCamera) Apply(camera *Camera) {
+	camera.Projection = c.Projection
+	camera.Near, camera.Far = c.Near, c.Far
+	if c.Projection == OrthographicProjection {
+		camera.OrthoHeight = c.OrthoHeight
+	} else {
+		camera.FOV = c.FOV
+	}
+	if c.Aspect > 0 {
+		camera.Aspect = c.Aspect
+	}
+
+	if c.Node == nil {
+		return
+	}
+
+	world := c.Node.Transform.WorldMatrix()
+	position, rotation, _, err := world.Decompose()
+	if err != nil {
+		return
+	}
+
+	camera.Position = position
+	camera.SetOrientation(rotation)
+}
+
+// Duration returns the time of the last keyframe of all channels
+func (a *Animation) Duration() time.Duration {
+	var last float32
+	for _, channel := range a.Channels {
+		if n := len(channel.Times); n > 0 && channel.Times[n-1] > last {
+			last = channel.Times[n-1]
+		}
+	}
+
+	return time.Duration(float64(last) * float64(time.Second))
+}
+
+// Apply moves the animated nodes to where they are at the given time
+func (a *Animation) Apply(at time.Duration) {
+	t := float32(at.Seconds())
+	for i := range a.Channels {
+		a.Channels[i].apply(t)
+	}
+}
+
+// components returns the number of values per keyframe
+func (c *AnimationChannel) components() int {
+	switch c.Path {
+	case AnimationTranslation, AnimationScale:
+		return 3
+	case AnimationRotation:
+		return 4
+	default:
+		// morph target weights are not supported
+		return 0
+	}
+}
+
+func (c *AnimationChannel) apply(t float32) {
+	components := c.components()
+	if components == 0 || c.Node == nil || len(c.Times) == 0 {
+		return
+	}
+
+	value := c.sample(t, components)
+	switch c.Path {
+	case AnimationTranslation:
+		c.Node.Transform.SetPosition(Vector{X: value[0], Y: value[1], Z: value[2]})
+	case AnimationScale:
+		c.Node.Transform.SetScale(Vector{X: value[0], Y: value[1], Z: value[2]})
+	case AnimationRotation:
+		c.Node.Transform.SetRotation(Quaternion{X: value[0], Y: value[1], Z: value[2], W: value[3]}.Normalize())
+	}
+}
+
+// sample interpolates the channel values at time t
+func (c *AnimationChannel) sample(t float32, components int) []float32 {
+	// cubic splines keep the tangents around every value
+	stride, offset := components, 0
+	if c.Interpolation == AnimationCubicSpline {
+		stride, offset = components*3, components
+	}
+
+	keyframe := func(i int) []float32 {
+		start := i*stride + offset
+		return c.Values[start : start+components]
+	}
+
+	count := len(c.Times)
+	next := sort.Search(count, func(i int) bool {
+		return c.Times[i] > t
+	})
+	if next == 0 {
+		return keyframe(0)
+	}
+	if next == count || c.Interpolation == AnimationStep {
+		return keyframe(next - 1)
+	}
+
+	previous := next - 1
+	dt := c.Times[next] - c.Times[previous]
+	s := (t - c.Times[previous]) / dt
+	v0, v1 := keyframe(previous), keyframe(next)
+	result := make([]float32, components)
+
+	switch {
+	case c.Interpolation == AnimationCubicSpline:
+		// hermite spline between the values with the out-tangent of the first and the in-tangent of the second keyframe
+		outTangent := c.Values[previous*stride+2*components : previous*stride+3*components]
+		inTangent := c.Values[next*stride : next*stride+components]
+
+		s2, s3 := s*s, s*s*s
+		h00 := 2*s3 - 3*s2 + 1
+		h10 := s3 - 2*s2 + s
+		h01 := -2*s3 + 3*s2
+		h11 := s3 - s2
+		for i := range result {
+			result[i] = h00*v0[i] + h10*dt*outTangent[i] + h01*v1[i] + h11*dt*inTangent[i]
+		}
+
+	case c.Path == AnimationRotation:
+		q0 := Quaternion{X: v0[0], Y: v0[1], Z: v0[2], W: v0[3]}
+		q1 := Quaternion{X: v1[0], Y: v1[1], Z: v1[2], W: v1[3]}
+		q := q0.Slerp(q1, s)
+		result[0], result[1], result[2], result[3] = q.X, q.Y, q.Z, q.W
+
+	default:
+		for i := range result {
+			result[i] = v0[i] + (v1[i]-v0[i])*s
+		}
+	}
+
+	return result
+}
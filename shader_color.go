@@ -19,6 +19,7 @@ type ColorShader struct {
 	shaderProgram uint32
 }
 
+// NewColorShader builds the color shader, it draws models of any vertex layout
 func NewColorShader() (*ColorShader, error) {
 	shader := &ColorShader{
 		vertexShaderPath: "../shaders/color.vs",
@@ -44,6 +45,9 @@ func (s *ColorShader) Shutdown() {
 func (s *ColorShader) SetShader() {
 	// install the shader program as part of the current rendering state
 	gl.UseProgram(s.shaderProgram)
+
+	// layouts without colors read the constant value of the color input, which draws them white
+	gl.VertexAttrib3f(uint32(SemanticColor.Location()), 1, 1, 1)
 }
 
 func (s *ColorShader) SetShaderParams(worldMatrix, viewMatrix, projectionMatrix Matrix) error {
@@ -130,9 +134,11 @@ func (s *ColorShader) initializeShader() error {
 	gl.AttachShader(s.shaderProgram, s.vertexShader)
 	gl.AttachShader(s.shaderProgram, s.pixelShader)
 
-	// bind the shader input variables
-	s.bindAttrib(0, "inputPosition")
-	s.bindAttrib(1, "inputColor")
+	// bind the shader input variables to the locations of their semantics, the same ones models upload them to.
+	// Inputs the shader does not declare are ignored.
+	for location, semantic := range vertexSemantics {
+		s.bindAttrib(uint32(location), VertexAttribute{Semantic: semantic}.Name())
+	}
 
 	// link the shader program
 	gl.LinkProgram(s.shaderProgram)
@@ -0,0 +1,509 @@
+package opengl_exercise
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"unsafe"
+
+	"github.com/nullbus/opengl_exercise/gl"
+)
+
+// LODOptions configures the chain of simplified levels built by GenerateLODs
+type LODOptions struct {
+	// Levels is the largest number of simplified levels after the full detail one
+	Levels int
+
+	// Reduction is the share of the triangles of a level the next one keeps, 0.5 halves them every level
+	Reduction float32
+
+	// MaxError ends the chain once a level gets further than this from the full detail surface, in model units.
+	// Zero means no limit.
+	MaxError float32
+}
+
+// DefaultLODOptions builds up to 4 levels that halve the triangles each time
+func DefaultLODOptions() LODOptions {
+	return LODOptions{
+		Levels:    4,
+		Reduction: 0.5,
+	}
+}
+
+// MeshLOD is a simplified level of a mesh, its indices use the vertices of the mesh it was made from
+type MeshLOD struct {
+	Indices []uint32
+	Groups  []MeshGroup
+
+	// Error is an estimate of how far the simplified surface is from the original one, in model units
+	Error float32
+}
+
+// boundaryWeight makes moving away from an open edge cost more than moving away from a face
+const boundaryWeight = 10
+
+// simplifyFlipLimit is the smallest cosine between a triangle normal before and after a collapse
+const simplifyFlipLimit = 0.5
+
+// vertex kinds for simplification
+const (
+	simplifyInterior = iota
+	simplifyBorder
+	simplifyLocked
+)
+
+// quadric is the symmetric 4x4 matrix that sums the weighted squared distances to a set of planes, followed by the total weight
+type quadric [11]float64
+
+func planeQuadric(normal, point Vector, weight float64) quadric {
+	a, b, c := float64(normal.X), float64(normal.Y), float64(normal.Z)
+	d := -(a*float64(point.X) + b*float64(point.Y) + c*float64(point.Z))
+
+	return quadric{
+		a * a * weight, a * b * weight, a * c * weight, a * d * weight,
+		b * b * weight, b * c * weight, b * d * weight,
+		c * c * weight, c * d * weight,
+		d * d * weight,
+		weight,
+	}
+}
+
+func (q *quadric) add(other quadric) {
+	for i := range q {
+		q[i] += other[i]
+	}
+}
+
+// error returns the weighted mean of the squared distances from a point to the planes
+func (q *quadric) error(point Vector) float64 {
+	if q[10] <= 0 {
+		return 0
+	}
+
+	x, y, z := float64(point.X), float64(point.Y), float64(point.Z)
+	sum := q[0]*x*x + 2*q[1]*x*y + 2*q[2]*x*z + 2*q[3]*x +
+		q[4]*y*y + 2*q[5]*y*z + 2*q[6]*y +
+		q[7]*z*z + 2*q[8]*z +
+		q[9]
+
+	// rounding can take the sum just below zero
+	return math.Max(sum/q[10], 0)
+}
+
+// Simplify collapses edges in the order of the least quadric error until the mesh has no more than targetTriangles triangles
+// or the next collapse would move the surface further than maxError, zero means no limit.
+// Vertices only move onto their neighbours, so the result shares the vertices of the mesh.
+// Open edges stay in place and only shorten along themselves. Vertices that share their position with other vertices,
+// like the ones on uv seams, and vertices used by several groups do not move at all. Weld the mesh first so that
+// duplicates without a seam are not mistaken for one.
+func (m *Mesh) Simplify(targetTriangles int, maxError float32) MeshLOD {
+	return simplifyIndices(vertexPositions(m.Vertices), m.Indices, m.Groups, targetTriangles, maxError)
+}
+
+// GenerateLODs simplifies the mesh level by level, see LODOptions. Every level is made from the one before, the full detail
+// mesh is not part of the result. The chain ends early once simplification stops making progress.
+func (m *Mesh) GenerateLODs(options LODOptions) []MeshLOD {
+	return lodChain(vertexPositions(m.Vertices), m.Indices, m.Groups, options)
+}
+
+func lodChain(positions []Vector, indices []uint32, groups []MeshGroup, options LODOptions) []MeshLOD {
+	reduction := options.Reduction
+	if reduction <= 0 || reduction >= 1 {
+		reduction = 0.5
+	}
+
+	var lods []MeshLOD
+	previous := MeshLOD{Indices: indices, Groups: groups}
+	for level := 0; level < options.Levels; level++ {
+		triangles := len(previous.Indices) / 3
+		target := int(float32(triangles) * reduction)
+
+		// the errors of the levels add up, since each one only knows the surface of the one before
+		var remaining float32
+		if options.MaxError > 0 {
+			remaining = options.MaxError - previous.Error
+			if remaining <= 0 {
+				break
+			}
+		}
+
+		lod := simplifyIndices(positions, previous.Indices, previous.Groups, target, remaining)
+		lod.Error += previous.Error
+
+		// stop when locked vertices or the error limit keep the level from getting noticeably smaller
+		if len(lod.Indices)/3 > triangles-(triangles-target)/4 || len(lod.Indices) == 0 {
+			break
+		}
+
+		lods = append(lods, lod)
+		previous = lod
+	}
+
+	return lods
+}
+
+// simplifyIndices is Mesh.Simplify working on positions only, so that models uploaded in any vertex layout can use it
+func simplifyIndices(positions []Vector, indices []uint32, groups []MeshGroup, targetTriangles int, maxError float32) MeshLOD {
+	ranges := (&Mesh{Indices: indices, Groups: groups}).ranges()
+
+	var triangles [][3]uint32
+	var triangleGroups []int
+	for g, group := range ranges {
+		for i := group.Start; i+2 < group.Start+group.Count; i += 3 {
+			triangles = append(triangles, [3]uint32{indices[i], indices[i+1], indices[i+2]})
+			triangleGroups = append(triangleGroups, g)
+		}
+	}
+
+	// vertices at the same position as another one are on a seam and vertices used by two groups are on a group border
+	locked := make([]bool, len(positions))
+	vertexGroups := make([]int, len(positions))
+	for i := range vertexGroups {
+		vertexGroups[i] = -1
+	}
+	firstAt := map[Vector]uint32{}
+	for t, triangle := range triangles {
+		for _, index := range triangle {
+			if first, found := firstAt[positions[index]]; !found {
+				firstAt[positions[index]] = index
+			} else if first != index {
+				locked[first], locked[index] = true, true
+			}
+
+			if vertexGroups[index] >= 0 && vertexGroups[index] != triangleGroups[t] {
+				locked[index] = true
+			}
+			vertexGroups[index] = triangleGroups[t]
+		}
+	}
+
+	// the quadrics start with the planes of the triangles and of the open edges around every vertex, weighted by their size
+	quadrics := make([]quadric, len(positions))
+	edges := countEdges(triangles, nil)
+	for _, triangle := range triangles {
+		face := triangleFace(positions, triangle)
+		normal := direction(face)
+		plane := planeQuadric(normal, positions[triangle[0]], float64(face.Length())/2)
+		for k, index := range triangle {
+			quadrics[index].add(plane)
+
+			a, b := triangle[k], triangle[(k+1)%3]
+			if edges[edgeKey(a, b)] == 1 {
+				// a plane through the edge and perpendicular to the triangle keeps the edge where it is
+				edge := positions[b].AddVector(positions[a].Negative())
+				border := direction(edge.Cross(normal))
+				edgePlane := planeQuadric(border, positions[a], float64(edge.Dot(edge))*boundaryWeight)
+				quadrics[a].add(edgePlane)
+				quadrics[b].add(edgePlane)
+			}
+		}
+	}
+
+	limit := math.Inf(1)
+	if maxError > 0 {
+		limit = float64(maxError) * float64(maxError)
+	}
+
+	dead := make([]bool, len(triangles))
+	alive := len(triangles)
+	var reached float64
+
+	// every pass collapses the cheapest edges that do not touch each other, then the adjacency is rebuilt
+	for alive > targetTriangles {
+		edges = countEdges(triangles, dead)
+		around := make([][]int, len(positions))
+		for t, triangle := range triangles {
+			if !dead[t] {
+				for _, index := range triangle {
+					around[index] = append(around[index], t)
+				}
+			}
+		}
+		kinds := vertexKinds(edges, locked)
+
+		type collapse struct {
+			from, to uint32
+			cost     float64
+		}
+		var collapses []collapse
+		for t, triangle := range triangles {
+			if dead[t] {
+				continue
+			}
+			for k := range triangle {
+				a, b := triangle[k], triangle[(k+1)%3]
+				open := edges[edgeKey(a, b)] == 1
+				for _, c := range []collapse{{from: a, to: b}, {from: b, to: a}} {
+					// border vertices may only slide along their open edges
+					if kinds[c.from] == simplifyLocked || (kinds[c.from] == simplifyBorder) != open {
+						continue
+					}
+					merged := quadrics[c.from]
+					merged.add(quadrics[c.to])
+					c.cost = merged.error(positions[c.to])
+					collapses = append(collapses, c)
+				}
+			}
+		}
+		sort.Slice(collapses, func(i, j int) bool {
+			if collapses[i].cost != collapses[j].cost {
+				return collapses[i].cost < collapses[j].cost
+			}
+			if collapses[i].from != collapses[j].from {
+				return collapses[i].from < collapses[j].from
+			}
+			return collapses[i].to < collapses[j].to
+		})
+
+		touched := make([]bool, len(positions))
+		collapsed := 0
+		for _, c := range collapses {
+			if c.cost > limit || alive <= targetTriangles {
+				break
+			}
+			if touched[c.from] || touched[c.to] {
+				continue
+			}
+			if !canCollapse(positions, triangles, dead, around, c.from, c.to, edges[edgeKey(c.from, c.to)] == 1) {
+				continue
+			}
+
+			// move the corners of from onto to, the triangles on the edge disappear
+			for _, t := range around[c.from] {
+				if dead[t] {
+					continue
+				}
+				for k, index := range triangles[t] {
+					touched[index] = true
+					if index == c.from {
+						triangles[t][k] = c.to
+					}
+				}
+				if triangles[t][0] == triangles[t][1] || triangles[t][1] == triangles[t][2] || triangles[t][2] == triangles[t][0] {
+					dead[t] = true
+					alive--
+				}
+			}
+
+			quadrics[c.to].add(quadrics[c.from])
+			reached = math.Max(reached, c.cost)
+			collapsed++
+		}
+
+		if collapsed == 0 {
+			break
+		}
+	}
+
+	// write the remaining triangles back group by group
+	lod := MeshLOD{
+		Indices: make([]uint32, 0, alive*3),
+		Error:   float32(math.Sqrt(reached)),
+	}
+	for g, group := range ranges {
+		start := len(lod.Indices)
+		for t, triangle := range triangles {
+			if !dead[t] && triangleGroups[t] == g {
+				lod.Indices = append(lod.Indices, triangle[0], triangle[1], triangle[2])
+			}
+		}
+		if len(groups) > 0 {
+			group.Start, group.Count = start, len(lod.Indices)-start
+			lod.Groups = append(lod.Groups, group)
+		}
+	}
+
+	return lod
+}
+
+func edgeKey(a, b uint32) [2]uint32 {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]uint32{a, b}
+}
+
+// countEdges returns how many live triangles use every edge
+func countEdges(triangles [][3]uint32, dead []bool) map[[2]uint32]int {
+	edges := make(map[[2]uint32]int, len(triangles)*3/2)
+	for t, triangle := range triangles {
+		if dead != nil && dead[t] {
+			continue
+		}
+		for k := range triangle {
+			edges[edgeKey(triangle[k], triangle[(k+1)%3])]++
+		}
+	}
+
+	return edges
+}
+
+// vertexKinds finds the vertices on open edges. Vertices where open edges meet in more than one chain or where more than two
+// triangles share an edge cannot move without tearing the surface.
+func vertexKinds(edges map[[2]uint32]int, locked []bool) []uint8 {
+	kinds := make([]uint8, len(locked))
+	openEdges := make([]int, len(locked))
+	for edge, count := range edges {
+		switch {
+		case count == 1:
+			openEdges[edge[0]]++
+			openEdges[edge[1]]++
+		case count > 2:
+			kinds[edge[0]], kinds[edge[1]] = simplifyLocked, simplifyLocked
+		}
+	}
+
+	for i := range kinds {
+		switch {
+		case locked[i] || kinds[i] == simplifyLocked || openEdges[i] > 2:
+			kinds[i] = simplifyLocked
+		case openEdges[i] > 0:
+			kinds[i] = simplifyBorder
+		}
+	}
+
+	return kinds
+}
+
+// canCollapse checks that moving from onto to keeps the surface a manifold and does not fold any triangle over
+func canCollapse(positions []Vector, triangles [][3]uint32, dead []bool, around [][]int, from, to uint32, open bool) bool {
+	// the ends of an edge may only share the neighbours across its triangles, otherwise the collapse pinches the surface
+	neighbours := map[uint32]bool{}
+	for _, t := range around[from] {
+		if !dead[t] {
+			for _, index := range triangles[t] {
+				neighbours[index] = true
+			}
+		}
+	}
+	shared := map[uint32]bool{}
+	for _, t := range around[to] {
+		if dead[t] {
+			continue
+		}
+		for _, index := range triangles[t] {
+			if index != from && index != to && neighbours[index] {
+				shared[index] = true
+			}
+		}
+	}
+	allowed := 2
+	if open {
+		allowed = 1
+	}
+	if len(shared) > allowed {
+		return false
+	}
+
+	for _, t := range around[from] {
+		triangle := triangles[t]
+		if dead[t] || triangle[0] == to || triangle[1] == to || triangle[2] == to {
+			continue
+		}
+
+		before := triangleFace(positions, triangle)
+		for k := range triangle {
+			if triangle[k] == from {
+				triangle[k] = to
+			}
+		}
+		after := triangleFace(positions, triangle)
+		if direction(before).Dot(direction(after)) < simplifyFlipLimit {
+			return false
+		}
+	}
+
+	return true
+}
+
+// triangleFace returns the cross product of two triangle edges, it points to the front and its length is twice the area
+func triangleFace(positions []Vector, triangle [3]uint32) Vector {
+	a, b, c := positions[triangle[0]], positions[triangle[1]], positions[triangle[2]]
+	return b.AddVector(a.Negative()).Cross(c.AddVector(a.Negative()))
+}
+
+// ModelLOD is a level of detail of a model, a range of its index buffer drawn with the vertices of the full detail level
+type ModelLOD struct {
+	Start, Count int
+	Groups       []MeshGroup
+
+	// Error is an estimate of how far the level is from the full detail surface, in model units
+	Error float32
+}
+
+// GenerateLODs builds simplified levels of the model, see Mesh.GenerateLODs, and uploads them after the full detail indices.
+// Render picks a level from them by the size of the model on screen.
+func (m *Model) GenerateLODs(options LODOptions) error {
+	if m.dynamic != nil {
+		return errors.New("dynamic models have no levels of detail")
+	}
+	if len(m.indices) == 0 || m.primitive != PrimitiveTriangles {
+		return errors.New("model has no triangles")
+	}
+
+	lods := []ModelLOD{{Start: 0, Count: len(m.indices), Groups: m.groups}}
+	indices := append([]uint32(nil), m.indices...)
+	for _, lod := range lodChain(m.positions, m.indices, m.groups, options) {
+		// the groups of the level point into the shared index buffer
+		start := len(indices)
+		for i := range lod.Groups {
+			lod.Groups[i].Start += start
+		}
+
+		lods = append(lods, ModelLOD{Start: start, Count: len(lod.Indices), Groups: lod.Groups, Error: lod.Error})
+		indices = append(indices, lod.Indices...)
+	}
+
+	// the element array binding is part of the vertex array object
+	gl.BindVertexArray(m.vertexArray)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.indexBuffer)
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*4 /*sizeof(uint32)*/, unsafe.Pointer(&indices[0]), gl.STATIC_DRAW)
+
+	m.lods = lods
+	return nil
+}
+
+// LODs returns the levels of detail of the model starting with the full detail one, or nil when GenerateLODs was not called
+func (m *Model) LODs() []ModelLOD {
+	return m.lods
+}
+
+// ScreenSize returns how much of the viewport height the bounding sphere of the model covers as seen by the camera,
+// using the view matrix of the last camera Render. The camera being inside the sphere counts as infinitely large.
+func (m *Model) ScreenSize(camera *Camera) float32 {
+	world := m.Transform.WorldMatrix()
+	sphere := m.boundingSphere.Transform(&world)
+	view := camera.ViewMatrix()
+	projection := camera.ProjectionMatrix()
+
+	// w is the view depth for perspective projections and 1 for orthographic ones
+	center := view.TransformPoint(sphere.Center)
+	w := center.Z*projection[11] + projection[15]
+	if w <= sphere.Radius*projection[11] {
+		return float32(math.Inf(1))
+	}
+
+	// the sphere covers 2 * radius * yScale / w of the 2 units of normalized device coordinates from bottom to top
+	return sphere.Radius * projection[5] / w
+}
+
+// SelectLOD returns the coarsest level of detail whose error covers no more than LODPixelError pixels of a viewport
+// viewportHeight pixels high
+func (m *Model) SelectLOD(camera *Camera, viewportHeight int32) int {
+	if len(m.lods) < 2 || m.boundingSphere.Radius <= 0 {
+		return 0
+	}
+
+	// the errors are in model units, the scale of the transform is part of the screen size of the sphere
+	pixelsPerUnit := m.ScreenSize(camera) * float32(viewportHeight) / (2 * m.boundingSphere.Radius)
+
+	selected := 0
+	for i, lod := range m.lods {
+		if lod.Error*pixelsPerUnit > m.LODPixelError {
+			break
+		}
+		selected = i
+	}
+
+	return selected
+}
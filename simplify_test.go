@@ -0,0 +1,241 @@
+package opengl_exercise
+
+import (
+	"math"
+	"testing"
+)
+
+// closedSphere returns an icosphere without texture coordinates, so no seam keeps its vertices from moving
+func closedSphere(subdivisions int) *Mesh {
+	mesh := NewIcosphereMesh(1, subdivisions)
+	for i := range mesh.Vertices {
+		mesh.Vertices[i].U, mesh.Vertices[i].V = 0, 0
+	}
+	mesh.Weld(0)
+
+	return mesh
+}
+
+// usedVertices returns the vertices the indices refer to
+func usedVertices(indices []uint32) map[uint32]bool {
+	used := map[uint32]bool{}
+	for _, index := range indices {
+		used[index] = true
+	}
+
+	return used
+}
+
+func TestSimplifyClosedSphere(t *testing.T) {
+	mesh := closedSphere(3)
+	triangles := len(mesh.Indices) / 3
+
+	lod := mesh.Simplify(triangles/4, 0)
+	if count := len(lod.Indices) / 3; count > triangles/4 || count < triangles/5 {
+		t.Fatalf("expected about %d triangles, got %d", triangles/4, count)
+	}
+	if lod.Error <= 0 || lod.Error > 0.1 {
+		t.Errorf("expected a small error above zero, got %v", lod.Error)
+	}
+
+	// the surface stays closed and every triangle faces out of the sphere
+	edges := map[[2]uint32]int{}
+	for i := 0; i < len(lod.Indices); i += 3 {
+		a, b, c := mesh.Vertices[lod.Indices[i]].position(), mesh.Vertices[lod.Indices[i+1]].position(), mesh.Vertices[lod.Indices[i+2]].position()
+		if normal := triangleNormal(a, b, c); normal.Dot(a.AddVector(b).AddVector(c)) <= 0 {
+			t.Errorf("triangle %d faces into the sphere", i/3)
+		}
+		for k := 0; k < 3; k++ {
+			edges[edgeKey(lod.Indices[i+k], lod.Indices[i+(k+1)%3])]++
+		}
+	}
+	for edge, count := range edges {
+		if count != 2 {
+			t.Fatalf("edge %v is used by %d triangles", edge, count)
+		}
+	}
+}
+
+func TestSimplifyOpenBorder(t *testing.T) {
+	mesh := NewPlaneMesh(2, 2, 8, 8)
+	lod := mesh.Simplify(2, 0)
+	if len(lod.Indices) != 6 || lod.Error != 0 {
+		t.Fatalf("expected a flat plane to become 2 triangles without error, got %d triangles and error %v", len(lod.Indices)/3, lod.Error)
+	}
+
+	// the outline did not move, so the area stays the same and every open edge is on a side of the square
+	var area float32
+	edges := map[[2]uint32]int{}
+	for i := 0; i < len(lod.Indices); i += 3 {
+		a, b, c := mesh.Vertices[lod.Indices[i]].position(), mesh.Vertices[lod.Indices[i+1]].position(), mesh.Vertices[lod.Indices[i+2]].position()
+		area += triangleFace(vertexPositions(mesh.Vertices), [3]uint32{lod.Indices[i], lod.Indices[i+1], lod.Indices[i+2]}).Length() / 2
+		if normal := triangleNormal(a, b, c); normal != (Vector{Y: 1}) {
+			t.Errorf("triangle %d: expected the normal (0, 1, 0), got %+v", i/3, normal)
+		}
+		for k := 0; k < 3; k++ {
+			edges[edgeKey(lod.Indices[i+k], lod.Indices[i+(k+1)%3])]++
+		}
+	}
+	if math.Abs(float64(area-4)) > 1e-5 {
+		t.Errorf("expected an area of 4, got %v", area)
+	}
+	for edge, count := range edges {
+		a, b := mesh.Vertices[edge[0]], mesh.Vertices[edge[1]]
+		onSide := (a.X == b.X && (a.X == 1 || a.X == -1)) || (a.Z == b.Z && (a.Z == 1 || a.Z == -1))
+		if count == 1 && !onSide {
+			t.Errorf("open edge from %+v to %+v is not on the outline", a.position(), b.position())
+		}
+	}
+}
+
+func TestSimplifyLockedVertices(t *testing.T) {
+	t.Run("uv seam", func(t *testing.T) {
+		mesh := NewUVSphereMesh(1, 32, 16)
+		mesh.Weld(0)
+
+		// vertices that share their position with another one are on the seam
+		at := map[Vector][]uint32{}
+		for index := range usedVertices(mesh.Indices) {
+			position := mesh.Vertices[index].position()
+			at[position] = append(at[position], index)
+		}
+
+		lod := mesh.Simplify(len(mesh.Indices)/3/4, 0)
+		if len(lod.Indices) >= len(mesh.Indices) {
+			t.Fatal("expected the sphere to be simplified")
+		}
+
+		used := usedVertices(lod.Indices)
+		for _, indices := range at {
+			for _, index := range indices {
+				if len(indices) > 1 && !used[index] {
+					t.Errorf("seam vertex %d at %+v was collapsed", index, mesh.Vertices[index].position())
+				}
+			}
+		}
+	})
+
+	t.Run("group border", func(t *testing.T) {
+		mesh := NewPlaneMesh(2, 2, 8, 8)
+		half := len(mesh.Indices) / 6 * 3
+		mesh.Groups = []MeshGroup{{Name: "a", Start: 0, Count: half}, {Name: "b", Start: half, Count: len(mesh.Indices) - half}}
+
+		shared := map[uint32]bool{}
+		first := usedVertices(mesh.Indices[:half])
+		for index := range usedVertices(mesh.Indices[half:]) {
+			if first[index] {
+				shared[index] = true
+			}
+		}
+
+		lod := mesh.Simplify(2, 0)
+		used := usedVertices(lod.Indices)
+		for index := range shared {
+			if !used[index] {
+				t.Errorf("vertex %d on the border between the groups was collapsed", index)
+			}
+		}
+
+		if len(lod.Groups) != 2 || lod.Groups[0].Start != 0 || lod.Groups[1].Start != lod.Groups[0].Count || lod.Groups[1].Start+lod.Groups[1].Count != len(lod.Indices) {
+			t.Errorf("expected the two groups to cover the indices, got %+v", lod.Groups)
+		}
+	})
+}
+
+func TestGenerateLODs(t *testing.T) {
+	mesh := closedSphere(4)
+
+	t.Run("chain", func(t *testing.T) {
+		lods := mesh.GenerateLODs(LODOptions{Levels: 4, Reduction: 0.5})
+		if len(lods) != 4 {
+			t.Fatalf("expected 4 levels, got %d", len(lods))
+		}
+
+		previous := MeshLOD{Indices: mesh.Indices}
+		for i, lod := range lods {
+			if len(lod.Indices)*2 > len(previous.Indices) {
+				t.Errorf("level %d: expected at most half of %d triangles, got %d", i+1, len(previous.Indices)/3, len(lod.Indices)/3)
+			}
+			if lod.Error < previous.Error {
+				t.Errorf("level %d: the error dropped from %v to %v", i+1, previous.Error, lod.Error)
+			}
+			previous = lod
+		}
+	})
+
+	t.Run("max error", func(t *testing.T) {
+		const maxError = 0.01
+		lods := mesh.GenerateLODs(LODOptions{Levels: 10, Reduction: 0.5, MaxError: maxError})
+		if len(lods) == 0 || len(lods) == 10 {
+			t.Fatalf("expected the error limit to end the chain early, got %d levels", len(lods))
+		}
+		for i, lod := range lods {
+			if lod.Error > maxError {
+				t.Errorf("level %d: error %v is above the limit", i+1, lod.Error)
+			}
+		}
+
+		// the error of a single simplification is limited the same way
+		if lod := mesh.Simplify(0, maxError); lod.Error > maxError || len(lod.Indices) == 0 {
+			t.Errorf("expected the simplification to stop at the error limit, got %d triangles with error %v", len(lod.Indices)/3, lod.Error)
+		}
+	})
+}
+
+func TestSelectLOD(t *testing.T) {
+	model := &Model{
+		Transform:      NewTransform(),
+		LODPixelError:  1,
+		boundingSphere: BoundingSphere{Radius: 1},
+		lods:           []ModelLOD{{}, {Error: 0.001}, {Error: 0.01}, {Error: 0.1}},
+	}
+	camera := NewCamera()
+
+	t.Run("screen size", func(t *testing.T) {
+		camera.Position = Vector{Z: -10}
+		camera.Render()
+
+		// a sphere of radius 1 at distance 10 covers radius / distance / tan(fov / 2) of the view height
+		expected := float32(1.0 / 10 / math.Tan(math.Pi/8))
+		if size := model.ScreenSize(camera); math.Abs(float64(size-expected)) > 1e-5 {
+			t.Errorf("expected a screen size of %v, got %v", expected, size)
+		}
+	})
+
+	t.Run("distance", func(t *testing.T) {
+		previous := -1
+		for _, distance := range []float32{0.5, 2, 10, 100, 1000, 10000} {
+			camera.Position = Vector{Z: -distance}
+			camera.Render()
+
+			lod := model.SelectLOD(camera, 600)
+			if lod < previous {
+				t.Errorf("distance %v: expected level %d or coarser, got %d", distance, previous, lod)
+			}
+			previous = lod
+		}
+		if previous != len(model.lods)-1 {
+			t.Errorf("expected the coarsest level far away, got %d", previous)
+		}
+
+		// inside the sphere the full detail is needed
+		camera.Position = Vector{}
+		camera.Render()
+		if lod := model.SelectLOD(camera, 600); lod != 0 {
+			t.Errorf("expected full detail inside the bounds, got level %d", lod)
+		}
+	})
+
+	t.Run("scale", func(t *testing.T) {
+		camera.Position = Vector{Z: -100}
+		camera.Render()
+		small := model.SelectLOD(camera, 600)
+
+		// a larger model covers more pixels at the same distance
+		model.Transform.SetScale(Vector{X: 10, Y: 10, Z: 10})
+		defer model.Transform.SetScale(Vector{X: 1, Y: 1, Z: 1})
+		if large := model.SelectLOD(camera, 600); large >= small {
+			t.Errorf("expected a more detailed level than %d for the scaled model, got %d", small, large)
+		}
+	})
+}
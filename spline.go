@@ -0,0 +1,260 @@
+package opengl_exercise
+
+import (
+	"math"
+	"sort"
+)
+
+// Curve is a path through space, t runs from 0 at the start to 1 at the end
+type Curve interface {
+	Point(t float32) Vector
+	Tangent(t float32) Vector
+}
+
+// CatmullRom evaluates the uniform Catmull-Rom segment between p1 and p2, p0 and p3 are the neighbouring points
+func CatmullRom(p0, p1, p2, p3 Vector, t float32) Vector {
+	t2 := t * t
+	t3 := t2 * t
+
+	return p0.MultiplyScalar(-0.5*t3 + t2 - 0.5*t).
+		AddVector(p1.MultiplyScalar(1.5*t3 - 2.5*t2 + 1)).
+		AddVector(p2.MultiplyScalar(-1.5*t3 + 2*t2 + 0.5*t)).
+		AddVector(p3.MultiplyScalar(0.5*t3 - 0.5*t2))
+}
+
+// CatmullRomTangent returns the derivative of CatmullRom with respect to t
+func CatmullRomTangent(p0, p1, p2, p3 Vector, t float32) Vector {
+	t2 := t * t
+
+	return p0.MultiplyScalar(-1.5*t2 + 2*t - 0.5).
+		AddVector(p1.MultiplyScalar(4.5*t2 - 5*t)).
+		AddVector(p2.MultiplyScalar(-4.5*t2 + 4*t + 0.5)).
+		AddVector(p3.MultiplyScalar(1.5*t2 - t))
+}
+
+// CubicBezier evaluates the cubic Bézier curve from p0 to p3 with control points p1 and p2
+func CubicBezier(p0, p1, p2, p3 Vector, t float32) Vector {
+	u := 1 - t
+
+	return p0.MultiplyScalar(u * u * u).
+		AddVector(p1.MultiplyScalar(3 * u * u * t)).
+		AddVector(p2.MultiplyScalar(3 * u * t * t)).
+		AddVector(p3.MultiplyScalar(t * t * t))
+}
+
+// CubicBezierTangent returns the derivative of CubicBezier with respect to t
+func CubicBezierTangent(p0, p1, p2, p3 Vector, t float32) Vector {
+	u := 1 - t
+
+	return p1.AddVector(p0.Negative()).MultiplyScalar(3 * u * u).
+		AddVector(p2.AddVector(p1.Negative()).MultiplyScalar(6 * u * t)).
+		AddVector(p3.AddVector(p2.Negative()).MultiplyScalar(3 * t * t))
+}
+
+// Hermite evaluates the cubic Hermite curve from p0 to p1 that leaves with tangent m0 and arrives with tangent m1
+func Hermite(p0, m0, p1, m1 Vector, t float32) Vector {
+	t2 := t * t
+	t3 := t2 * t
+
+	return p0.MultiplyScalar(2*t3 - 3*t2 + 1).
+		AddVector(m0.MultiplyScalar(t3 - 2*t2 + t)).
+		AddVector(p1.MultiplyScalar(-2*t3 + 3*t2)).
+		AddVector(m1.MultiplyScalar(t3 - t2))
+}
+
+// HermiteTangent returns the derivative of Hermite with respect to t
+func HermiteTangent(p0, m0, p1, m1 Vector, t float32) Vector {
+	t2 := t * t
+
+	return p0.MultiplyScalar(6*t2 - 6*t).
+		AddVector(m0.MultiplyScalar(3*t2 - 4*t + 1)).
+		AddVector(p1.MultiplyScalar(-6*t2 + 6*t)).
+		AddVector(m1.MultiplyScalar(3*t2 - 2*t))
+}
+
+// segment maps t over a path of count segments to the segment index and the t inside that segment
+func segment(t float32, count int) (int, float32) {
+	if t <= 0 {
+		return 0, 0
+	}
+	if t >= 1 {
+		return count - 1, 1
+	}
+
+	scaled := t * float32(count)
+	index := int(scaled)
+	return index, scaled - float32(index)
+}
+
+// CatmullRomSpline is a smooth path that passes through all of its points
+type CatmullRomSpline struct {
+	Points []Vector
+}
+
+// controlPoints returns the four points of a segment, repeating the end points for the first and last one
+func (s *CatmullRomSpline) controlPoints(index int) (p0, p1, p2, p3 Vector) {
+	last := len(s.Points) - 1
+	clamp := func(i int) Vector {
+		if i < 0 {
+			i = 0
+		}
+		if i > last {
+			i = last
+		}
+		return s.Points[i]
+	}
+
+	return clamp(index - 1), clamp(index), clamp(index + 1), clamp(index + 2)
+}
+
+func (s *CatmullRomSpline) Point(t float32) Vector {
+	switch len(s.Points) {
+	case 0:
+		return Vector{}
+	case 1:
+		return s.Points[0]
+	}
+
+	index, local := segment(t, len(s.Points)-1)
+	p0, p1, p2, p3 := s.controlPoints(index)
+	return CatmullRom(p0, p1, p2, p3, local)
+}
+
+func (s *CatmullRomSpline) Tangent(t float32) Vector {
+	if len(s.Points) < 2 {
+		return Vector{}
+	}
+
+	count := len(s.Points) - 1
+	index, local := segment(t, count)
+	p0, p1, p2, p3 := s.controlPoints(index)
+
+	// scale from segment t to path t
+	return CatmullRomTangent(p0, p1, p2, p3, local).MultiplyScalar(float32(count))
+}
+
+// BezierSpline is a chain of cubic Bézier segments. The points are laid out as start, control, control, end,
+// where each end point is also the start of the next segment, so there are 3*n+1 points for n segments.
+type BezierSpline struct {
+	Points []Vector
+}
+
+func (s *BezierSpline) segments() int {
+	return (len(s.Points) - 1) / 3
+}
+
+func (s *BezierSpline) Point(t float32) Vector {
+	count := s.segments()
+	if count < 1 {
+		if len(s.Points) > 0 {
+			return s.Points[0]
+		}
+		return Vector{}
+	}
+
+	index, local := segment(t, count)
+	p := s.Points[index*3:]
+	return CubicBezier(p[0], p[1], p[2], p[3], local)
+}
+
+func (s *BezierSpline) Tangent(t float32) Vector {
+	count := s.segments()
+	if count < 1 {
+		return Vector{}
+	}
+
+	index, local := segment(t, count)
+	p := s.Points[index*3:]
+	return CubicBezierTangent(p[0], p[1], p[2], p[3], local).MultiplyScalar(float32(count))
+}
+
+// HermiteSpline passes through its points with the tangent given for each of them
+type HermiteSpline struct {
+	Points   []Vector
+	Tangents []Vector
+}
+
+func (s *HermiteSpline) Point(t float32) Vector {
+	switch {
+	case len(s.Points) == 0:
+		return Vector{}
+	case len(s.Points) == 1 || len(s.Tangents) < len(s.Points):
+		return s.Points[0]
+	}
+
+	index, local := segment(t, len(s.Points)-1)
+	return Hermite(s.Points[index], s.Tangents[index], s.Points[index+1], s.Tangents[index+1], local)
+}
+
+func (s *HermiteSpline) Tangent(t float32) Vector {
+	if len(s.Points) < 2 || len(s.Tangents) < len(s.Points) {
+		return Vector{}
+	}
+
+	count := len(s.Points) - 1
+	index, local := segment(t, count)
+	return HermiteTangent(s.Points[index], s.Tangents[index], s.Points[index+1], s.Tangents[index+1], local).MultiplyScalar(float32(count))
+}
+
+// ArcLengthCurve reparameterizes a curve by distance travelled, so moving t at a constant rate moves along the curve at constant speed
+type ArcLengthCurve struct {
+	curve Curve
+
+	// cumulative length of the curve at evenly spaced values of the original t
+	lengths []float32
+}
+
+// NewArcLengthCurve measures the curve with the given number of straight line samples, more samples give a more even speed
+func NewArcLengthCurve(curve Curve, samples int) *ArcLengthCurve {
+	if samples < 1 {
+		samples = 1
+	}
+
+	lengths := make([]float32, samples+1)
+	previous := curve.Point(0)
+	for i := 1; i <= samples; i++ {
+		point := curve.Point(float32(i) / float32(samples))
+		step := point.AddVector(previous.Negative())
+		lengths[i] = lengths[i-1] + float32(math.Sqrt(float64(step.Dot(step))))
+		previous = point
+	}
+
+	return &ArcLengthCurve{
+		curve:   curve,
+		lengths: lengths,
+	}
+}
+
+// Length returns the approximate length of the whole curve
+func (c *ArcLengthCurve) Length() float32 {
+	return c.lengths[len(c.lengths)-1]
+}
+
+// Parameter returns the t of the original curve that is the given distance from the start
+func (c *ArcLengthCurve) Parameter(distance float32) float32 {
+	total := c.Length()
+	if total <= 0 || distance <= 0 {
+		return 0
+	}
+	if distance >= total {
+		return 1
+	}
+
+	// find the sample interval that contains the distance and interpolate inside it
+	index := sort.Search(len(c.lengths), func(i int) bool { return c.lengths[i] >= distance })
+	start, end := c.lengths[index-1], c.lengths[index]
+	fraction := (distance - start) / (end - start)
+
+	samples := float32(len(c.lengths) - 1)
+	return (float32(index-1) + fraction) / samples
+}
+
+// Point returns the point a fraction t of the curve length from the start
+func (c *ArcLengthCurve) Point(t float32) Vector {
+	return c.curve.Point(c.Parameter(t * c.Length()))
+}
+
+// Tangent returns the unit direction of travel at a fraction t of the curve length
+func (c *ArcLengthCurve) Tangent(t float32) Vector {
+	return c.curve.Tangent(c.Parameter(t * c.Length())).Normalize()
+}
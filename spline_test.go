@@ -0,0 +1,107 @@
+package opengl_exercise
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSplineEndpoints(t *testing.T) {
+	points := []Vector{{0, 0, 0}, {1, 2, 0}, {3, 2, 1}, {4, 0, -1}}
+	bezier := []Vector{{0, 0, 0}, {0, 1, 0}, {1, 2, 0}, {2, 2, 0}, {3, 2, 0}, {4, 1, 1}, {4, 0, 2}}
+
+	tests := []struct {
+		name  string
+		curve Curve
+		// the points the curve passes through, evenly spaced in t
+		through []Vector
+		// the tangents at the start and the end
+		start, end Vector
+	}{
+		// the end points are repeated, so the end tangents point at the neighbour with half the usual weight
+		{"catmull-rom", &CatmullRomSpline{Points: points}, points, points[1].MultiplyScalar(0.5 * 3), points[3].AddVector(points[2].Negative()).MultiplyScalar(0.5 * 3)},
+		// the tangents of a Bézier curve point at the control points
+		{"bezier", &BezierSpline{Points: bezier}, []Vector{bezier[0], bezier[3], bezier[6]}, bezier[1].AddVector(bezier[0].Negative()).MultiplyScalar(3 * 2), bezier[6].AddVector(bezier[5].Negative()).MultiplyScalar(3 * 2)},
+		{"hermite", &HermiteSpline{Points: points[:2], Tangents: []Vector{{1, 0, 0}, {0, 0, 5}}}, points[:2], Vector{1, 0, 0}, Vector{0, 0, 5}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i, expected := range test.through {
+				at := float32(i) / float32(len(test.through)-1)
+				if point := test.curve.Point(at); !vectorNearlyEqual(point, expected) {
+					t.Errorf("t %v: expected %+v, got %+v", at, expected, point)
+				}
+			}
+
+			// t is clamped to the curve
+			if point := test.curve.Point(-1); !vectorNearlyEqual(point, test.through[0]) {
+				t.Errorf("before the start: expected %+v, got %+v", test.through[0], point)
+			}
+			if point, last := test.curve.Point(2), test.through[len(test.through)-1]; !vectorNearlyEqual(point, last) {
+				t.Errorf("after the end: expected %+v, got %+v", last, point)
+			}
+
+			if tangent := test.curve.Tangent(0); !vectorNearlyEqual(tangent, test.start) {
+				t.Errorf("start: expected the tangent %+v, got %+v", test.start, tangent)
+			}
+			if tangent := test.curve.Tangent(1); !vectorNearlyEqual(tangent, test.end) {
+				t.Errorf("end: expected the tangent %+v, got %+v", test.end, tangent)
+			}
+
+			// the tangent is the derivative of the point with respect to t
+			const step = 1e-3
+			for _, at := range []float32{0.1, 0.3, 0.55, 0.9} {
+				difference := test.curve.Point(at + step).AddVector(test.curve.Point(at - step).Negative()).MultiplyScalar(1 / (2 * step))
+				tangent := test.curve.Tangent(at)
+				if difference.AddVector(tangent.Negative()).Length() > 0.01*tangent.Length() {
+					t.Errorf("t %v: expected the tangent %+v, got %+v", at, difference, tangent)
+				}
+			}
+		})
+	}
+}
+
+func TestArcLengthCurve(t *testing.T) {
+	// the control points bunch up at the start, so the curve crawls there and races at the end
+	curve := &BezierSpline{Points: []Vector{{0, 0, 0}, {0.01, 0, 0}, {0.02, 0.01, 0}, {10, 5, 0}}}
+	arcLength := NewArcLengthCurve(curve, 1000)
+
+	const steps = 20
+	speeds := func(c Curve) (min, max float32) {
+		min, max = float32(math.MaxFloat32), 0
+		previous := c.Point(0)
+		for i := 1; i <= steps; i++ {
+			point := c.Point(float32(i) / steps)
+			step := point.AddVector(previous.Negative()).Length()
+			min, max = float32(math.Min(float64(min), float64(step))), float32(math.Max(float64(max), float64(step)))
+			previous = point
+		}
+		return min, max
+	}
+
+	if min, max := speeds(curve); max < 10*min {
+		t.Fatalf("expected the original curve to change speed, got steps from %v to %v", min, max)
+	}
+
+	// equal steps of t cover equal distances along the curve
+	expected := arcLength.Length() / steps
+	if min, max := speeds(arcLength); min < 0.99*expected || max > 1.01*expected {
+		t.Errorf("expected steps of %v, got steps from %v to %v", expected, min, max)
+	}
+
+	if length := NewArcLengthCurve(&BezierSpline{Points: []Vector{{0, 0, 0}, {1, 0, 0}, {2, 0, 0}, {6, 0, 0}}}, 10).Length(); math.Abs(float64(length-6)) > matrixEpsilon {
+		t.Errorf("expected a straight curve of length 6, got %v", length)
+	}
+
+	if start, end := arcLength.Point(0), arcLength.Point(1); start != curve.Points[0] || !vectorNearlyEqual(end, curve.Points[3]) {
+		t.Errorf("expected the curve to run from %+v to %+v, got %+v to %+v", curve.Points[0], curve.Points[3], start, end)
+	}
+	for _, at := range []float32{0, 0.5, 1} {
+		if length := arcLength.Tangent(at).Length(); math.Abs(float64(length-1)) > matrixEpsilon {
+			t.Errorf("t %v: expected a unit tangent, got length %v", at, length)
+		}
+	}
+	if parameter := arcLength.Parameter(arcLength.Length() / 2); parameter <= 0.5 {
+		t.Errorf("expected the middle of the length past the middle of t, got %v", parameter)
+	}
+}
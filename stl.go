@@ -0,0 +1,257 @@
+package opengl_exercise
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+)
+
+const (
+	stlHeaderSize   = 80
+	stlTriangleSize = 50
+
+	// binary STL colors follow the VisCAM and SolidView convention, 5 bits per channel with bit 15 marking a valid color
+	stlColorValid = 1 << 15
+)
+
+// LoadSTL reads an ASCII or binary STL file
+func LoadSTL(path string) (*Mesh, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	mesh, err := ReadSTL(file)
+
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) && parseErr.File == "" {
+		parseErr.File = path
+	}
+
+	return mesh, err
+}
+
+// ReadSTL reads an ASCII or binary STL file. Every triangle gets its own vertices with the face normal calculated from its corners,
+// binary files can color the faces.
+//
+// STL files are converted to the left-handed space like OBJ files, z is negated and the triangle corners are swapped.
+func ReadSTL(r io.Reader) (*Mesh, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// binary files may start with "solid" as well, so the size decides
+	if len(data) >= stlHeaderSize+4 {
+		count := binary.LittleEndian.Uint32(data[stlHeaderSize:])
+		if uint64(len(data)) == stlHeaderSize+4+uint64(count)*stlTriangleSize {
+			return readBinarySTL(data[stlHeaderSize+4:], int(count)), nil
+		}
+	}
+
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("solid")) {
+		return nil, errors.New("stl: neither an ASCII file nor a binary file of the size given by its triangle count")
+	}
+
+	return readASCIISTL(bytes.NewReader(data))
+}
+
+func readBinarySTL(data []byte, count int) *Mesh {
+	mesh := &Mesh{}
+	for i := 0; i < count; i++ {
+		triangle := data[i*stlTriangleSize:]
+
+		// skip the stored normal, it is calculated from the corners
+		var corners [3]Vertex
+		for j := range corners {
+			offset := 12 + j*12
+			corners[j] = Vertex{
+				X: math.Float32frombits(binary.LittleEndian.Uint32(triangle[offset:])),
+				Y: math.Float32frombits(binary.LittleEndian.Uint32(triangle[offset+4:])),
+				Z: -math.Float32frombits(binary.LittleEndian.Uint32(triangle[offset+8:])),
+				R: 1, G: 1, B: 1,
+			}
+		}
+
+		if attribute := binary.LittleEndian.Uint16(triangle[48:]); attribute&stlColorValid != 0 {
+			r := float32(attribute>>10&31) / 31
+			g := float32(attribute>>5&31) / 31
+			b := float32(attribute&31) / 31
+			for j := range corners {
+				corners[j].R, corners[j].G, corners[j].B = r, g, b
+			}
+		}
+
+		mesh.appendFlatTriangle(corners[0], corners[2], corners[1])
+	}
+
+	return mesh
+}
+
+func readASCIISTL(r io.Reader) (*Mesh, error) {
+	mesh := &Mesh{}
+
+	var corners []Vertex
+	inFacet, inLoop := false, false
+	err := scanStatements(r, func(keyword string, args []string) error {
+		switch keyword {
+		case "solid", "endsolid":
+			if inFacet {
+				return fmt.Errorf("'%s' inside a facet", keyword)
+			}
+
+		case "facet":
+			if inFacet {
+				return errors.New("facet inside a facet")
+			}
+			inFacet = true
+			corners = corners[:0]
+
+		case "outer":
+			if !inFacet || inLoop {
+				return errors.New("'outer loop' outside of a facet")
+			}
+			inLoop = true
+
+		case "vertex":
+			if !inLoop {
+				return errors.New("vertex outside of a loop")
+			}
+			if len(corners) == 3 {
+				return errors.New("facet has more than 3 vertices")
+			}
+			if len(args) != 3 {
+				return fmt.Errorf("vertex needs 3 values, got %d", len(args))
+			}
+			values, err := parseFloats(args)
+			if err != nil {
+				return err
+			}
+			corners = append(corners, Vertex{X: values[0], Y: values[1], Z: -values[2], R: 1, G: 1, B: 1})
+
+		case "endloop":
+			if !inLoop {
+				return errors.New("endloop without a loop")
+			}
+			inLoop = false
+
+		case "endfacet":
+			if !inFacet || inLoop {
+				return errors.New("endfacet without a facet")
+			}
+			if len(corners) != 3 {
+				return fmt.Errorf("facet needs 3 vertices, got %d", len(corners))
+			}
+			inFacet = false
+			mesh.appendFlatTriangle(corners[0], corners[2], corners[1])
+
+		default:
+			return fmt.Errorf("unknown keyword '%s'", keyword)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if inFacet {
+		return nil, errors.New("stl: file ends inside a facet")
+	}
+
+	return mesh, nil
+}
+
+// WriteSTL writes the triangles of a mesh in ASCII or binary little endian STL, the only encodings STL has.
+// Binary files store the color of the first corner of each triangle unless the mesh is all white.
+func WriteSTL(w io.Writer, mesh *Mesh, encoding MeshEncoding) error {
+	switch encoding {
+	case EncodingASCII:
+		return writeASCIISTL(w, mesh)
+	case EncodingBinaryLittleEndian:
+		return writeBinarySTL(w, mesh)
+	default:
+		return errors.New("stl: only ASCII and binary little endian are supported")
+	}
+}
+
+// stlTriangle returns the corners of a triangle in the right-handed, counter clockwise order of STL files
+func stlTriangle(mesh *Mesh, triangle int) (a, b, c Vertex, normal Vector) {
+	a = mesh.Vertices[mesh.Indices[triangle*3]]
+	b = mesh.Vertices[mesh.Indices[triangle*3+2]]
+	c = mesh.Vertices[mesh.Indices[triangle*3+1]]
+	a.Z, b.Z, c.Z = -a.Z, -b.Z, -c.Z
+
+	// the cross product of the counter clockwise corners gives the outward normal in the right-handed coordinates of the file
+	normal = triangleNormal(Vector{a.X, a.Y, a.Z}, Vector{b.X, b.Y, b.Z}, Vector{c.X, c.Y, c.Z})
+
+	return a, b, c, normal
+}
+
+func writeASCIISTL(w io.Writer, mesh *Mesh) error {
+	writer := bufio.NewWriter(w)
+	format := func(v float32) string {
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	}
+
+	fmt.Fprintln(writer, "solid mesh")
+	for i := 0; i < len(mesh.Indices)/3; i++ {
+		a, b, c, normal := stlTriangle(mesh, i)
+
+		fmt.Fprintf(writer, "  facet normal %s %s %s\n", format(normal.X), format(normal.Y), format(normal.Z))
+		fmt.Fprintln(writer, "    outer loop")
+		for _, v := range []Vertex{a, b, c} {
+			fmt.Fprintf(writer, "      vertex %s %s %s\n", format(v.X), format(v.Y), format(v.Z))
+		}
+		fmt.Fprintln(writer, "    endloop")
+		fmt.Fprintln(writer, "  endfacet")
+	}
+	fmt.Fprintln(writer, "endsolid mesh")
+
+	return writer.Flush()
+}
+
+func writeBinarySTL(w io.Writer, mesh *Mesh) error {
+	colored := false
+	for _, v := range mesh.Vertices {
+		if v.R != 1 || v.G != 1 || v.B != 1 {
+			colored = true
+			break
+		}
+	}
+
+	count := len(mesh.Indices) / 3
+	data := make([]byte, stlHeaderSize+4+count*stlTriangleSize)
+	copy(data, "binary STL")
+	binary.LittleEndian.PutUint32(data[stlHeaderSize:], uint32(count))
+
+	channel := func(value float32) uint16 {
+		return uint16(math.Round(math.Max(0, math.Min(1, float64(value))) * 31))
+	}
+
+	for i := 0; i < count; i++ {
+		triangle := data[stlHeaderSize+4+i*stlTriangleSize:]
+		a, b, c, normal := stlTriangle(mesh, i)
+
+		values := []float32{normal.X, normal.Y, normal.Z, a.X, a.Y, a.Z, b.X, b.Y, b.Z, c.X, c.Y, c.Z}
+		for j, value := range values {
+			binary.LittleEndian.PutUint32(triangle[j*4:], math.Float32bits(value))
+		}
+
+		if colored {
+			attribute := stlColorValid | channel(a.R)<<10 | channel(a.G)<<5 | channel(a.B)
+			binary.LittleEndian.PutUint16(triangle[48:], attribute)
+		}
+	}
+
+	_, err := w.Write(data)
+	return err
+}
@@ -0,0 +1,193 @@
+package opengl_exercise
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+// testQuad returns two clockwise triangles with flat normals, the second one colored red
+func testQuad() *Mesh {
+	white := Vertex{R: 1, G: 1, B: 1}
+	corner := func(v Vertex, x, y, z float32) Vertex {
+		v.X, v.Y, v.Z = x, y, z
+		return v
+	}
+
+	mesh := &Mesh{}
+	mesh.appendFlatTriangle(corner(white, 0, 0, 1), corner(white, 0, 1, 1), corner(white, 1, 0, 1))
+	red := Vertex{R: 1}
+	mesh.appendFlatTriangle(corner(red, 1, 0, 1), corner(red, 0, 1, 1), corner(red, 1, 1, 1.5))
+	return mesh
+}
+
+func TestSTLRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding MeshEncoding
+		colors   bool
+	}{
+		{"ascii", EncodingASCII, false},
+		{"binary", EncodingBinaryLittleEndian, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mesh := testQuad()
+
+			var buffer bytes.Buffer
+			if err := WriteSTL(&buffer, mesh, test.encoding); err != nil {
+				t.Fatal(err)
+			}
+
+			read, err := ReadSTL(&buffer)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(read.Vertices) != len(mesh.Vertices) || len(read.Indices) != len(mesh.Indices) {
+				t.Fatalf("expected %d vertices and %d indices, got %d and %d", len(mesh.Vertices), len(mesh.Indices), len(read.Vertices), len(read.Indices))
+			}
+
+			for i, index := range mesh.Indices {
+				expected, got := mesh.Vertices[index], read.Vertices[read.Indices[i]]
+				if !test.colors {
+					expected.R, expected.G, expected.B = 1, 1, 1
+				}
+				if expected != got {
+					t.Errorf("corner %d: expected %+v, got %+v", i, expected, got)
+				}
+			}
+		})
+	}
+
+	if err := WriteSTL(&bytes.Buffer{}, testQuad(), EncodingBinaryBigEndian); err == nil {
+		t.Error("expected an error for big endian STL")
+	}
+}
+
+func TestReadSTL(t *testing.T) {
+	// a counter clockwise triangle facing +z in the right-handed space of the file
+	const ascii = `solid triangle
+  facet normal 0 0 1
+    outer loop
+      vertex 0 0 0
+      vertex 1 0 0
+      vertex 0 1 0
+    endloop
+  endfacet
+endsolid triangle
+`
+	// a binary file may start with "solid" too
+	binaryFile := make([]byte, stlHeaderSize+4+stlTriangleSize)
+	copy(binaryFile, "solid but binary")
+	binary.LittleEndian.PutUint32(binaryFile[stlHeaderSize:], 1)
+	for i, value := range []float32{0, 0, 1, 0, 0, 0, 1, 0, 0, 0, 1, 0} {
+		binary.LittleEndian.PutUint32(binaryFile[stlHeaderSize+4+i*4:], math.Float32bits(value))
+	}
+	binary.LittleEndian.PutUint16(binaryFile[stlHeaderSize+4+48:], stlColorValid|31<<5)
+
+	for name, data := range map[string][]byte{"ascii": []byte(ascii), "binary": binaryFile} {
+		t.Run(name, func(t *testing.T) {
+			mesh, err := ReadSTL(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(mesh.Vertices) != 3 || len(mesh.Indices) != 3 {
+				t.Fatalf("unexpected mesh %+v", mesh)
+			}
+
+			// the triangle faces -z after mirroring and is clockwise around its normal
+			v0, v1, v2 := mesh.Vertices[mesh.Indices[0]], mesh.Vertices[mesh.Indices[1]], mesh.Vertices[mesh.Indices[2]]
+			if normal := (Vector{v0.NX, v0.NY, v0.NZ}); normal != (Vector{0, 0, -1}) {
+				t.Errorf("expected normal (0, 0, -1), got %+v", normal)
+			}
+			edge1 := Vector{v1.X - v0.X, v1.Y - v0.Y, v1.Z - v0.Z}
+			edge2 := Vector{v2.X - v0.X, v2.Y - v0.Y, v2.Z - v0.Z}
+			if face := edge1.Cross(edge2); face.Z >= 0 {
+				t.Errorf("triangle is not clockwise around its normal: %+v", face)
+			}
+
+			color := Vector{v0.R, v0.G, v0.B}
+			if expected := map[string]Vector{"ascii": {1, 1, 1}, "binary": {0, 1, 0}}[name]; color != expected {
+				t.Errorf("expected color %+v, got %+v", expected, color)
+			}
+		})
+	}
+}
+
+func TestWriteSTLNormal(t *testing.T) {
+	const ascii = `solid triangle
+  facet normal 0 0 1
+    outer loop
+      vertex 0 0 0
+      vertex 1 0 0
+      vertex 0 1 0
+    endloop
+  endfacet
+endsolid triangle
+`
+	mesh, err := ReadSTL(strings.NewReader(ascii))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("ascii", func(t *testing.T) {
+		var buffer bytes.Buffer
+		if err := WriteSTL(&buffer, mesh, EncodingASCII); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buffer.String(), "facet normal 0 0 1\n") {
+			t.Errorf("expected the facet normal 0 0 1, got\n%s", buffer.String())
+		}
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		var buffer bytes.Buffer
+		if err := WriteSTL(&buffer, mesh, EncodingBinaryLittleEndian); err != nil {
+			t.Fatal(err)
+		}
+
+		facet := buffer.Bytes()[stlHeaderSize+4:]
+		var normal [3]float32
+		for i := range normal {
+			normal[i] = math.Float32frombits(binary.LittleEndian.Uint32(facet[i*4:]))
+		}
+		if normal != [3]float32{0, 0, 1} {
+			t.Errorf("expected the facet normal (0, 0, 1), got %v", normal)
+		}
+	})
+}
+
+func TestReadSTLInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		line  int
+		error string
+	}{
+		{"not stl", "hello", 0, "neither"},
+		{"vertex outside loop", "solid\nfacet normal 0 0 1\nvertex 0 0 0\n", 3, "outside of a loop"},
+		{"two vertices", "solid\nfacet normal 0 0 1\nouter loop\nvertex 0 0 0\nvertex 1 0 0\nendloop\nendfacet\n", 7, "needs 3 vertices"},
+		{"invalid number", "solid\nfacet normal 0 0 1\nouter loop\nvertex 0 x 0\n", 4, "invalid number"},
+		{"unknown keyword", "solid\nfacet normal 0 0 1\nouter loop\ncolor 1 0 0\n", 4, "unknown keyword"},
+		{"unfinished facet", "solid\nfacet normal 0 0 1\nouter loop\n", 0, "ends inside a facet"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ReadSTL(strings.NewReader(test.data))
+			if err == nil || !strings.Contains(err.Error(), test.error) {
+				t.Fatalf("expected error containing %q, got %v", test.error, err)
+			}
+
+			var parseErr *ParseError
+			if test.line > 0 && (!errors.As(err, &parseErr) || parseErr.Line != test.line) {
+				t.Errorf("expected the error on line %d, got %v", test.line, err)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math"
 	"runtime"
 	"syscall"
 	"time"
@@ -15,6 +16,7 @@ import (
 type System struct {
 	Fullscreen  bool
 	Vsync       bool
+	FieldOfView float32
 	ScreenDepth float32
 	ScreenNear  float32
 
@@ -29,6 +31,7 @@ func NewSystem(fullscreen, vsync bool) *System {
 	return &System{
 		Fullscreen:  fullscreen,
 		Vsync:       vsync,
+		FieldOfView: math.Pi / 4,
 		ScreenDepth: 1000,
 		ScreenNear:  0.1,
 	}
@@ -47,7 +50,7 @@ func (s *System) Initialize() error {
 		return errors.New("creating input: " + err.Error())
 	}
 
-	s.graphics, err = NewGraphics(s.opengl, s.hwnd)
+	s.graphics, err = NewGraphics(s.opengl, s.input, s.hwnd)
 	if err != nil {
 		return errors.New("creating graphics: " + err.Error())
 	}
@@ -183,7 +186,7 @@ func (s *System) initializeWindows() (width, height int, err error) {
 		return 0, 0, fmt.Errorf("Failed to create window(2): %d", w32.GetLastError())
 	}
 
-	if err := s.opengl.InitializeOpenGL(s.hwnd, width, height, s.ScreenDepth, s.ScreenNear, s.Vsync); err != nil {
+	if err := s.opengl.InitializeOpenGL(s.hwnd, width, height, s.FieldOfView, s.ScreenDepth, s.ScreenNear, s.Vsync); err != nil {
 		return 0, 0, errors.New("Could not initialize opengl, check if video card supports opengl 4.0: " + err.Error())
 	}
 
@@ -227,6 +230,29 @@ func (s *System) windowProc(hwnd syscall.Handle, msg uint32, wparam, lparam uint
 		s.input.KeyUp(uint32(wparam))
 		return 0
 
+	case w32.WM_MOUSEMOVE:
+		// the cursor position is packed into lparam as two signed 16bit values
+		s.input.MouseMove(int(int16(w32.LOWORD(uint32(lparam)))), int(int16(w32.HIWORD(uint32(lparam)))))
+		return 0
+
+	case w32.WM_LBUTTONDOWN, w32.WM_RBUTTONDOWN, w32.WM_MBUTTONDOWN:
+		// keep receiving mouse messages while dragging outside the window
+		w32.SetCapture(w32.HWND(hwnd))
+		s.input.MouseButtonDown(mouseButton(msg))
+		return 0
+
+	case w32.WM_LBUTTONUP, w32.WM_RBUTTONUP, w32.WM_MBUTTONUP:
+		s.input.MouseButtonUp(mouseButton(msg))
+		if !s.input.IsMouseButtonDown(MouseLeft) && !s.input.IsMouseButtonDown(MouseRight) && !s.input.IsMouseButtonDown(MouseMiddle) {
+			w32.ReleaseCapture()
+		}
+		return 0
+
+	case w32.WM_MOUSEWHEEL:
+		// the wheel movement is in the high word of wparam, in multiples of 120 per notch
+		s.input.MouseWheel(float32(int16(w32.HIWORD(uint32(wparam)))) / 120)
+		return 0
+
 	default:
 		return w32.DefWindowProc(w32.HWND(hwnd), msg, wparam, lparam)
 	}
@@ -239,5 +265,21 @@ func (s *System) frame(delta time.Duration) error {
 	}
 
 	// do frame processing for the graphics object
-	return s.graphics.Frame(delta)
+	err := s.graphics.Frame(delta)
+
+	// start collecting the mouse movement of the next frame
+	s.input.EndFrame()
+
+	return err
+}
+
+func mouseButton(msg uint32) MouseButton {
+	switch msg {
+	case w32.WM_RBUTTONDOWN, w32.WM_RBUTTONUP:
+		return MouseRight
+	case w32.WM_MBUTTONDOWN, w32.WM_MBUTTONUP:
+		return MouseMiddle
+	default:
+		return MouseLeft
+	}
 }
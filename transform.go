@@ -0,0 +1,125 @@
+package opengl_exercise
+
+import (
+	"errors"
+)
+
+// Transform places an object in the world with a position, rotation and scale relative to an optional parent.
+// The world matrix is cached and only rebuilt after the transform or one of its parents has changed.
+type Transform struct {
+	position Vector
+	rotation Quaternion
+	scale    Vector
+	parent   *Transform
+
+	worldMatrix Matrix
+	dirty       bool
+
+	// version is bumped every time the world matrix is rebuilt so children can tell their cached matrix is stale
+	version       uint64
+	parentVersion uint64
+}
+
+func NewTransform() *Transform {
+	return &Transform{
+		rotation: IdentityQuaternion(),
+		scale:    Vector{X: 1, Y: 1, Z: 1},
+		dirty:    true,
+	}
+}
+
+func (t *Transform) Position() Vector {
+	return t.position
+}
+
+func (t *Transform) SetPosition(position Vector) {
+	t.position = position
+	t.dirty = true
+}
+
+// Translate moves the transform by an offset in parent space
+func (t *Transform) Translate(offset Vector) {
+	t.SetPosition(t.position.AddVector(offset))
+}
+
+func (t *Transform) Rotation() Quaternion {
+	return t.rotation
+}
+
+func (t *Transform) SetRotation(rotation Quaternion) {
+	t.rotation = rotation.Normalize()
+	t.dirty = true
+}
+
+// Rotate applies a rotation in local space on top of the current one
+func (t *Transform) Rotate(rotation Quaternion) {
+	t.SetRotation(rotation.Multiply(t.rotation))
+}
+
+func (t *Transform) Scale() Vector {
+	return t.scale
+}
+
+func (t *Transform) SetScale(scale Vector) {
+	t.scale = scale
+	t.dirty = true
+}
+
+func (t *Transform) Parent() *Transform {
+	return t.parent
+}
+
+// SetParent attaches the transform to a parent, or detaches it when parent is nil
+func (t *Transform) SetParent(parent *Transform) error {
+	for p := parent; p != nil; p = p.parent {
+		if p == t {
+			return errors.New("transform can't be its own ancestor")
+		}
+	}
+
+	t.parent = parent
+	t.dirty = true
+	return nil
+}
+
+// LocalMatrix returns the transform relative to its parent, scaling first, then rotating, then translating
+func (t *Transform) LocalMatrix() Matrix {
+	scale := ScalingMatrix(t.scale.X, t.scale.Y, t.scale.Z)
+	rotation := t.rotation.Matrix()
+	translation := TranslationMatrix(t.position.X, t.position.Y, t.position.Z)
+
+	local := scale.Multiply(&rotation)
+	return local.Multiply(&translation)
+}
+
+// WorldMatrix returns the matrix that takes model space into world space
+func (t *Transform) WorldMatrix() Matrix {
+	var parentMatrix Matrix
+	if t.parent != nil {
+		parentMatrix = t.parent.WorldMatrix()
+		if t.parent.version != t.parentVersion {
+			t.dirty = true
+		}
+	}
+
+	if !t.dirty {
+		return t.worldMatrix
+	}
+
+	t.worldMatrix = t.LocalMatrix()
+	if t.parent != nil {
+		t.worldMatrix = t.worldMatrix.Multiply(&parentMatrix)
+		t.parentVersion = t.parent.version
+	}
+
+	t.dirty = false
+	t.version++
+
+	return t.worldMatrix
+}
+
+// WorldPosition returns the position of the transform origin in world space
+func (t *Transform) WorldPosition() Vector {
+	world := t.WorldMatrix()
+	return Vector{X: world[12], Y: world[13], Z: world[14]}
+}
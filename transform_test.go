@@ -0,0 +1,144 @@
+package opengl_exercise
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTransformLocalMatrix(t *testing.T) {
+	transform := NewTransform()
+	rotation := QuaternionFromAxisAngle(Vector{Y: 1}, math.Pi/2)
+	transform.SetScale(Vector{2, 2, 2})
+	transform.SetRotation(rotation)
+	transform.SetPosition(Vector{0, 0, 5})
+
+	// scaled first, then rotated, then moved
+	local := transform.LocalMatrix()
+	expected := rotation.Rotate(Vector{2, 0, 0}).AddVector(Vector{0, 0, 5})
+	if got := local.TransformPoint(Vector{1, 0, 0}); !vectorNearlyEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+
+	// without a parent the world matrix is the local one
+	if world := transform.WorldMatrix(); !matrixNearlyEqual(world, local) {
+		t.Errorf("expected the local matrix %v, got %v", local, world)
+	}
+}
+
+func TestTransformParentChain(t *testing.T) {
+	root := NewTransform()
+	root.SetPosition(Vector{10, 0, 0})
+	parent := NewTransform()
+	parent.SetParent(root)
+	parent.SetRotation(QuaternionFromAxisAngle(Vector{Y: 1}, math.Pi/2))
+	child := NewTransform()
+	child.SetParent(parent)
+	child.SetPosition(Vector{1, 0, 0})
+
+	// worldPosition is what the chain of local matrices gives, rebuilt from scratch
+	worldPosition := func() Vector {
+		parentLocal, rootLocal := parent.LocalMatrix(), root.LocalMatrix()
+		world := child.LocalMatrix()
+		world = world.Multiply(&parentLocal)
+		world = world.Multiply(&rootLocal)
+		return Vector{world[12], world[13], world[14]}
+	}
+
+	if got, expected := child.WorldPosition(), worldPosition(); !vectorNearlyEqual(got, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, got)
+	}
+
+	// nothing changed, so the cached matrices are returned without being rebuilt
+	version := child.version
+	child.WorldMatrix()
+	if child.version != version {
+		t.Errorf("expected the cached world matrix, got it rebuilt to version %d", child.version)
+	}
+
+	changes := []struct {
+		name   string
+		change func()
+	}{
+		{"root moved", func() { root.Translate(Vector{0, 5, 0}) }},
+		{"parent rotated", func() { parent.Rotate(QuaternionFromAxisAngle(Vector{Y: 1}, math.Pi/4)) }},
+		{"parent scaled", func() { parent.SetScale(Vector{3, 1, 3}) }},
+		// the parent matrix is rebuilt before the child asks for it, the child still has to notice
+		{"parent rebuilt first", func() { root.SetPosition(Vector{-4, 0, 2}); parent.WorldMatrix() }},
+		{"root rebuilt first", func() { root.SetScale(Vector{2, 2, 2}); root.WorldMatrix() }},
+		{"detached", func() { child.SetParent(nil) }},
+		{"attached to the root", func() { child.SetParent(root) }},
+	}
+
+	for _, change := range changes {
+		t.Run(change.name, func(t *testing.T) {
+			before := child.WorldPosition()
+			change.change()
+
+			var expected Vector
+			switch child.Parent() {
+			case parent:
+				expected = worldPosition()
+			case root:
+				rootWorld := root.WorldMatrix()
+				expected = rootWorld.TransformPoint(child.Position())
+			default:
+				expected = child.Position()
+			}
+
+			got := child.WorldPosition()
+			if !vectorNearlyEqual(got, expected) {
+				t.Errorf("expected %+v, got %+v", expected, got)
+			}
+			if vectorNearlyEqual(got, before) {
+				t.Errorf("expected the world position to move from %+v", before)
+			}
+		})
+	}
+}
+
+func TestTransformSetParentCycle(t *testing.T) {
+	a, b, c := NewTransform(), NewTransform(), NewTransform()
+	if err := b.SetParent(a); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetParent(b); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		child, parent  *Transform
+		expectedParent *Transform
+	}{
+		{"itself", a, a, nil},
+		{"its child", a, b, nil},
+		{"its grandchild", a, c, nil},
+		{"the middle under its child", b, c, a},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.child.SetParent(test.parent); err == nil {
+				t.Fatal("expected an error for a cycle")
+			}
+			if test.child.Parent() != test.expectedParent {
+				t.Error("expected the parent to be kept after the error")
+			}
+		})
+	}
+
+	// the chain still works and can be rearranged without cycles
+	if err := a.SetParent(nil); err != nil {
+		t.Errorf("detaching: unexpected error %v", err)
+	}
+	if err := c.SetParent(a); err != nil {
+		t.Errorf("moving to the grandparent: unexpected error %v", err)
+	}
+	if err := b.SetParent(c); err != nil {
+		t.Errorf("moving under the former child: unexpected error %v", err)
+	}
+	a.SetPosition(Vector{1, 2, 3})
+	if position := b.WorldPosition(); position != (Vector{1, 2, 3}) {
+		t.Errorf("expected b to follow a through c to %+v, got %+v", a.Position(), position)
+	}
+}
@@ -0,0 +1,35 @@
+package opengl_exercise
+
+// Vector4 is a homogeneous coordinate, used where the w component of a transformed Vector matters such as clip space
+type Vector4 struct {
+	X, Y, Z, W float32
+}
+
+// Vector4 extends the vector to homogeneous coordinates with the given w, 1 for points and 0 for directions
+func (v Vector) Vector4(w float32) Vector4 {
+	return Vector4{X: v.X, Y: v.Y, Z: v.Z, W: w}
+}
+
+func (v Vector4) MultiplyMatrix(m *Matrix) Vector4 {
+	return Vector4{
+		X: v.X*(*m)[0] + v.Y*(*m)[4] + v.Z*(*m)[8] + v.W*(*m)[12],
+		Y: v.X*(*m)[1] + v.Y*(*m)[5] + v.Z*(*m)[9] + v.W*(*m)[13],
+		Z: v.X*(*m)[2] + v.Y*(*m)[6] + v.Z*(*m)[10] + v.W*(*m)[14],
+		W: v.X*(*m)[3] + v.Y*(*m)[7] + v.Z*(*m)[11] + v.W*(*m)[15],
+	}
+}
+
+// PerspectiveDivide divides by w to go from clip space to normalized device coordinates.
+// Directions and points at infinity have no w to divide by and are returned as they are.
+func (v Vector4) PerspectiveDivide() Vector {
+	if v.W == 0 {
+		return v.Vector()
+	}
+
+	return Vector{X: v.X / v.W, Y: v.Y / v.W, Z: v.Z / v.W}
+}
+
+// Vector drops the w component without dividing by it
+func (v Vector4) Vector() Vector {
+	return Vector{X: v.X, Y: v.Y, Z: v.Z}
+}
@@ -0,0 +1,213 @@
+package opengl_exercise
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// VertexSemantic names what a vertex attribute holds, the shader input for it is called "input" followed by the semantic
+type VertexSemantic string
+
+const (
+	SemanticPosition    VertexSemantic = "Position"
+	SemanticColor       VertexSemantic = "Color"
+	SemanticNormal      VertexSemantic = "Normal"
+	SemanticTexCoord    VertexSemantic = "TexCoord"
+	SemanticTangent     VertexSemantic = "Tangent"
+	SemanticBitangent   VertexSemantic = "Bitangent"
+	SemanticBoneIndices VertexSemantic = "BoneIndices"
+	SemanticBoneWeights VertexSemantic = "BoneWeights"
+)
+
+// vertexSemantics lists the known semantics, the index of a semantic is its shader attribute location
+var vertexSemantics = []VertexSemantic{
+	SemanticPosition,
+	SemanticColor,
+	SemanticNormal,
+	SemanticTexCoord,
+	SemanticTangent,
+	SemanticBitangent,
+	SemanticBoneIndices,
+	SemanticBoneWeights,
+}
+
+// Location returns the shader attribute location of the semantic, which is the same in every layout, or -1 for an unknown semantic
+func (s VertexSemantic) Location() int {
+	for location, semantic := range vertexSemantics {
+		if semantic == s {
+			return location
+		}
+	}
+
+	return -1
+}
+
+// VertexAttributeType is the component type of a vertex attribute
+type VertexAttributeType int
+
+const (
+	AttributeFloat32 VertexAttributeType = iota
+	AttributeInt8
+	AttributeUint8
+	AttributeInt16
+	AttributeUint16
+	AttributeInt32
+	AttributeUint32
+)
+
+// Size returns the size of one component in bytes
+func (t VertexAttributeType) Size() int {
+	switch t {
+	case AttributeInt8, AttributeUint8:
+		return 1
+	case AttributeInt16, AttributeUint16:
+		return 2
+	default:
+		return 4
+	}
+}
+
+// VertexAttribute describes one attribute of the vertices in a vertex buffer
+type VertexAttribute struct {
+	Semantic   VertexSemantic
+	Components int
+	Type       VertexAttributeType
+
+	// Normalized integer attributes reach the shader as floats from 0 to 1, or -1 to 1 for signed types.
+	// Other integer attributes reach it as integers.
+	Normalized bool
+}
+
+// Name returns the shader input the attribute is bound to
+func (a VertexAttribute) Name() string {
+	return "input" + string(a.Semantic)
+}
+
+// Size returns the size of the attribute in bytes, without the padding
+func (a VertexAttribute) Size() int {
+	return a.Components * a.Type.Size()
+}
+
+// VertexLayout lists the attributes of interleaved vertices in the order they are stored.
+// Every attribute starts at a multiple of 4 bytes. Attributes are bound to the shader location of their semantic,
+// so one shader draws models of any layout.
+type VertexLayout struct {
+	Attributes []VertexAttribute
+}
+
+// DefaultVertexLayout returns the layout of the Vertex struct
+func DefaultVertexLayout() VertexLayout {
+	return VertexLayout{Attributes: []VertexAttribute{
+		{Semantic: SemanticPosition, Components: 3, Type: AttributeFloat32},
+		{Semantic: SemanticColor, Components: 3, Type: AttributeFloat32},
+		{Semantic: SemanticNormal, Components: 3, Type: AttributeFloat32},
+		{Semantic: SemanticTexCoord, Components: 2, Type: AttributeFloat32},
+		{Semantic: SemanticTangent, Components: 4, Type: AttributeFloat32},
+	}}
+}
+
+// Validate checks that the attributes can be uploaded, the layout needs a three component float position
+func (l VertexLayout) Validate() error {
+	seen := map[VertexSemantic]bool{}
+	for i, attribute := range l.Attributes {
+		if attribute.Semantic.Location() < 0 {
+			return fmt.Errorf("attribute %d has the unknown semantic '%s'", i, attribute.Semantic)
+		}
+		if attribute.Components < 1 || attribute.Components > 4 {
+			return fmt.Errorf("attribute %d (%s) has %d components, 1 to 4 are allowed", i, attribute.Semantic, attribute.Components)
+		}
+		if attribute.Type < AttributeFloat32 || attribute.Type > AttributeUint32 {
+			return fmt.Errorf("attribute %d (%s) has an unknown type", i, attribute.Semantic)
+		}
+		if attribute.Type == AttributeFloat32 && attribute.Normalized {
+			return fmt.Errorf("attribute %d (%s) is a normalized float", i, attribute.Semantic)
+		}
+		if seen[attribute.Semantic] {
+			return fmt.Errorf("attribute %s is listed twice", attribute.Semantic)
+		}
+		seen[attribute.Semantic] = true
+	}
+
+	if position, ok := l.Attribute(SemanticPosition); !ok || position.Components != 3 || position.Type != AttributeFloat32 {
+		return errors.New("layout needs a position of 3 floats")
+	}
+
+	return nil
+}
+
+// Equal tells whether both layouts list the same attributes in the same order
+func (l VertexLayout) Equal(other VertexLayout) bool {
+	if len(l.Attributes) != len(other.Attributes) {
+		return false
+	}
+	for i, attribute := range l.Attributes {
+		if attribute != other.Attributes[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Stride returns the size of one vertex in bytes
+func (l VertexLayout) Stride() int {
+	stride := 0
+	for _, attribute := range l.Attributes {
+		stride += align4(attribute.Size())
+	}
+
+	return stride
+}
+
+// Offset returns the byte offset of the attribute at the given index within a vertex
+func (l VertexLayout) Offset(index int) int {
+	offset := 0
+	for _, attribute := range l.Attributes[:index] {
+		offset += align4(attribute.Size())
+	}
+
+	return offset
+}
+
+// Index returns the position of a semantic in the attribute list, or -1 when the layout does not have it
+func (l VertexLayout) Index(semantic VertexSemantic) int {
+	for i, attribute := range l.Attributes {
+		if attribute.Semantic == semantic {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Attribute returns the attribute with the given semantic
+func (l VertexLayout) Attribute(semantic VertexSemantic) (VertexAttribute, bool) {
+	if index := l.Index(semantic); index >= 0 {
+		return l.Attributes[index], true
+	}
+
+	return VertexAttribute{}, false
+}
+
+// Positions reads the vertex positions out of interleaved vertex data in this layout
+func (l VertexLayout) Positions(data []byte) []Vector {
+	stride, offset := l.Stride(), l.Offset(l.Index(SemanticPosition))
+
+	positions := make([]Vector, len(data)/stride)
+	for i := range positions {
+		position := data[i*stride+offset:]
+		positions[i] = Vector{
+			X: math.Float32frombits(binary.LittleEndian.Uint32(position)),
+			Y: math.Float32frombits(binary.LittleEndian.Uint32(position[4:])),
+			Z: math.Float32frombits(binary.LittleEndian.Uint32(position[8:])),
+		}
+	}
+
+	return positions
+}
+
+func align4(size int) int {
+	return (size + 3) &^ 3
+}
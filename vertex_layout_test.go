@@ -0,0 +1,120 @@
+package opengl_exercise
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestDefaultVertexLayout(t *testing.T) {
+	layout := DefaultVertexLayout()
+	if err := layout.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the layout describes the memory of the Vertex struct, both have to change together
+	if stride := layout.Stride(); stride != int(unsafe.Sizeof(Vertex{})) {
+		t.Errorf("expected the stride %d of the Vertex struct, got %d", unsafe.Sizeof(Vertex{}), stride)
+	}
+
+	var vertex Vertex
+	offsets := map[VertexSemantic]uintptr{
+		SemanticPosition: unsafe.Offsetof(vertex.X),
+		SemanticColor:    unsafe.Offsetof(vertex.R),
+		SemanticNormal:   unsafe.Offsetof(vertex.NX),
+		SemanticTexCoord: unsafe.Offsetof(vertex.U),
+		SemanticTangent:  unsafe.Offsetof(vertex.TX),
+	}
+	if len(offsets) != len(layout.Attributes) {
+		t.Fatalf("expected %d attributes, got %d", len(offsets), len(layout.Attributes))
+	}
+	for semantic, expected := range offsets {
+		index := layout.Index(semantic)
+		if index < 0 {
+			t.Errorf("%s: missing from the layout", semantic)
+			continue
+		}
+		if offset := layout.Offset(index); offset != int(expected) {
+			t.Errorf("%s: expected offset %d, got %d", semantic, expected, offset)
+		}
+	}
+}
+
+func TestVertexLayoutPadding(t *testing.T) {
+	// byte and short attributes are padded to 4 bytes
+	layout := VertexLayout{Attributes: []VertexAttribute{
+		{Semantic: SemanticColor, Components: 3, Type: AttributeUint8, Normalized: true},
+		{Semantic: SemanticPosition, Components: 3, Type: AttributeFloat32},
+		{Semantic: SemanticTexCoord, Components: 1, Type: AttributeInt16},
+		{Semantic: SemanticBoneIndices, Components: 4, Type: AttributeUint8},
+		{Semantic: SemanticBoneWeights, Components: 3, Type: AttributeUint16, Normalized: true},
+	}}
+	if err := layout.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if stride := layout.Stride(); stride != 32 {
+		t.Errorf("expected stride 32, got %d", stride)
+	}
+	for i, expected := range []int{0, 4, 16, 20, 24} {
+		if offset := layout.Offset(i); offset != expected {
+			t.Errorf("attribute %d: expected offset %d, got %d", i, expected, offset)
+		}
+	}
+
+	// the shader location belongs to the semantic, not to the place in the list
+	if index, location := layout.Index(SemanticPosition), SemanticPosition.Location(); index != 1 || location != 0 {
+		t.Errorf("expected the position at index 1 and location 0, got %d and %d", index, location)
+	}
+	if index := layout.Index(SemanticNormal); index != -1 {
+		t.Errorf("expected no normal, got index %d", index)
+	}
+	if attribute, ok := layout.Attribute(SemanticBoneWeights); !ok || attribute.Components != 3 || attribute.Name() != "inputBoneWeights" {
+		t.Errorf("unexpected bone weights %+v", attribute)
+	}
+
+	// the positions are read from between the other attributes
+	var data bytes.Buffer
+	for _, position := range []Vector{{1, 2, 3}, {-4, 5, -6}} {
+		binary.Write(&data, binary.LittleEndian, [4]uint8{255, 0, 0})
+		binary.Write(&data, binary.LittleEndian, [3]float32{position.X, position.Y, position.Z})
+		binary.Write(&data, binary.LittleEndian, [2]int16{7})
+		binary.Write(&data, binary.LittleEndian, [4]uint8{1, 2, 3, 4})
+		binary.Write(&data, binary.LittleEndian, [4]uint16{0xffff})
+	}
+	positions := layout.Positions(data.Bytes())
+	if len(positions) != 2 || positions[0] != (Vector{1, 2, 3}) || positions[1] != (Vector{-4, 5, -6}) {
+		t.Errorf("unexpected positions %+v", positions)
+	}
+}
+
+func TestVertexLayoutValidate(t *testing.T) {
+	position := VertexAttribute{Semantic: SemanticPosition, Components: 3, Type: AttributeFloat32}
+
+	tests := []struct {
+		name       string
+		attributes []VertexAttribute
+		error      string
+	}{
+		{"no position", []VertexAttribute{{Semantic: SemanticColor, Components: 3, Type: AttributeFloat32}}, "needs a position"},
+		{"short position", []VertexAttribute{{Semantic: SemanticPosition, Components: 2, Type: AttributeFloat32}}, "needs a position"},
+		{"integer position", []VertexAttribute{{Semantic: SemanticPosition, Components: 3, Type: AttributeInt16}}, "needs a position"},
+		{"no components", []VertexAttribute{position, {Semantic: SemanticColor, Type: AttributeFloat32}}, "has 0 components"},
+		{"five components", []VertexAttribute{position, {Semantic: SemanticColor, Components: 5, Type: AttributeFloat32}}, "has 5 components"},
+		{"unknown type", []VertexAttribute{position, {Semantic: SemanticColor, Components: 3, Type: AttributeUint32 + 1}}, "unknown type"},
+		{"normalized float", []VertexAttribute{position, {Semantic: SemanticNormal, Components: 3, Type: AttributeFloat32, Normalized: true}}, "normalized float"},
+		{"twice", []VertexAttribute{position, position}, "listed twice"},
+		{"unknown semantic", []VertexAttribute{position, {Semantic: "Velocity", Components: 3, Type: AttributeFloat32}}, "unknown semantic"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := VertexLayout{Attributes: test.attributes}.Validate()
+			if err == nil || !strings.Contains(err.Error(), test.error) {
+				t.Errorf("expected error containing %q, got %v", test.error, err)
+			}
+		})
+	}
+}
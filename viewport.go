@@ -0,0 +1,102 @@
+package opengl_exercise
+
+import (
+	"math"
+)
+
+// Viewport is a rectangle of the window that shows the scene through its own camera
+type Viewport struct {
+	// X, Y, Width and Height are fractions of the window size, measured from the bottom left corner like gl.Viewport
+	X, Y          float32
+	Width, Height float32
+
+	Camera     *Camera
+	ClearColor [4]float32
+
+	// Order decides the drawing order, viewports with a higher order are drawn on top of the others
+	Order int
+}
+
+// NewViewport returns a viewport covering the given fraction of the window with a black background
+func NewViewport(x, y, width, height float32, camera *Camera) *Viewport {
+	return &Viewport{
+		X:          x,
+		Y:          y,
+		Width:      width,
+		Height:     height,
+		Camera:     camera,
+		ClearColor: [4]float32{0, 0, 0, 1},
+	}
+}
+
+// SplitScreenViewports divides the window between the cameras, side by side for two and in a grid for more
+func SplitScreenViewports(cameras ...*Camera) []*Viewport {
+	if len(cameras) == 0 {
+		return nil
+	}
+
+	columns := int(math.Ceil(math.Sqrt(float64(len(cameras)))))
+	rows := (len(cameras) + columns - 1) / columns
+
+	viewports := make([]*Viewport, len(cameras))
+	for i, camera := range cameras {
+		column, row := i%columns, i/columns
+
+		// fill the rows from the top of the window
+		viewports[i] = NewViewport(
+			float32(column)/float32(columns),
+			1-float32(row+1)/float32(rows),
+			1/float32(columns),
+			1/float32(rows),
+			camera,
+		)
+	}
+
+	return viewports
+}
+
+// PictureInPictureViewport returns a small viewport in the top right corner that is drawn over the rest.
+// size is the fraction of the window width and height it covers.
+func PictureInPictureViewport(camera *Camera, size float32) *Viewport {
+	const margin = 0.02
+
+	viewport := NewViewport(1-size-margin, 1-size-margin, size, size, camera)
+	viewport.Order = 1
+	return viewport
+}
+
+// Pixels returns the viewport rectangle in window pixels
+func (v *Viewport) Pixels(screenWidth, screenHeight int) (x, y, width, height int32) {
+	x = int32(math.Round(float64(v.X * float32(screenWidth))))
+	y = int32(math.Round(float64(v.Y * float32(screenHeight))))
+	width = int32(math.Round(float64((v.X+v.Width)*float32(screenWidth)))) - x
+	height = int32(math.Round(float64((v.Y+v.Height)*float32(screenHeight)))) - y
+	return x, y, width, height
+}
+
+// Aspect returns the width of the viewport divided by its height in pixels
+func (v *Viewport) Aspect(screenWidth, screenHeight int) float32 {
+	_, _, width, height := v.Pixels(screenWidth, screenHeight)
+	if height == 0 {
+		return 1
+	}
+
+	return float32(width) / float32(height)
+}
+
+// Contains tests whether a window pixel measured from the top left corner, like the mouse position, is inside the viewport
+func (v *Viewport) Contains(x, y, screenWidth, screenHeight int) bool {
+	left, bottom, width, height := v.Pixels(screenWidth, screenHeight)
+	flippedY := int32(screenHeight - 1 - y)
+
+	return int32(x) >= left && int32(x) < left+width && flippedY >= bottom && flippedY < bottom+height
+}
+
+// PickRay returns the world space ray through a window pixel as seen by the viewport camera.
+// x and y are measured from the top left corner of the window like the mouse position.
+func (v *Viewport) PickRay(opengl *OpenGL, x, y int) (Ray, error) {
+	screenWidth, screenHeight := opengl.ScreenSize()
+	left, bottom, width, height := v.Pixels(screenWidth, screenHeight)
+
+	return opengl.pickRay(x, y, [4]int32{left, bottom, width, height}, v.Camera.ViewMatrix(), v.Camera.ProjectionMatrixForAspect(v.Aspect(screenWidth, screenHeight)))
+}